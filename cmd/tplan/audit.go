@@ -0,0 +1,262 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yourusername/tplan/internal/apply"
+	"github.com/yourusername/tplan/internal/audit"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// auditPath returns the audit log file: the configured path, or
+// .tplan/audit.jsonl at the root of the git repository
+func (s *session) auditPath() string {
+	if s.cfg.Audit.Path != "" {
+		return s.cfg.Audit.Path
+	}
+	return filepath.Join(stateDir(), "audit.jsonl")
+}
+
+// recordAudit appends the outcome of an apply to the audit log. Failing to
+// record is reported but does not change the outcome of the apply.
+func (s *session) recordAudit(planResult *models.PlanResult, plans []rootPlan, result *apply.Result, hash string) {
+	record := audit.Record{
+		Time:     result.Started,
+		Duration: result.Finished.Sub(result.Started),
+		User:     osUser(),
+		GitUser:  gitUser("."),
+		Dir:      absPath("."),
+		Roots:    planResult.Roots,
+		Commit:   gitHead("."),
+		PlanHash: hash,
+		Summary:  planResult.Summary,
+		Status:   audit.StatusFailed,
+	}
+	record.Host, _ = os.Hostname()
+	record.Branch, record.Workspaces = s.planContext(planResult, plans)
+
+	if result.Succeeded() {
+		record.Status = audit.StatusSucceeded
+	}
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+
+	// Run-all applies run without events, so only their overall outcome is known
+	runAll := len(plans) > 0 && plans[0].outDir != ""
+	record.Resources = make([]audit.Resource, 0, len(result.Resources))
+	for _, state := range result.Resources {
+		status := audit.ResourceNotApplied
+		switch {
+		case runAll && result.Err == nil:
+			status = audit.ResourceApplied
+		case runAll:
+			status = audit.ResourceUnknown
+		case state.Status == apply.StatusApplied:
+			status = audit.ResourceApplied
+		case state.Status == apply.StatusFailed:
+			status = audit.ResourceFailed
+		}
+		record.Resources = append(record.Resources, audit.Resource{
+			Root:    state.Root,
+			Address: state.Address,
+			Action:  string(state.Action),
+			Status:  status,
+		})
+	}
+
+	if err := audit.Append(s.auditPath(), record); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+}
+
+// planHash returns the sha256 of the plan files of all successfully planned
+// roots, so an audit record identifies exactly what was applied
+func planHash(plans []rootPlan) string {
+	h := sha256.New()
+	for _, p := range plans {
+		if p.err != nil {
+			continue
+		}
+		file, err := os.Open(p.path())
+		if err != nil {
+			continue
+		}
+		io.WriteString(h, p.dir+"\x00")
+		io.Copy(h, file)
+		file.Close()
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// osUser returns the name of the user running tplan
+func osUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// gitUser returns the git identity configured for dir, like "Jane Doe <jane@example.com>"
+func gitUser(dir string) string {
+	get := func(key string) string {
+		cmd := exec.Command("git", "config", key)
+		cmd.Dir = dir
+		output, err := cmd.Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	name, email := get("user.name"), get("user.email")
+	switch {
+	case name != "" && email != "":
+		return name + " <" + email + ">"
+	case email != "":
+		return "<" + email + ">"
+	}
+	return name
+}
+
+// cmdAudit lists the applies recorded in the audit log, or shows one in detail
+func cmdAudit(args []string) error {
+	fs := newFlagSet("audit", "tplan audit [OPTIONS] [show N]",
+		"List the applies recorded in the audit log, newest first, or show the\nresources of one apply. N is the number shown in the list (1 is the newest).")
+	var common commonFlags
+	common.register(fs)
+	limit := fs.Int("n", 20, "Number of records to list (0 for all)")
+	jsonOut := fs.Bool("json", false, "Print the records as JSON lines")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+
+	path := s.auditPath()
+	records, err := audit.Read(path)
+	if err != nil {
+		return err
+	}
+
+	// Newest first
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	switch {
+	case fs.NArg() == 0:
+		if *limit > 0 && len(records) > *limit {
+			records = records[:*limit]
+		}
+		if *jsonOut {
+			return printAuditJSON(records)
+		}
+		if len(records) == 0 {
+			fmt.Printf("No applies recorded in %s yet.\n", path)
+			return nil
+		}
+		return listAudit(records)
+
+	case fs.NArg() == 2 && fs.Arg(0) == "show":
+		n, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || n < 1 || n > len(records) {
+			return fmt.Errorf("audit record %s does not exist (%d records)", fs.Arg(1), len(records))
+		}
+		if *jsonOut {
+			return printAuditJSON(records[n-1 : n])
+		}
+		showAudit(records[n-1])
+		return nil
+
+	default:
+		fs.Usage()
+		return &exitError{code: 2}
+	}
+}
+
+// printAuditJSON prints records as JSON lines
+func printAuditJSON(records []audit.Record) error {
+	encoder := json.NewEncoder(os.Stdout)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listAudit prints one line per apply
+func listAudit(records []audit.Record) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tTIME\tUSER\tWORKSPACE\tSTATUS\tCHANGES\tPLAN")
+	for i, record := range records {
+		summary := record.Summary
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t+%d ~%d -%d ±%d\t%s\n",
+			i+1, record.Time.Local().Format("2006-01-02 15:04"), auditWho(record),
+			orDash(strings.Join(record.Workspaces, ",")), record.Status,
+			summary.ToCreate, summary.ToUpdate, summary.ToDelete, summary.ToReplace,
+			shortCommit(record.PlanHash))
+	}
+	return w.Flush()
+}
+
+// showAudit prints an apply with every resource it changed
+func showAudit(record audit.Record) {
+	fmt.Printf("Time:       %s (%s)\n", record.Time.Local().Format("2006-01-02 15:04:05 MST"), record.Duration.Round(time.Second))
+	fmt.Printf("User:       %s\n", auditWho(record))
+	if record.Host != "" {
+		fmt.Printf("Host:       %s\n", record.Host)
+	}
+	fmt.Printf("Directory:  %s\n", record.Dir)
+	if len(record.Roots) > 0 {
+		fmt.Printf("Roots:      %s\n", strings.Join(record.Roots, ", "))
+	}
+	fmt.Printf("Workspace:  %s\n", orDash(strings.Join(record.Workspaces, ", ")))
+	if record.Branch != "" || record.Commit != "" {
+		fmt.Printf("Git:        %s@%s\n", orDash(record.Branch), shortCommit(record.Commit))
+	}
+	fmt.Printf("Plan hash:  %s\n", record.PlanHash)
+	fmt.Printf("Status:     %s\n", record.Status)
+	if record.Error != "" {
+		fmt.Printf("Error:      %s\n", record.Error)
+	}
+
+	summary := record.Summary
+	fmt.Printf("\nPlan: %d to add, %d to change, %d to destroy, %d to replace.\n\n",
+		summary.ToCreate, summary.ToUpdate, summary.ToDelete, summary.ToReplace)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tACTION\tSTATUS")
+	for _, res := range record.Resources {
+		address := res.Address
+		if res.Root != "" {
+			address = res.Root + ": " + address
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", address, res.Action, res.Status)
+	}
+	w.Flush()
+}
+
+// auditWho describes who applied, preferring the git identity
+func auditWho(record audit.Record) string {
+	if record.GitUser != "" {
+		return fmt.Sprintf("%s (%s)", record.GitUser, record.User)
+	}
+	return record.User
+}
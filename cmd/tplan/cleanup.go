@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// cleanup holds the functions that remove temporary files and locks when
+// tplan exits, including when it is interrupted
+var cleanup struct {
+	sync.Mutex
+	funcs []func()
+
+	// running are the terraform commands that have been started and not yet
+	// waited for
+	running map[*exec.Cmd]bool
+
+	// tui is set while the TUI runs; it quits on its own when interrupted
+	tui bool
+}
+
+// atExit registers fn to run when tplan exits. Functions run in reverse
+// order of registration, at most once.
+func atExit(fn func()) {
+	cleanup.Lock()
+	defer cleanup.Unlock()
+	cleanup.funcs = append(cleanup.funcs, fn)
+}
+
+// runCleanup runs the functions registered with atExit
+func runCleanup() {
+	cleanup.Lock()
+	funcs := cleanup.funcs
+	cleanup.funcs = nil
+	cleanup.Unlock()
+
+	for i := len(funcs) - 1; i >= 0; i-- {
+		funcs[i]()
+	}
+}
+
+// runCommand runs cmd like cmd.Run, but lets an interrupt wait for it to
+// finish instead of exiting while terraform still holds the plan or state
+func runCommand(cmd *exec.Cmd) error {
+	if err := startCommand(cmd); err != nil {
+		return err
+	}
+	return waitCommand(cmd)
+}
+
+// startCommand starts cmd and tracks it until waitCommand returns
+func startCommand(cmd *exec.Cmd) error {
+	cleanup.Lock()
+	defer cleanup.Unlock()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if cleanup.running == nil {
+		cleanup.running = make(map[*exec.Cmd]bool)
+	}
+	cleanup.running[cmd] = true
+	return nil
+}
+
+// waitCommand waits for a command started with startCommand
+func waitCommand(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	cleanup.Lock()
+	delete(cleanup.running, cmd)
+	cleanup.Unlock()
+	return err
+}
+
+// runningTUI marks the TUI as running until the returned function is called
+func runningTUI() func() {
+	cleanup.Lock()
+	cleanup.tui = true
+	cleanup.Unlock()
+	return func() {
+		cleanup.Lock()
+		cleanup.tui = false
+		cleanup.Unlock()
+	}
+}
+
+// handleSignals makes SIGINT and SIGTERM clean up before tplan exits.
+// While terraform runs, the signal is left to terraform: Ctrl-C reaches it
+// through the terminal and SIGTERM is passed on, so it can stop gracefully
+// and tplan cleans up when the command returns. A second signal, or one
+// while nothing runs, cleans up and exits immediately. The TUI quits by
+// itself and the command returns normally.
+func handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		interrupted := false
+		for sig := range signals {
+			cleanup.Lock()
+			commands := make([]*exec.Cmd, 0, len(cleanup.running))
+			for cmd := range cleanup.running {
+				commands = append(commands, cmd)
+			}
+			tui := cleanup.tui
+			cleanup.Unlock()
+
+			if tui && len(commands) == 0 && !interrupted {
+				interrupted = true
+				continue
+			}
+			if interrupted || len(commands) == 0 {
+				fmt.Fprintln(os.Stderr, "\nInterrupted, cleaning up...")
+				runCleanup()
+				os.Exit(130)
+			}
+			interrupted = true
+
+			fmt.Fprintln(os.Stderr, "\nInterrupted, waiting for terraform to stop (interrupt again to exit now)...")
+			if sig != os.Interrupt {
+				for _, cmd := range commands {
+					cmd.Process.Signal(sig)
+				}
+			}
+		}
+	}()
+}
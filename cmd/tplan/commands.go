@@ -0,0 +1,579 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/apply"
+	"github.com/yourusername/tplan/internal/config"
+	"github.com/yourusername/tplan/internal/diff"
+	"github.com/yourusername/tplan/internal/models"
+	"github.com/yourusername/tplan/internal/policy"
+	"github.com/yourusername/tplan/internal/report"
+	"github.com/yourusername/tplan/internal/tui"
+)
+
+// newFlagSet creates the flag set of a command with usage text listing its flags
+func newFlagSet(name, usage, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s\n\n%s\n\nOptions:\n", usage, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses command flags, turning -h into a clean exit. Flags the
+// command doesn't know are terraform arguments and are left in fs.Args().
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(separateTerraformArgs(fs, args)); err != nil {
+		if err == flag.ErrHelp {
+			return &exitError{code: 0}
+		}
+		return &exitError{code: 2}
+	}
+	return nil
+}
+
+// cmdRun is the default command: plan, show the TUI, and optionally apply.
+// It keeps the flags of the original single-command CLI.
+func cmdRun(args []string) error {
+	fs := flag.NewFlagSet("tplan", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	var roots rootFlags
+	roots.register(fs)
+	reportMode := fs.Bool("report", false, "Generate a Markdown report (report.md)")
+	format := fs.String("format", "", "Output format: tui or text (default: tui, or text when stdout is not a terminal)")
+	showNoise := fs.Bool("show-noise", false, "Show changes matching ignore rules")
+	out := fs.String("out", "", "Keep the binary plan in this file for a later 'tplan apply'")
+	versionFlag := fs.Bool("version", false, "Show version information")
+	fs.BoolVar(versionFlag, "v", false, "Show version information")
+	help := fs.Bool("help", false, "Show help message")
+	fs.BoolVar(help, "h", false, "Show help message")
+	fs.Usage = printHelp
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *versionFlag {
+		return cmdVersion(nil)
+	}
+
+	if *help {
+		printHelp()
+		return nil
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+	if err := s.setOutputFormat(*format); err != nil {
+		return err
+	}
+	s.showNoise = *showNoise
+	if err := s.setTerraformArgs(fs.Args()); err != nil {
+		return err
+	}
+	if err := s.selectRoots(&roots); err != nil {
+		return err
+	}
+	if *out != "" && (s.runAll || len(s.roots) > 1) {
+		return fmt.Errorf("-out needs exactly one root module")
+	}
+
+	// Plan into temporary plan files
+	planResult, plans, err := s.planAll()
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		if err := keepPlan(plans, *out); err != nil {
+			return err
+		}
+		fmt.Printf("\nSaved plan to %s\n", *out)
+	}
+
+	// If report mode is enabled, generate the report and exit
+	if *reportMode {
+		path := s.cfg.ReportPath(s.cfg.Report.Format)
+		if err := s.writeReport(planResult, s.cfg.Report.Format, path, *showNoise); err != nil {
+			return fmt.Errorf("failed to generate report: %w", err)
+		}
+		fmt.Printf("\n✓ Report generated: %s\n", path)
+		return nil
+	}
+
+	return s.view(planResult, plans)
+}
+
+// view runs the TUI and applies the plans if the user asked for it, or
+// prints the plan as text when the TUI is not used.
+// plans is empty when only a JSON plan is available.
+func (s *session) view(planResult *models.PlanResult, plans []rootPlan) error {
+	planFiles := make([]string, 0, len(plans))
+	for _, p := range plans {
+		if p.err == nil {
+			planFiles = append(planFiles, p.path())
+		}
+	}
+
+	opts := tui.Options{
+		TfCmd:       s.tfCmd,
+		PlanFile:    strings.Join(planFiles, ", "),
+		IgnoreRules: s.ignoreRules,
+		Grouping:    s.cfg.UI.Grouping,
+
+		Workspaces:        s.workspaces(planResult, plans),
+		ProductionPattern: s.cfg.ProductionPattern(),
+		ShowNoise:         s.showNoise,
+		DisableMouse:      config.Enabled(s.cfg.UI.DisableMouse),
+		Keys:              s.keys,
+	}
+
+	// Without a terminal there is nothing to interact with, and nothing to
+	// confirm an apply
+	if s.text {
+		fmt.Print("\n" + tui.RenderText(planResult, opts))
+		return nil
+	}
+
+	// Apply inside the TUI with live progress. Terragrunt run-all plans are
+	// applied by terragrunt after the TUI exits instead.
+	var applied bool
+	var applyErr error
+	if len(planFiles) > 0 && plans[0].outDir == "" {
+		opts.Apply = func(send func(apply.Event)) error {
+			applied = true
+			applyErr = s.streamApply(planResult, plans, send)
+			return applyErr
+		}
+	}
+
+	fmt.Println("\nLaunching TUI...")
+	done := runningTUI()
+	shouldApply, err := tui.Run(planResult, opts)
+	done()
+	if err != nil {
+		return fmt.Errorf("failed to run TUI: %w", err)
+	}
+
+	if applied {
+		if applyErr != nil {
+			return applyErr
+		}
+		fmt.Println("\n✓ Apply completed successfully")
+		return nil
+	}
+
+	// The user confirmed the apply in the TUI
+	if shouldApply {
+		return s.applyPlans(planResult, plans, true)
+	}
+	return nil
+}
+
+// cmdPlan runs terraform plan and keeps the plan file for later commands
+func cmdPlan(args []string) error {
+	fs := newFlagSet("plan", "tplan plan [OPTIONS] [-- TERRAFORM_ARGS...]",
+		"Run terraform plan, save the binary plan and print a summary.")
+	var common commonFlags
+	common.register(fs)
+	out := fs.String("out", "tplan.tfplan", "Write the binary plan to this file")
+	jsonOut := fs.String("json-out", "", "Also write the JSON plan to this file")
+	detailedExitCode := fs.Bool("detailed-exitcode", false, "Exit with 2 if the plan has changes (like terraform plan -detailed-exitcode)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+	if err := s.setTerraformArgs(fs.Args()); err != nil {
+		return err
+	}
+
+	if _, err := s.terraform(); err != nil {
+		return err
+	}
+	saved := s.savedPlan(*out)
+	if err := s.runPlan(saved.dir, saved.planFile, nil, nil); err != nil {
+		return err
+	}
+
+	fmt.Println("\nGenerating JSON output...")
+	jsonOutput, err := s.showJSON(saved.dir, saved.planFile)
+	if err != nil {
+		return err
+	}
+	if *jsonOut != "" {
+		if err := os.WriteFile(*jsonOut, jsonOutput, 0644); err != nil {
+			return fmt.Errorf("failed to write JSON plan: %w", err)
+		}
+	}
+
+	planResult, err := s.parsePlan(jsonOutput, saved.dir)
+	if err != nil {
+		return err
+	}
+	s.recordHistory(planResult, []rootPlan{saved})
+
+	printSummary(planResult)
+	fmt.Printf("Saved plan to %s\n", *out)
+
+	if *detailedExitCode && hasChanges(planResult) {
+		return &exitError{code: 2}
+	}
+	return nil
+}
+
+// cmdView shows a saved plan in the TUI, or creates a fresh one
+func cmdView(args []string) error {
+	fs := newFlagSet("view", "tplan view [OPTIONS] [PLAN_FILE]",
+		"Show a plan in the TUI. PLAN_FILE may be a binary plan or the output of\n'terraform show -json'; without it a new plan is created. Applying from\nthe TUI is only possible for binary plans. When stdout is not a terminal\nthe plan is printed as text.")
+	var common commonFlags
+	common.register(fs)
+	var roots rootFlags
+	roots.register(fs)
+	format := fs.String("format", "", "Output format: tui or text (default: tui, or text when stdout is not a terminal)")
+	showNoise := fs.Bool("show-noise", false, "Show changes matching ignore rules")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+	if err := s.setOutputFormat(*format); err != nil {
+		return err
+	}
+	s.showNoise = *showNoise
+
+	planFile, tfArgs := splitPlanFileArg(fs.Args())
+	if err := s.setTerraformArgs(tfArgs); err != nil {
+		return err
+	}
+	if planFile == "" {
+		if err := s.selectRoots(&roots); err != nil {
+			return err
+		}
+		planResult, plans, err := s.planAll()
+		if err != nil {
+			return err
+		}
+		return s.view(planResult, plans)
+	}
+
+	planResult, err := s.loadPlan(planFile)
+	if err != nil {
+		return err
+	}
+	if s.tfCmd == "" {
+		// A JSON plan cannot be applied
+		return s.view(planResult, nil)
+	}
+	return s.view(planResult, []rootPlan{s.savedPlan(planFile)})
+}
+
+// cmdReport writes a report for a saved plan, or for a fresh one
+func cmdReport(args []string) error {
+	fs := newFlagSet("report", "tplan report [OPTIONS] [PLAN_FILE]",
+		"Write a report for a plan. PLAN_FILE may be a binary plan or the output\nof 'terraform show -json'; without it a new plan is created.")
+	var common commonFlags
+	common.register(fs)
+	var roots rootFlags
+	roots.register(fs)
+	output := fs.String("o", "", "Write the report to this file, or - for stdout (default: report.path from the config, or report.md / report.html / report.txt)")
+	format := fs.String("format", "", "Report format: markdown, pr-comment, html or text (default: report.format from the config)")
+	templateFile := fs.String("template", "", "Render the Markdown report with this text/template file (default: report.template from the config)")
+	printTemplate := fs.Bool("print-template", false, "Print the built-in Markdown report template and exit")
+	showNoise := fs.Bool("show-noise", false, "Show changes matching ignore rules in the report")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *printTemplate {
+		fmt.Print(report.DefaultTemplate())
+		return nil
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+	if *templateFile != "" {
+		s.cfg.Report.Template = *templateFile
+	}
+	if *format == "" {
+		*format = s.cfg.Report.Format
+	}
+	if !config.ValidFormat(*format) {
+		return &exitError{code: 2, err: fmt.Errorf("unsupported report format %q", *format)}
+	}
+	if *output == "" {
+		*output = s.cfg.ReportPath(*format)
+	}
+	if *templateFile != "" && *format != config.FormatMarkdown {
+		return &exitError{code: 2, err: fmt.Errorf("-template only applies to the %s format", config.FormatMarkdown)}
+	}
+	if s.cfg.Report.Template != "" && *format == config.FormatMarkdown {
+		// Check the template before spending time on a plan
+		if _, err := report.LoadTemplate(s.cfg.Report.Template); err != nil {
+			return err
+		}
+	}
+
+	planResult, err := s.planFromArgs(fs.Args(), &roots)
+	if err != nil {
+		return err
+	}
+
+	if err := s.writeReport(planResult, *format, *output, *showNoise); err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+	if *output != "-" {
+		fmt.Printf("\n✓ Report generated: %s\n", *output)
+	}
+	return nil
+}
+
+// cmdApply applies a saved binary plan, or plans and applies in one go
+func cmdApply(args []string) error {
+	fs := newFlagSet("apply", "tplan apply [OPTIONS] [PLAN_FILE]",
+		"Apply a binary plan. Without PLAN_FILE new plans are created for the\nselected roots, summarised and applied after confirmation.")
+	var common commonFlags
+	common.register(fs)
+	var roots rootFlags
+	roots.register(fs)
+	autoApprove := fs.Bool("auto-approve", false, "Skip the confirmation prompt")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+
+	planFile, tfArgs := splitPlanFileArg(fs.Args())
+	if err := s.setTerraformArgs(tfArgs); err != nil {
+		return err
+	}
+	if planFile == "" {
+		if err := s.selectRoots(&roots); err != nil {
+			return err
+		}
+		planResult, plans, err := s.planAll()
+		if err != nil {
+			return err
+		}
+		printSummary(planResult)
+		if !hasChanges(planResult) {
+			fmt.Println("No changes. Nothing to apply.")
+			return nil
+		}
+		return s.applyPlans(planResult, plans, *autoApprove)
+	}
+
+	data, err := os.ReadFile(planFile)
+	if err != nil {
+		return fmt.Errorf("failed to read plan file: %w", err)
+	}
+	if isJSONPlan(data) {
+		return fmt.Errorf("%s is a JSON plan; apply needs the binary plan file", planFile)
+	}
+
+	// The parsed plan lists the changes for the audit log
+	planResult, err := s.loadPlan(planFile)
+	if err != nil {
+		return err
+	}
+	printSummary(planResult)
+	return s.applyPlans(planResult, []rootPlan{s.savedPlan(planFile)}, *autoApprove)
+}
+
+// cmdDiff compares the planned changes of two plans
+func cmdDiff(args []string) error {
+	fs := newFlagSet("diff", "tplan diff [OPTIONS] OLD_PLAN NEW_PLAN",
+		"Show how the planned changes differ between two plans, e.g. before and\nafter a code change. Plans may be binary or JSON.")
+	var common commonFlags
+	common.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return &exitError{code: 2}
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+
+	oldPlan, err := s.loadPlan(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	newPlan, err := s.loadPlan(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	s.printPlanDiff(oldPlan, newPlan, fs.Arg(0), fs.Arg(1))
+	return nil
+}
+
+// printPlanDiff prints how the planned changes of two plans differ
+func (s *session) printPlanDiff(oldPlan, newPlan *models.PlanResult, oldName, newName string) {
+	diffs := diff.ComparePlans(oldPlan, newPlan)
+	if len(diffs) == 0 {
+		fmt.Println("\nThe plans make the same changes.")
+		return
+	}
+
+	fmt.Printf("\n%d resource(s) differ:\n\n", len(diffs))
+	for _, d := range diffs {
+		address := d.Address
+		if d.Root != "" {
+			address = d.Root + ": " + address
+		}
+		switch d.Kind {
+		case diff.Added:
+			fmt.Printf("+ %s (%s, only in %s)\n", address, d.NewAction, newName)
+		case diff.Removed:
+			fmt.Printf("- %s (%s, only in %s)\n", address, d.OldAction, oldName)
+		default:
+			if d.OldAction != d.NewAction {
+				fmt.Printf("~ %s (%s -> %s)\n", address, d.OldAction, d.NewAction)
+			} else {
+				fmt.Printf("~ %s (%s)\n", address, d.NewAction)
+			}
+			s.ignoreRules.Apply(d.Type, d.Attributes)
+			for _, change := range d.Attributes.Changes() {
+				if change.Ignored {
+					continue
+				}
+				fmt.Printf("    %s %s: %s -> %s\n", change.Kind, change.Path,
+					truncate(change.BeforeString(), 60), truncate(change.AfterString(), 60))
+			}
+		}
+	}
+}
+
+// cmdCheck evaluates policies against a plan and fails on violations
+func cmdCheck(args []string) error {
+	fs := newFlagSet("check", "tplan check [OPTIONS] [PLAN_FILE]",
+		"Check a plan against policy files (from -policy and the policies list in\n.tplan.yaml) and built-in guards. Exits with 1 if an error-severity rule\nis violated.")
+	var common commonFlags
+	common.register(fs)
+	var roots rootFlags
+	roots.register(fs)
+	var policies stringList
+	fs.Var(&policies, "policy", "Policy file to evaluate (repeatable)")
+	denyDestroy := fs.Bool("deny-destroy", false, "Fail if any resource is destroyed or replaced")
+	maxChanges := fs.Int("max-changes", -1, "Fail if the plan changes more than this many resources")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+
+	planResult, err := s.planFromArgs(fs.Args(), &roots)
+	if err != nil {
+		return err
+	}
+
+	violations, err := policy.EvaluateAll(append(s.cfg.Policies, policies...), planResult)
+	if err != nil {
+		return err
+	}
+
+	builtin := &policy.Policy{Name: "built-in"}
+	if *denyDestroy {
+		builtin.Rules = append(builtin.Rules, policy.Rule{
+			Name:     "deny-destroy",
+			Actions:  []models.ChangeAction{models.ActionDelete, models.ActionReplace},
+			Severity: policy.SeverityError,
+		})
+	}
+	if *maxChanges >= 0 {
+		builtin.Rules = append(builtin.Rules, policy.Rule{
+			Name:     "max-changes",
+			Actions:  []models.ChangeAction{models.ActionCreate, models.ActionUpdate, models.ActionDelete, models.ActionReplace},
+			MaxCount: *maxChanges,
+			Severity: policy.SeverityError,
+		})
+	}
+	violations = append(violations, builtin.Evaluate(planResult)...)
+
+	printSummary(planResult)
+	if len(violations) == 0 {
+		fmt.Println("✓ All checks passed")
+		return nil
+	}
+
+	failed := false
+	for _, v := range violations {
+		level := "WARN "
+		if v.IsError() {
+			level = "ERROR"
+			failed = true
+		}
+		fmt.Printf("\n%s [%s] %s: %d resource(s)\n", level, v.Policy, v.Rule.Name, len(v.Resources))
+		if v.Rule.Description != "" {
+			fmt.Printf("      %s\n", v.Rule.Description)
+		}
+		for _, addr := range v.Resources {
+			fmt.Printf("      - %s\n", addr)
+		}
+	}
+
+	if failed {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// cmdVersion prints version information
+func cmdVersion(args []string) error {
+	fmt.Printf("tplan version %s\n", Version)
+	return nil
+}
+
+// planFromArgs loads the plan file given as the first argument, or creates
+// fresh plans for the selected roots passing the arguments to terraform
+func (s *session) planFromArgs(args []string, roots *rootFlags) (*models.PlanResult, error) {
+	planFile, tfArgs := splitPlanFileArg(args)
+	if err := s.setTerraformArgs(tfArgs); err != nil {
+		return nil, err
+	}
+	if planFile != "" {
+		return s.loadPlan(planFile)
+	}
+
+	if err := s.selectRoots(roots); err != nil {
+		return nil, err
+	}
+	planResult, plans, err := s.planAll()
+	removePlanFiles(plans)
+	return planResult, err
+}
+
+// truncate shortens a string to maxLen characters for one-line output
+func truncate(s string, maxLen int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
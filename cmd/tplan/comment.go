@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/yourusername/tplan/internal/config"
+	"github.com/yourusername/tplan/internal/prcomment"
+	"github.com/yourusername/tplan/internal/report"
+)
+
+// cmdComment posts the plan as a comment on the current pull request, or
+// updates the comment tplan posted before
+func cmdComment(args []string) error {
+	fs := newFlagSet("comment", "tplan comment [OPTIONS] [PLAN_FILE]",
+		"Post a plan summary as a pull request (GitHub) or merge request (GitLab)\ncomment. A previous comment with the same -id is updated in place.\nThe API token is read from GITHUB_TOKEN or GITLAB_TOKEN.")
+	var common commonFlags
+	common.register(fs)
+	var roots rootFlags
+	roots.register(fs)
+	platform := fs.String("platform", "", "github or gitlab (default: comment.platform from the config, or detected from CI)")
+	apiURL := fs.String("api-url", "", "API endpoint (default: comment.api_url from the user config, the CI environment or the public service)")
+	repo := fs.String("repo", "", "Repository as owner/name (GitHub) or project ID or path (GitLab) (default: from CI)")
+	number := fs.Int("pr", 0, "Pull request number or merge request IID (default: from CI)")
+	id := fs.String("id", "", "Comment ID, to keep one comment per plan, e.g. per environment (default: comment.id from the config)")
+	maxLength := fs.Int("max-length", 0, "Maximum comment size in bytes, at least 1024 (default: comment.max_length from the config, or 65536)")
+	showNoise := fs.Bool("show-noise", false, "Show changes matching ignore rules in the comment")
+	dryRun := fs.Bool("dry-run", false, "Print the comment instead of posting it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+	opts := s.commentOptions()
+	if *id != "" {
+		if !config.ValidCommentID(*id) {
+			return &exitError{code: 2, err: fmt.Errorf("invalid -id %q (expected up to 64 letters, digits, '.', '_', '-' or '/')", *id)}
+		}
+		opts.ID = *id
+	}
+	if *maxLength < 0 {
+		return &exitError{code: 2, err: fmt.Errorf("-max-length must be positive")}
+	}
+	if *maxLength > 0 {
+		opts.MaxLength = *maxLength
+	}
+	if opts.MaxLength > 0 && opts.MaxLength < report.MinCommentMaxLength {
+		return &exitError{code: 2, err: fmt.Errorf("comment size limit %d is below the minimum of %d bytes", opts.MaxLength, report.MinCommentMaxLength)}
+	}
+
+	// Check the destination before spending time on a plan
+	var client *prcomment.Client
+	if !*dryRun {
+		if *platform == "" {
+			*platform = s.cfg.Comment.Platform
+		}
+		client = prcomment.FromEnvironment(*platform)
+		if *apiURL == "" {
+			*apiURL = s.cfg.Comment.APIURL
+		}
+		if *apiURL != "" {
+			client.APIURL = *apiURL
+		}
+		if *repo != "" {
+			client.Repo = *repo
+		}
+		if *number != 0 {
+			client.Number = *number
+		}
+		if err := client.Validate(); err != nil {
+			return fmt.Errorf("cannot post comment: %w", err)
+		}
+	}
+
+	planResult, err := s.planFromArgs(fs.Args(), &roots)
+	if err != nil {
+		return err
+	}
+
+	gen := report.NewGenerator(planResult, s.drift).WithIgnoreRules(s.ignoreRules, *showNoise)
+	body := gen.GeneratePRComment(opts)
+	if *dryRun {
+		fmt.Fprint(os.Stdout, body)
+		return nil
+	}
+
+	created, err := client.Upsert(context.Background(), report.CommentMarker(opts.ID), body)
+	if err != nil {
+		return fmt.Errorf("failed to post comment: %w", err)
+	}
+	if created {
+		fmt.Printf("\n✓ Created comment on %s #%d\n", client.Repo, client.Number)
+	} else {
+		fmt.Printf("\n✓ Updated comment on %s #%d\n", client.Repo, client.Number)
+	}
+	return nil
+}
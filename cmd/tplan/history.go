@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yourusername/tplan/internal/config"
+	"github.com/yourusername/tplan/internal/history"
+	"github.com/yourusername/tplan/internal/models"
+	"github.com/yourusername/tplan/internal/tui"
+)
+
+// recordHistory saves a plan to the plan history. Failing to record is not
+// fatal; the plan itself succeeded.
+func (s *session) recordHistory(planResult *models.PlanResult, plans []rootPlan) {
+	if config.Enabled(s.cfg.History.Disabled) || planResult == nil {
+		return
+	}
+
+	entry := &history.Entry{
+		Dir:    absPath("."),
+		Roots:  planResult.Roots,
+		Args:   s.args.plan,
+		Commit: gitHead("."),
+		Plan:   planResult,
+	}
+	entry.Branch, entry.Workspaces = s.planContext(planResult, plans)
+
+	store := history.NewStore(s.cfg.History.Dir)
+	if err := store.Save(entry, s.cfg.History.Keep); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record plan history: %v\n", err)
+	}
+}
+
+// cmdHistory lists, shows and compares recorded plans
+func cmdHistory(args []string) error {
+	fs := newFlagSet("history", "tplan history [OPTIONS] [list | show REF | diff OLD NEW]",
+		"List the plans tplan recorded, open one in the TUI, or compare two.\nREF is the number shown by 'tplan history' (1 is the newest) or a\nprefix of the entry ID.")
+	var common commonFlags
+	common.register(fs)
+	limit := fs.Int("n", 20, "Number of entries to list (0 for all)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s, err := newSession(&common)
+	if err != nil {
+		return err
+	}
+	store := history.NewStore(s.cfg.History.Dir)
+
+	action := "list"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+	}
+	refs := fs.Args()
+	if len(refs) > 0 {
+		refs = refs[1:]
+	}
+
+	switch {
+	case action == "list" && len(refs) == 0:
+		return listHistory(store, *limit)
+	case action == "show" && len(refs) == 1:
+		if err := s.setOutputFormat(""); err != nil {
+			return err
+		}
+		entry, err := store.Load(refs[0])
+		if err != nil {
+			return err
+		}
+		return s.viewHistory(entry)
+	case action == "diff" && len(refs) == 2:
+		oldEntry, err := store.Load(refs[0])
+		if err != nil {
+			return err
+		}
+		newEntry, err := store.Load(refs[1])
+		if err != nil {
+			return err
+		}
+		s.printPlanDiff(oldEntry.Plan, newEntry.Plan, oldEntry.ID, newEntry.ID)
+		return nil
+	default:
+		fs.Usage()
+		return &exitError{code: 2}
+	}
+}
+
+// listHistory prints the newest recorded plans as a table
+func listHistory(store *history.Store, limit int) error {
+	entries, skipped, err := store.List()
+	if err != nil {
+		return err
+	}
+	for _, err := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: skipping unreadable entry: %v\n", err)
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No plans recorded in %s yet.\n", store.Dir())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tID\tTIME\tBRANCH\tCOMMIT\tWORKSPACE\tCHANGES\tARGS")
+	for i, entry := range entries {
+		if limit > 0 && i == limit {
+			break
+		}
+		summary := entry.Summary
+		commit := "-"
+		if entry.Commit != "" {
+			commit = shortCommit(entry.Commit)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t+%d ~%d -%d ±%d\t%s\n",
+			i+1, entry.ID, entry.Time.Local().Format("2006-01-02 15:04"),
+			orDash(entry.Branch), commit, orDash(strings.Join(entry.Workspaces, ",")),
+			summary.ToCreate, summary.ToUpdate, summary.ToDelete, summary.ToReplace,
+			truncate(strings.Join(entry.Args, " "), 50))
+	}
+	w.Flush()
+
+	if limit > 0 && len(entries) > limit {
+		fmt.Printf("\n%d older entries not shown (use -n 0 to list all)\n", len(entries)-limit)
+	}
+	return nil
+}
+
+// viewHistory opens a recorded plan in the TUI. Recorded plans cannot be applied.
+func (s *session) viewHistory(entry *history.Entry) error {
+	fmt.Printf("Plan %s from %s", entry.ID, entry.Time.Local().Format("2006-01-02 15:04:05"))
+	if entry.Branch != "" {
+		fmt.Printf(" on %s@%s", entry.Branch, shortCommit(entry.Commit))
+	}
+	fmt.Println()
+
+	opts := tui.Options{
+		IgnoreRules:  s.ignoreRules,
+		Grouping:     s.cfg.UI.Grouping,
+		DisableMouse: config.Enabled(s.cfg.UI.DisableMouse),
+		Keys:         s.keys,
+	}
+	if s.text {
+		fmt.Print("\n" + tui.RenderText(entry.Plan, opts))
+		return nil
+	}
+
+	done := runningTUI()
+	_, err := tui.Run(entry.Plan, opts)
+	done()
+	if err != nil {
+		return fmt.Errorf("failed to run TUI: %w", err)
+	}
+	return nil
+}
+
+// orDash returns "-" for empty table cells
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
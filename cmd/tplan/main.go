@@ -1,282 +1,99 @@
 package main
 
 import (
-	"bytes"
-	"flag"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
-
-	"github.com/yourusername/tplan/internal/git"
-	"github.com/yourusername/tplan/internal/models"
-	"github.com/yourusername/tplan/internal/parser"
-	"github.com/yourusername/tplan/internal/report"
-	"github.com/yourusername/tplan/internal/tui"
+	"strings"
 )
 
 // Version is set via ldflags during build
 var Version = "dev"
 
-func main() {
-	// Parse command-line flags
-	driftMode := flag.Bool("drift", false, "Enable drift detection and git integration")
-	reportMode := flag.Bool("report", false, "Generate a Markdown report (report.md)")
-	versionFlag := flag.Bool("version", false, "Show version information")
-	flag.BoolVar(versionFlag, "v", false, "Show version information")
-	help := flag.Bool("help", false, "Show help message")
-	flag.BoolVar(help, "h", false, "Show help message")
-	flag.Parse()
-
-	if *versionFlag {
-		fmt.Printf("tplan version %s\n", Version)
-		os.Exit(0)
-	}
-
-	if *help {
-		printHelp()
-		os.Exit(0)
-	}
-
-	// Check if terraform or tofu is installed
-	tfCmd := findTerraformCommand()
-	if tfCmd == "" {
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════\n")
-		fmt.Fprintf(os.Stderr, "  ERROR: Neither Terraform nor OpenTofu is installed\n")
-		fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════\n")
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "tplan requires either Terraform or OpenTofu to be installed.\n")
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "Install Terraform:\n")
-		fmt.Fprintf(os.Stderr, "  https://developer.hashicorp.com/terraform/install\n")
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "Or install OpenTofu:\n")
-		fmt.Fprintf(os.Stderr, "  https://opentofu.org/docs/intro/install/\n")
-		fmt.Fprintf(os.Stderr, "\n")
-		os.Exit(1)
-	}
-
-	fmt.Printf("Using: %s\n", tfCmd)
-
-	// Create temporary plan file
-	planFile := filepath.Join(".", ".tplan-temp.tfplan")
-
-	// Get any additional arguments to pass to terraform plan
-	planArgs := flag.Args()
-
-	// Run terraform plan -out=<planfile>
-	fmt.Printf("\nRunning: %s plan -out=%s", tfCmd, planFile)
-	if len(planArgs) > 0 {
-		fmt.Printf(" %v", planArgs)
-	}
-	fmt.Println()
-
-	if err := runTerraformPlan(tfCmd, planFile, planArgs); err != nil {
-		fmt.Fprintf(os.Stderr, "\nError running terraform plan: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Run terraform show -json <planfile>
-	fmt.Println("\nGenerating JSON output...")
-	jsonOutput, err := runTerraformShow(tfCmd, planFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating JSON output: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Parse the JSON output
-	p := parser.NewParser()
-	planResult, err := p.ParseBytes(jsonOutput)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing plan: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Always enrich with file information for grouping
-	// This populates the FilePath in DriftInfo even without full drift mode
-	if err := enrichWithFileInfo(planResult, *driftMode); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Could not get file information: %v\n", err)
-		// Continue anyway - we'll show the plan without file info
-	}
-
-	// If report mode is enabled, generate the report and exit
-	if *reportMode {
-		if err := generateReport(planResult, *driftMode); err != nil {
-			fmt.Fprintf(os.Stderr, "Error generating report: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Println("\n✓ Report generated: report.md")
-		os.Exit(0)
-	}
-
-	// Run the TUI
-	fmt.Println("\nLaunching TUI...")
-	shouldApply, err := tui.Run(planResult, tfCmd, planFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
-		// Clean up plan file before exiting
-		os.Remove(planFile)
-		os.Exit(1)
-	}
-
-	// If user pressed 'a', run terraform apply
-	if shouldApply {
-		// Ask for confirmation
-		fmt.Print("\nAre you sure you want to apply this plan? (yes/no): ")
-		var response string
-		fmt.Scanln(&response)
-
-		if response != "yes" {
-			fmt.Println("Apply cancelled.")
-			// Clean up plan file
-			os.Remove(planFile)
-			os.Exit(0)
-		}
-
-		fmt.Println("\nApplying plan...")
-		if err := runTerraformApply(tfCmd, planFile); err != nil {
-			fmt.Fprintf(os.Stderr, "\nError running terraform apply: %v\n", err)
-			// Clean up plan file before exiting
-			os.Remove(planFile)
-			os.Exit(1)
-		}
-		fmt.Println("\n✓ Apply completed successfully")
-	}
-
-	// Clean up plan file
-	if err := os.Remove(planFile); err != nil && !os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Warning: failed to clean up temp file %s: %v\n", planFile, err)
-	}
-}
-
-// findTerraformCommand checks for terraform or tofu and returns the command to use
-func findTerraformCommand() string {
-	// Check for terraform first
-	if _, err := exec.LookPath("terraform"); err == nil {
-		return "terraform"
-	}
-
-	// Check for tofu as fallback
-	if _, err := exec.LookPath("tofu"); err == nil {
-		return "tofu"
-	}
-
-	return ""
+// commands maps subcommand names to their entry points
+var commands = map[string]func(args []string) error{
+	"plan":    cmdPlan,
+	"view":    cmdView,
+	"report":  cmdReport,
+	"apply":   cmdApply,
+	"diff":    cmdDiff,
+	"check":   cmdCheck,
+	"history": cmdHistory,
+	"audit":   cmdAudit,
+	"comment": cmdComment,
+	"version": cmdVersion,
 }
 
-// runTerraformPlan runs terraform/tofu plan and saves to a file
-func runTerraformPlan(tfCmd, planFile string, extraArgs []string) error {
-	args := []string{"plan", "-out=" + planFile}
-	args = append(args, extraArgs...)
-
-	cmd := exec.Command(tfCmd, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-
-	return cmd.Run()
+// exitError ends the program with a specific exit code.
+// If err is nil, nothing is printed.
+type exitError struct {
+	code int
+	err  error
 }
 
-// runTerraformShow runs terraform/tofu show -json and returns the output
-func runTerraformShow(tfCmd, planFile string) ([]byte, error) {
-	var stdout bytes.Buffer
-
-	cmd := exec.Command(tfCmd, "show", "-json", planFile)
-	cmd.Stdout = &stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, err
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
 	}
-
-	return stdout.Bytes(), nil
+	return e.err.Error()
 }
 
-// runTerraformApply runs terraform/tofu apply with the plan file
-func runTerraformApply(tfCmd, planFile string) error {
-	cmd := exec.Command(tfCmd, "apply", planFile)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
+// stringList is a repeatable string flag
+type stringList []string
 
-	return cmd.Run()
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
 }
 
-func generateReport(planResult *models.PlanResult, includeDrift bool) error {
-	gen := report.NewGenerator(planResult, includeDrift)
-	return gen.WriteToFile("report.md")
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
-func enrichWithFileInfo(planResult *models.PlanResult, fullDriftMode bool) error {
-	// Get current directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
-	}
-
-	// Initialize git repository
-	repo, err := git.NewRepository(cwd)
-	if err != nil {
-		return fmt.Errorf("failed to initialize git repository: %w", err)
-	}
-
-	// For each resource change, try to get git/file information
-	for i := range planResult.Resources {
-		resource := &planResult.Resources[i]
-
-		// Get full drift info for this resource (includes file path and git info)
-		driftInfo, err := repo.GetDriftInfo(resource.Address)
-		if err != nil {
-			// Not a critical error - just skip this resource
-			continue
+func main() {
+	handleSignals()
+	if err := runAndCleanUp(os.Args[1:]); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", exitErr.err)
+			}
+			os.Exit(exitErr.code)
 		}
-
-		// Always attach the full drift info
-		// This provides file grouping and git information
-		resource.DriftInfo = driftInfo
+		fmt.Fprintf(os.Stderr, "\nError: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	// Second pass: for deleted resources without file info, try to find their replacement
-	for i := range planResult.Resources {
-		resource := &planResult.Resources[i]
-
-		// Only process deleted resources without drift info
-		if resource.Action != models.ActionDelete || resource.DriftInfo != nil {
-			continue
-		}
+// runAndCleanUp runs a command and removes its temporary files and locks
+// afterwards, even if it panics
+func runAndCleanUp(args []string) error {
+	defer runCleanup()
+	return run(args)
+}
 
-		// Look for a create operation with the same type and index
-		for j := range planResult.Resources {
-			other := &planResult.Resources[j]
+// run dispatches to a subcommand. Without one (no arguments, or only flags
+// and terraform arguments) tplan plans, shows the TUI and offers to apply.
+func run(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return cmdRun(args)
+	}
 
-			// Check if this is a potential replacement (same type, same index, create action)
-			if other.Action == models.ActionCreate &&
-				other.Type == resource.Type &&
-				indexMatches(other.Index, resource.Index) &&
-				other.DriftInfo != nil {
-				// Copy the drift info from the replacement
-				resource.DriftInfo = other.DriftInfo
-				break
+	if args[0] == "help" {
+		if len(args) > 1 {
+			if cmd, ok := commands[args[1]]; ok {
+				return cmd([]string{"-h"})
 			}
 		}
+		printHelp()
+		return nil
 	}
 
-	return nil
-}
-
-// indexMatches checks if two resource indices match
-func indexMatches(idx1, idx2 interface{}) bool {
-	// Handle nil cases
-	if idx1 == nil && idx2 == nil {
-		return true
-	}
-	if idx1 == nil || idx2 == nil {
-		return false
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return &exitError{code: 2, err: fmt.Errorf("unknown command %q (run 'tplan help' for usage)", args[0])}
 	}
-
-	// Compare as strings to handle both int and string indices
-	return fmt.Sprintf("%v", idx1) == fmt.Sprintf("%v", idx2)
+	return cmd(args[1:])
 }
 
 func printHelp() {
@@ -287,24 +104,53 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("USAGE:")
 	fmt.Println("  tplan [OPTIONS] [TERRAFORM_ARGS...]")
-	fmt.Println()
-	fmt.Println("  tplan runs 'terraform plan' (or 'tofu plan'), captures the output,")
-	fmt.Println("  and displays it in an interactive TUI.")
+	fmt.Println("  tplan <COMMAND> [OPTIONS] [ARGS...]")
+	fmt.Println()
+	fmt.Println("  Without a command, tplan runs 'terraform plan' (or 'tofu plan'), captures")
+	fmt.Println("  the output, and displays it in an interactive TUI.")
+	fmt.Println()
+	fmt.Println("COMMANDS:")
+	fmt.Println("  plan      Run terraform plan and save the plan file (-out, -json-out)")
+	fmt.Println("  view      Show a saved binary or JSON plan in the TUI")
+	fmt.Println("  report    Write a report for a saved or fresh plan (-o, -format markdown|pr-comment|html|text)")
+	fmt.Println("  apply     Apply a saved plan, or plan and apply after confirmation")
+	fmt.Println("  diff      Compare the planned changes of two plans")
+	fmt.Println("  check     Check a plan against policies; exits 1 on violations")
+	fmt.Println("  history   List recorded plans, open one in the TUI or diff two")
+	fmt.Println("  audit     Show the log of applies (who, when, where, what)")
+	fmt.Println("  comment   Post the plan as a GitHub or GitLab pull request comment")
+	fmt.Println("  version   Show version information")
+	fmt.Println("  help      Show this help, or 'tplan help <command>' for a command")
 	fmt.Println()
 	fmt.Println("OPTIONS:")
 	fmt.Println("  -drift        Enable drift detection with git integration")
 	fmt.Println("                Shows git commit, branch, and author info for resources")
 	fmt.Println("  -report       Generate a Markdown report (report.md) and exit")
 	fmt.Println("                Use with -drift to include git information in the report")
+	fmt.Println("  -root         Root module directory or glob to plan (repeatable)")
+	fmt.Println("                Plans run in parallel and are shown grouped by root")
+	fmt.Println("  -concurrency  Maximum number of root modules planned at once (default: 4)")
+	fmt.Println("  -run-all      Plan every terragrunt unit below the working directory")
+	fmt.Println("                with 'terragrunt run-all', in dependency order")
+	fmt.Println("  -out          Keep the binary plan in this file for a later 'tplan apply FILE'")
+	fmt.Println("  -config       Use this config file instead of .tplan.yaml")
+	fmt.Println("  -ignore-file  File with ignore rules for noisy attributes (default: .tplanignore)")
+	fmt.Println("  -format       Output format: tui or text (default: tui, or text when stdout")
+	fmt.Println("                is not a terminal; colors follow NO_COLOR)")
+	fmt.Println("  -show-noise   Include changes matching ignore rules")
 	fmt.Println("  -v, -version  Show version information")
 	fmt.Println("  -h, -help     Show this help message")
 	fmt.Println()
 	fmt.Println("TERRAFORM ARGUMENTS:")
-	fmt.Println("  Any additional arguments are passed directly to terraform/tofu plan.")
+	fmt.Println("  Other flags are passed to terraform/tofu: each goes to the commands that")
+	fmt.Println("  accept it, e.g. -var-file to plan, -lock-timeout to plan and apply.")
+	fmt.Println("  -chdir=DIR runs terraform in DIR. Arguments after -- always go to")
+	fmt.Println("  terraform. TF_CLI_ARGS and TF_CLI_ARGS_{plan,show,apply} are routed too.")
 	fmt.Println()
 	fmt.Println("  Examples:")
 	fmt.Println("    tplan -target=aws_instance.example")
-	fmt.Println("    tplan -var-file=prod.tfvars")
+	fmt.Println("    tplan -var-file=prod.tfvars -lock-timeout=5m")
+	fmt.Println("    tplan -chdir=envs/prod")
 	fmt.Println("    tplan -destroy")
 	fmt.Println()
 	fmt.Println("EXAMPLES:")
@@ -326,17 +172,52 @@ func printHelp() {
 	fmt.Println("  # Use variable file")
 	fmt.Println("  tplan -var-file=production.tfvars")
 	fmt.Println()
-	fmt.Println("KEYBOARD CONTROLS:")
+	fmt.Println("  # Plan every environment and region in one TUI")
+	fmt.Println("  tplan -root 'envs/*/*'")
+	fmt.Println()
+	fmt.Println("  # CI: plan once, check policies, report and apply the same plan")
+	fmt.Println("  tplan plan -out=ci.tfplan -detailed-exitcode")
+	fmt.Println("  tplan check -deny-destroy ci.tfplan")
+	fmt.Println("  tplan report -o plan.md ci.tfplan")
+	fmt.Println("  tplan apply -auto-approve ci.tfplan")
+	fmt.Println()
+	fmt.Println("  # Review now, apply exactly this plan later")
+	fmt.Println("  tplan -out=reviewed.tfplan")
+	fmt.Println("  tplan apply reviewed.tfplan")
+	fmt.Println()
+	fmt.Println("  # What did the plan say before the incident?")
+	fmt.Println("  tplan history")
+	fmt.Println("  tplan history diff 5 1")
+	fmt.Println()
+	fmt.Println("KEYBOARD CONTROLS (defaults; see ui.keymap and ui.keybindings):")
 	fmt.Println("  ↑/↓, j/k      Navigate up/down")
-	fmt.Println("  Enter, Space  Expand/collapse resource")
+	fmt.Println("  Enter, Space  Expand/collapse a group, or open the details of a resource")
+	fmt.Println("  ←/→, h/l      Collapse/expand (← on a collapsed node jumps to its group)")
+	fmt.Println("  PgUp/PgDn     Scroll the detail pane (also Ctrl+U/Ctrl+D)")
+	fmt.Println("  /             Search the detail pane; n/N next/previous match")
+	fmt.Println("  Esc           Clear the search, then return from the detail pane to the tree")
 	fmt.Println("  e             Expand all")
 	fmt.Println("  c             Collapse all")
+	fmt.Println("  E             Expand everything in the selected group")
+	fmt.Println("  C             Collapse everything in the selected group")
 	fmt.Println("  Tab           Switch between Changes/Errors/Warnings")
+	fmt.Println("  n             Show/hide changes matching ignore rules")
 	fmt.Println("  g             Jump to top")
 	fmt.Println("  G             Jump to bottom")
+	fmt.Println("  a             Apply (asks for confirmation)")
+	fmt.Println("  f, s          After an apply: next failed resource, apply summary")
+	fmt.Println("  y + y/t/s/b/a/d  Copy the address, -target, state show command, before/after/changes JSON")
+	fmt.Println("  ?             Show all key bindings")
 	fmt.Println("  q             Quit")
+	fmt.Println("  Mouse         Click tabs and nodes, scroll with the wheel, drag the pane divider")
+	fmt.Println()
+	fmt.Println("CONFIGURATION:")
+	fmt.Println("  Settings are read from the user config file (e.g. ~/.config/tplan/config.yaml),")
+	fmt.Println("  then .tplan.yaml at the repository root, then .tplan.yaml in the working")
+	fmt.Println("  directory. Later files override earlier ones; flags override all of them.")
 	fmt.Println()
 	fmt.Println("REQUIREMENTS:")
 	fmt.Println("  Either Terraform or OpenTofu must be installed and available in PATH.")
+	fmt.Println("  Directories with a terragrunt.hcl are planned with terragrunt.")
 	fmt.Println()
 }
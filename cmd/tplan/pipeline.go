@@ -0,0 +1,770 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/yourusername/tplan/internal/apply"
+	"github.com/yourusername/tplan/internal/config"
+	"github.com/yourusername/tplan/internal/git"
+	"github.com/yourusername/tplan/internal/ignore"
+	"github.com/yourusername/tplan/internal/lock"
+	"github.com/yourusername/tplan/internal/models"
+	"github.com/yourusername/tplan/internal/parser"
+	"github.com/yourusername/tplan/internal/report"
+	"github.com/yourusername/tplan/internal/tui"
+	"github.com/yourusername/tplan/internal/workspace"
+)
+
+// commonFlags are the flags shared by every command that loads a plan
+type commonFlags struct {
+	drift      bool
+	configFile string
+	ignoreFile string
+}
+
+// register adds the common flags to a command's flag set
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&c.drift, "drift", false, "Enable drift detection and git integration")
+	fs.StringVar(&c.configFile, "config", "", "Use this config file instead of .tplan.yaml")
+	fs.StringVar(&c.ignoreFile, "ignore-file", ignore.DefaultFile, "File with ignore rules for attributes that always churn")
+}
+
+// session holds the configuration shared by the steps of a command
+type session struct {
+	cfg         *config.Config
+	ignoreRules ignore.Rules
+	drift       bool
+	tfCmd       string
+
+	// roots are the root module directories to plan; empty means the working directory
+	roots []string
+
+	// runAll plans every terragrunt unit below the working directory with run-all
+	runAll bool
+
+	// args are the terraform arguments for plan, show and apply
+	args terraformArgs
+
+	// tempDir is the private directory for temporary plan files, created on first use
+	tempDir string
+
+	// text prints plans as static text instead of running the TUI
+	text bool
+
+	// showNoise shows changes matching ignore rules in the TUI and text output
+	showNoise bool
+
+	// keys are the TUI key bindings from ui.keymap and ui.keybindings
+	keys *tui.KeyMap
+}
+
+// newSession loads the layered configuration and ignore rules
+func newSession(common *commonFlags) (*session, error) {
+	// Load layered configuration (user config, repo root, working directory)
+	cfg, err := config.Load(".", common.configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Load ignore rules for noisy attributes from the config and the ignore file
+	fileRules, err := ignore.LoadFile(common.ignoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore rules: %w", err)
+	}
+
+	return &session{
+		cfg:         cfg,
+		ignoreRules: append(cfg.IgnoreRules(), fileRules...),
+		drift:       common.drift,
+		text:        !stdoutIsTerminal(),
+	}, nil
+}
+
+// outputTUI is the -format value that selects the interactive TUI
+const outputTUI = "tui"
+
+// setOutputFormat selects the TUI or static text output. Without a format,
+// text is used when stdout is not a terminal. The theme is applied and the
+// key bindings of the TUI are checked here, before a plan is made.
+func (s *session) setOutputFormat(format string) error {
+	switch format {
+	case "":
+	case outputTUI:
+		s.text = false
+	case config.FormatText:
+		s.text = true
+	default:
+		return &exitError{code: 2, err: fmt.Errorf("unsupported output format %q (expected %s or %s)", format, outputTUI, config.FormatText)}
+	}
+	if err := tui.UseTheme(s.cfg.UI.Theme, config.Enabled(s.cfg.UI.ActionLabels)); err != nil {
+		return err
+	}
+	if s.text {
+		return nil
+	}
+
+	keys, err := tui.NewKeyMap(s.cfg.UI.Keymap, s.cfg.UI.Keybindings)
+	if err != nil {
+		return fmt.Errorf("invalid ui.keybindings: %w", err)
+	}
+	s.keys = keys
+	return nil
+}
+
+// stdoutIsTerminal returns true if stdout is an interactive terminal
+func stdoutIsTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// terraform returns the terraform or tofu command, looking it up on first use
+func (s *session) terraform() (string, error) {
+	if s.tfCmd != "" {
+		return s.tfCmd, nil
+	}
+
+	terragrunt := s.usesTerragrunt()
+	tfCmd := findTerraformCommand(s.cfg.Terraform.Binary, terragrunt)
+	if tfCmd == "" && s.cfg.Terraform.Binary != "" {
+		return "", fmt.Errorf("configured terraform binary %q was not found in PATH", s.cfg.Terraform.Binary)
+	}
+	if tfCmd == "" {
+		if terragrunt {
+			printTerragruntMissing()
+		} else {
+			printTerraformMissing()
+		}
+		return "", &exitError{code: 1}
+	}
+
+	fmt.Printf("Using: %s\n", tfCmd)
+	s.tfCmd = tfCmd
+	return tfCmd, nil
+}
+
+// rootPlan is a binary plan file created in a root module directory
+type rootPlan struct {
+	dir      string // directory terraform runs in
+	planFile string // plan file, absolute or relative to dir
+	outDir   string // set if terragrunt run-all wrote the plan, see unitPlanFile
+	err      error  // set if planning this root failed
+
+	// snapshot is the configuration of dir when it was planned; nil for saved plans
+	snapshot *configSnapshot
+}
+
+// planTempDir returns the private directory for temporary plan files.
+// Plans can contain secrets, so only the current user can read it. It is
+// removed when tplan exits.
+func (s *session) planTempDir() (string, error) {
+	if s.tempDir != "" {
+		return s.tempDir, nil
+	}
+	dir, err := os.MkdirTemp("", "tplan-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	atExit(func() {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to clean up %s: %v\n", dir, err)
+		}
+	})
+	s.tempDir = dir
+	return dir, nil
+}
+
+// newRootPlan returns a unique temporary plan file for the i-th root module
+func (s *session) newRootPlan(dir string, i int) (rootPlan, error) {
+	tempDir, err := s.planTempDir()
+	if err != nil {
+		return rootPlan{}, err
+	}
+	return rootPlan{dir: dir, planFile: filepath.Join(tempDir, fmt.Sprintf("%d.tfplan", i))}, nil
+}
+
+// keepPlan copies the binary plan of a single root to path for a later
+// 'tplan apply PLAN_FILE'. Like terraform, it is only readable by the user.
+func keepPlan(plans []rootPlan, path string) error {
+	if len(plans) != 1 || plans[0].outDir != "" {
+		return fmt.Errorf("-out needs exactly one root module, got %d", len(plans))
+	}
+	data, err := os.ReadFile(plans[0].path())
+	if err != nil {
+		return fmt.Errorf("failed to read plan file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save plan file: %w", err)
+	}
+	return nil
+}
+
+// planFileArg returns how to pass a plan file in dir to the terraform command.
+// Terragrunt runs terraform in its cache, so it needs an absolute path.
+func (s *session) planFileArg(dir, planFile string) string {
+	if isTerragrunt(s.tfCmd) && !filepath.IsAbs(planFile) {
+		return absPath(filepath.Join(dir, planFile))
+	}
+	return planFile
+}
+
+// savedPlan returns a plan file given on the command line, relative to the
+// working directory, as a plan of the directory terraform runs in
+func (s *session) savedPlan(path string) rootPlan {
+	if s.args.chdir != "" && !filepath.IsAbs(path) {
+		path = absPath(path)
+	}
+	return rootPlan{dir: s.workDir(), planFile: s.planFileArg(s.workDir(), path)}
+}
+
+// path returns the plan file path relative to the working directory
+func (p rootPlan) path() string {
+	if filepath.IsAbs(p.planFile) {
+		return p.planFile
+	}
+	return filepath.Join(p.dir, p.planFile)
+}
+
+// removePlanFiles deletes the temporary plan files of all roots. Plan files
+// of planAll are also removed when tplan exits.
+func removePlanFiles(plans []rootPlan) {
+	for _, p := range plans {
+		removePlanFile(p.path())
+	}
+}
+
+// stateDir returns the .tplan directory at the root of the git repository,
+// or in the working directory outside a repository
+func stateDir() string {
+	dir := absPath(".")
+	if root := config.FindRepositoryRoot(dir); root != "" {
+		dir = root
+	}
+	return filepath.Join(dir, ".tplan")
+}
+
+// absPath returns the absolute form of a path, or the path itself if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// planAll creates temporary plans for the selected root modules, or for the
+// working directory if no roots were selected, and records them in the plan
+// history. The plan files are removed when tplan exits, even if it is
+// interrupted.
+func (s *session) planAll() (*models.PlanResult, []rootPlan, error) {
+	if s.args.chdir != "" && (s.runAll || len(s.roots) > 0) {
+		return nil, nil, fmt.Errorf("-chdir cannot be combined with root modules (-root, -run-all or roots in the config)")
+	}
+
+	var planResult *models.PlanResult
+	var plans []rootPlan
+	var err error
+	switch {
+	case s.runAll:
+		planResult, plans, err = s.createRunAllPlans()
+	case len(s.roots) == 0:
+		planResult, plans, err = s.createPlans([]string{s.workDir()})
+	default:
+		planResult, plans, err = s.createPlans(s.roots)
+	}
+	atExit(func() { removePlanFiles(plans) })
+
+	if err == nil {
+		s.recordHistory(planResult, plans)
+	}
+	return planResult, plans, err
+}
+
+// usesTerragrunt returns true if the directories to plan are terragrunt units
+func (s *session) usesTerragrunt() bool {
+	if s.runAll {
+		return true
+	}
+	if len(s.roots) == 0 {
+		return git.IsTerragruntUnit(".")
+	}
+	for _, root := range s.roots {
+		if git.IsTerragruntUnit(root) {
+			return true
+		}
+	}
+	return false
+}
+
+// createPlan runs terraform plan in dir into planFile, then converts and parses it
+func (s *session) createPlan(dir, planFile string) (*models.PlanResult, error) {
+	if err := s.runPlan(dir, planFile, nil, nil); err != nil {
+		return nil, err
+	}
+	return s.showPlan(dir, planFile)
+}
+
+// runPlan runs terraform plan in dir and saves the binary plan to planFile.
+// extraArgs come before the terraform arguments of the session. With a nil
+// output, terraform runs interactively and its output is shown live.
+func (s *session) runPlan(dir, planFile string, extraArgs []string, output io.Writer) error {
+	tfCmd, err := s.terraform()
+	if err != nil {
+		return err
+	}
+	planArgs := append(append([]string{}, extraArgs...), s.args.plan...)
+
+	// Run terraform plan -out=<planfile>
+	if output == nil {
+		fmt.Printf("\nRunning: %s plan -out=%s", tfCmd, rootPlan{dir: dir, planFile: planFile}.path())
+		if len(planArgs) > 0 {
+			fmt.Printf(" %v", planArgs)
+		}
+		fmt.Println()
+	}
+
+	if err := runTerraformPlan(tfCmd, dir, planFile, planArgs, output); err != nil {
+		return fmt.Errorf("terraform plan failed: %w", err)
+	}
+	return nil
+}
+
+// showPlan converts a binary plan file to JSON with terraform show and parses it
+func (s *session) showPlan(dir, planFile string) (*models.PlanResult, error) {
+	fmt.Println("\nGenerating JSON output...")
+	jsonOutput, err := s.showJSON(dir, planFile)
+	if err != nil {
+		return nil, err
+	}
+	return s.parsePlan(jsonOutput, dir)
+}
+
+// showJSON runs terraform show -json in dir on a binary plan file
+func (s *session) showJSON(dir, planFile string) ([]byte, error) {
+	tfCmd, err := s.terraform()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonOutput, err := runTerraformShow(tfCmd, dir, planFile, s.args.show)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate JSON output: %w", err)
+	}
+	return jsonOutput, nil
+}
+
+// loadPlan loads a plan from a JSON plan file or a binary plan file
+func (s *session) loadPlan(path string) (*models.PlanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+
+	if isJSONPlan(data) {
+		return s.parsePlan(data, s.workDir())
+	}
+	if _, err := s.terraform(); err != nil {
+		return nil, err
+	}
+	saved := s.savedPlan(path)
+	return s.showPlan(saved.dir, saved.planFile)
+}
+
+// isJSONPlan returns true if the data looks like the output of terraform show -json
+func isJSONPlan(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// parsePlan parses JSON plan output and enriches it with information about
+// the files in dir that declare each resource
+func (s *session) parsePlan(data []byte, dir string) (*models.PlanResult, error) {
+	p := parser.NewParser()
+	planResult, err := p.ParseBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+
+	// Always enrich with file information for grouping
+	// This populates the FilePath in DriftInfo even without full drift mode
+	if err := enrichWithFileInfo(planResult, dir, s.drift); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not get file information: %v\n", err)
+		// Continue anyway - we'll show the plan without file info
+	}
+
+	return planResult, nil
+}
+
+// writeReport renders the plan in the given format and writes it to path
+func (s *session) writeReport(planResult *models.PlanResult, format, path string, showNoise bool) error {
+	gen := report.NewGenerator(planResult, s.drift).WithIgnoreRules(s.ignoreRules, showNoise)
+
+	var content string
+	switch format {
+	case config.FormatMarkdown:
+		if s.cfg.Report.Template == "" {
+			content = gen.GenerateMarkdown()
+			break
+		}
+		tmpl, err := report.LoadTemplate(s.cfg.Report.Template)
+		if err != nil {
+			return err
+		}
+		if content, err = gen.GenerateTemplate(tmpl); err != nil {
+			return err
+		}
+	case config.FormatPRComment:
+		content = gen.GeneratePRComment(s.commentOptions())
+	case config.FormatHTML:
+		content = gen.GenerateHTML()
+	case config.FormatText:
+		if err := tui.UseTheme(s.cfg.UI.Theme, config.Enabled(s.cfg.UI.ActionLabels)); err != nil {
+			return err
+		}
+		if path != "-" {
+			tui.DisableColor()
+		}
+		content = tui.RenderText(planResult, tui.Options{
+			IgnoreRules: s.ignoreRules,
+			Grouping:    s.cfg.UI.Grouping,
+			ShowNoise:   showNoise,
+		})
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+
+	if path == "-" {
+		_, err := fmt.Fprint(os.Stdout, content)
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// commentOptions returns the configured pull request comment options
+func (s *session) commentOptions() report.CommentOptions {
+	return report.CommentOptions{ID: s.cfg.Comment.ID, MaxLength: s.cfg.Comment.MaxLength}
+}
+
+// applyPlans asks for confirmation and runs terraform apply on the binary
+// plan files, one root after another. Roots whose plan failed are skipped.
+// Nothing is applied if the configuration of any root changed since its plan.
+func (s *session) applyPlans(planResult *models.PlanResult, plans []rootPlan, autoApprove bool) error {
+	release, err := lockApply()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := checkPlansStale(plans); err != nil {
+		return err
+	}
+
+	if !autoApprove && !confirmApply(planResult, s.workspaces(planResult, plans)) {
+		fmt.Println("Apply cancelled.")
+		return nil
+	}
+
+	if len(plans) == 1 {
+		fmt.Println("\nApplying plan...")
+	} else if len(plans) > 0 && plans[0].outDir != "" {
+		fmt.Println("\nApplying plans...")
+	}
+
+	// Multi-root events carry their root; print a header when it changes
+	root := ""
+	err = s.runApply(planResult, plans, func(event apply.Event) {
+		if event.Root != root {
+			root = event.Root
+			fmt.Printf("\n── Applying %s ──\n", root)
+		}
+		printApplyEvent(event)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println("\n✓ Apply completed successfully")
+	return nil
+}
+
+// streamApply applies the plans one root after another like applyPlans, but
+// without a confirmation prompt, sending every apply event to send. It is
+// used by the TUI, which confirms the apply itself.
+func (s *session) streamApply(planResult *models.PlanResult, plans []rootPlan, send func(apply.Event)) error {
+	release, err := lockApply()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := checkPlansStale(plans); err != nil {
+		return err
+	}
+	return s.runApply(planResult, plans, send)
+}
+
+// lockApply takes the apply lock of the repository, so two tplan runs
+// don't apply at the same time. The returned function releases it.
+func lockApply() (func(), error) {
+	applyLock, err := lock.Acquire(filepath.Join(stateDir(), "apply.lock"), osUser())
+	if err != nil {
+		return nil, err
+	}
+	release := func() {
+		if err := applyLock.Release(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	atExit(release)
+	return release, nil
+}
+
+// checkPlansStale returns an error if any successfully planned root is stale
+func checkPlansStale(plans []rootPlan) error {
+	for _, p := range plans {
+		if p.err != nil {
+			continue
+		}
+		if err := checkStale(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runApply applies the plans, sending every apply event to onEvent, and
+// records the outcome in the audit log
+func (s *session) runApply(planResult *models.PlanResult, plans []rootPlan, onEvent func(apply.Event)) error {
+	tfCmd, err := s.terraform()
+	if err != nil {
+		return err
+	}
+
+	// Hash the plan files before terraform gets to them
+	hash := planHash(plans)
+	result := apply.NewResult(planResult)
+	result.Started = time.Now()
+
+	err = applyEach(tfCmd, s.args.apply, plans, func(event apply.Event) {
+		result.Apply(event, time.Now())
+		onEvent(event)
+	})
+	result.Finish(err, time.Now())
+
+	s.recordAudit(planResult, plans, result, hash)
+	return err
+}
+
+// applyEach applies the plans one root after another with the apply
+// arguments and stops at the first failure. Terragrunt applies the units of
+// a run-all plan in dependency order on the terminal, without events.
+func applyEach(tfCmd string, args []string, plans []rootPlan, onEvent func(apply.Event)) error {
+	if len(plans) > 0 && plans[0].outDir != "" {
+		if err := runTerragruntRunAll(tfCmd, ".", plans[0].outDir, append([]string{"apply"}, args...)); err != nil {
+			return fmt.Errorf("terragrunt run-all apply failed: %w", err)
+		}
+		return nil
+	}
+
+	for _, p := range plans {
+		if p.err != nil {
+			continue
+		}
+		root := ""
+		if len(plans) > 1 {
+			root = p.dir
+		}
+		err := runTerraformApply(tfCmd, p.dir, p.planFile, args, func(event apply.Event) {
+			event.Root = root
+			onEvent(event)
+		})
+		if err != nil {
+			if root != "" {
+				return fmt.Errorf("terraform apply failed in %s: %w", p.dir, err)
+			}
+			return fmt.Errorf("terraform apply failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// printApplyEvent prints an apply event the way terraform's human-readable output would
+func printApplyEvent(event apply.Event) {
+	switch event.Type {
+	case apply.EventApplyStart, apply.EventApplyProgress, apply.EventApplyComplete, apply.EventApplyErrored, apply.EventChangeSummary:
+		fmt.Println(event.Message)
+	case apply.EventDiagnostic:
+		fmt.Fprintln(os.Stderr, event.Message)
+		if event.Diagnostic != nil && event.Diagnostic.Detail != "" {
+			fmt.Fprintf(os.Stderr, "\n%s\n\n", event.Diagnostic.Detail)
+		}
+	}
+}
+
+// confirmApply asks the user to confirm an apply on the terminal. Like the
+// TUI, plans that delete or replace resources list them and require typing
+// the workspaces they are in.
+func confirmApply(planResult *models.PlanResult, workspaces []workspace.Info) bool {
+	destructive := planResult.DestructiveResources()
+	if len(destructive) == 0 {
+		fmt.Print("\nAre you sure you want to apply this plan? (yes/no): ")
+		return readLine() == "yes"
+	}
+
+	fmt.Println("\nThe following resources will be destroyed:")
+	for _, res := range destructive {
+		address := res.Address
+		if res.Root != "" {
+			address = res.Root + ": " + address
+		}
+		fmt.Printf("  %s (%s)\n", address, res.Action)
+	}
+	word := workspace.ConfirmationWord(planResult, workspaces)
+	fmt.Printf("\nType %s to confirm: ", word)
+	return readLine() == word
+}
+
+// readLine reads a line of input from the terminal without surrounding spaces
+func readLine() string {
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// workspaces detects the workspace, backend and variable files of each planned
+// root, or of the working directory if the plan was loaded from a file
+func (s *session) workspaces(planResult *models.PlanResult, plans []rootPlan) []workspace.Info {
+	dirs := make([]string, 0, len(plans))
+	for _, p := range plans {
+		if p.err == nil {
+			dirs = append(dirs, p.dir)
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = append(dirs, ".")
+	}
+
+	infos := make([]workspace.Info, len(dirs))
+	for i, dir := range dirs {
+		infos[i] = workspace.Detect(dir, s.args.plan)
+		if s.tfCmd != "" {
+			infos[i].Binary = filepath.Base(s.tfCmd)
+		}
+		infos[i].Version = planResult.TerraformVersion
+	}
+	return infos
+}
+
+// planContext returns the git branch and the distinct workspaces of the planned roots
+func (s *session) planContext(planResult *models.PlanResult, plans []rootPlan) (string, []string) {
+	branch := ""
+	workspaces := make([]string, 0, 1)
+	for _, info := range s.workspaces(planResult, plans) {
+		if branch == "" {
+			branch = info.Branch
+		}
+		if !containsString(workspaces, info.Workspace) {
+			workspaces = append(workspaces, info.Workspace)
+		}
+	}
+	return branch, workspaces
+}
+
+// containsString returns true if list includes value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// printSummary prints a one-line summary of the plan, like terraform does
+func printSummary(planResult *models.PlanResult) {
+	summary := planResult.Summary
+	fmt.Printf("\nPlan: %d to add, %d to change, %d to destroy, %d to replace.\n",
+		summary.ToCreate, summary.ToUpdate, summary.ToDelete, summary.ToReplace)
+}
+
+// hasChanges returns true if the plan changes any resource
+func hasChanges(planResult *models.PlanResult) bool {
+	summary := planResult.Summary
+	return summary.ToCreate+summary.ToUpdate+summary.ToDelete+summary.ToReplace > 0
+}
+
+// removePlanFile deletes a temporary plan file, warning if that fails
+func removePlanFile(planFile string) {
+	if err := os.Remove(planFile); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Warning: failed to clean up temp file %s: %v\n", planFile, err)
+	}
+}
+
+func enrichWithFileInfo(planResult *models.PlanResult, dir string, fullDriftMode bool) error {
+	// Initialize git repository for the directory that was planned
+	repo, err := git.NewRepository(dir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+
+	// For each resource change, try to get git/file information
+	for i := range planResult.Resources {
+		resource := &planResult.Resources[i]
+
+		// Get full drift info for this resource (includes file path and git info)
+		driftInfo, err := repo.GetDriftInfo(resource.Address)
+		if err != nil {
+			// Not a critical error - just skip this resource
+			continue
+		}
+
+		// Always attach the full drift info
+		// This provides file grouping and git information
+		resource.DriftInfo = driftInfo
+	}
+
+	// Second pass: for deleted resources without file info, try to find their replacement
+	for i := range planResult.Resources {
+		resource := &planResult.Resources[i]
+
+		// Only process deleted resources without drift info
+		if resource.Action != models.ActionDelete || resource.DriftInfo != nil {
+			continue
+		}
+
+		// Look for a create operation with the same type and index
+		for j := range planResult.Resources {
+			other := &planResult.Resources[j]
+
+			// Check if this is a potential replacement (same type, same index, create action)
+			if other.Action == models.ActionCreate &&
+				other.Type == resource.Type &&
+				indexMatches(other.Index, resource.Index) &&
+				other.DriftInfo != nil {
+				// Copy the drift info from the replacement
+				resource.DriftInfo = other.DriftInfo
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// indexMatches checks if two resource indices match
+func indexMatches(idx1, idx2 interface{}) bool {
+	// Handle nil cases
+	if idx1 == nil && idx2 == nil {
+		return true
+	}
+	if idx1 == nil || idx2 == nil {
+		return false
+	}
+
+	// Compare as strings to handle both int and string indices
+	return fmt.Sprintf("%v", idx1) == fmt.Sprintf("%v", idx2)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/tplan/internal/git"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// rootFlags select the root module directories to plan together
+type rootFlags struct {
+	roots       stringList
+	concurrency int
+	runAll      bool
+}
+
+// register adds the root selection flags to a command's flag set
+func (r *rootFlags) register(fs *flag.FlagSet) {
+	fs.Var(&r.roots, "root", "Root module directory or glob to plan, e.g. 'envs/*/*' (repeatable)")
+	fs.IntVar(&r.concurrency, "concurrency", 0, "Maximum number of root modules planned at once (default: terraform.concurrency from the config)")
+	fs.BoolVar(&r.runAll, "run-all", false, "Plan every terragrunt unit below the working directory with 'terragrunt run-all'")
+}
+
+// selectRoots resolves the root modules to plan from the flags, falling back
+// to the roots listed in the config. No roots means the working directory.
+func (s *session) selectRoots(flags *rootFlags) error {
+	patterns := []string(flags.roots)
+	if len(patterns) == 0 {
+		patterns = s.cfg.Roots
+	}
+	if flags.concurrency > 0 {
+		s.cfg.Terraform.Concurrency = flags.concurrency
+	}
+	if flags.runAll {
+		if len(flags.roots) > 0 {
+			return fmt.Errorf("-run-all and -root cannot be combined")
+		}
+		s.runAll = true
+		return nil
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	roots, err := resolveRoots(patterns)
+	if err != nil {
+		return err
+	}
+	s.roots = roots
+	return nil
+}
+
+// resolveRoots expands root patterns into a sorted list of directories,
+// relative to the working directory where possible. Directories matched by
+// a glob are only kept if they contain Terraform files or a terragrunt.hcl.
+func resolveRoots(patterns []string) ([]string, error) {
+	cwd, _ := os.Getwd()
+	seen := make(map[string]bool)
+	roots := make([]string, 0)
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root pattern %q: %w", pattern, err)
+		}
+		isGlob := strings.ContainsAny(pattern, "*?[")
+
+		found := 0
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+			if isGlob && !isRootModule(match) {
+				continue
+			}
+			found++
+
+			if cwd != "" {
+				if rel, err := filepath.Rel(cwd, match); err == nil && !strings.HasPrefix(rel, "..") {
+					match = rel
+				}
+			}
+			match = filepath.Clean(match)
+			if !seen[match] {
+				seen[match] = true
+				roots = append(roots, match)
+			}
+		}
+
+		if found == 0 {
+			return nil, fmt.Errorf("root %q did not match any root module directory", pattern)
+		}
+	}
+
+	sort.Strings(roots)
+	return roots, nil
+}
+
+// isRootModule returns true if the directory contains Terraform configuration
+// or is a terragrunt unit
+func isRootModule(dir string) bool {
+	if git.IsTerragruntUnit(dir) {
+		return true
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.tf"))
+	return len(matches) > 0
+}
+
+// createPlans plans several root modules in parallel, running at most
+// terraform.concurrency plans at a time, and merges the results.
+func (s *session) createPlans(roots []string) (*models.PlanResult, []rootPlan, error) {
+	tfCmd, err := s.terraform()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plans := make([]rootPlan, len(roots))
+	for i, root := range roots {
+		if plans[i], err = s.newRootPlan(root, i); err != nil {
+			return nil, nil, err
+		}
+		plans[i].snapshot = takeSnapshot(root)
+	}
+
+	// A single root keeps the interactive flow
+	if len(roots) == 1 {
+		planResult, err := s.createPlan(plans[0].dir, plans[0].planFile)
+		return planResult, plans, err
+	}
+
+	// Parallel plans cannot prompt for input on the shared terminal
+	args := []string{"-input=false"}
+
+	fmt.Printf("\nPlanning %d root modules with %s (%d at a time)...\n", len(roots), tfCmd, s.cfg.Terraform.Concurrency)
+	merged, err := s.collectPlans(plans, func(p *rootPlan, output io.Writer) (*models.PlanResult, error) {
+		if err := s.runPlan(p.dir, p.planFile, args, output); err != nil {
+			return nil, err
+		}
+		jsonOutput, err := s.showJSON(p.dir, p.planFile)
+		if err != nil {
+			return nil, err
+		}
+		return s.parsePlan(jsonOutput, p.dir)
+	})
+	return merged, plans, err
+}
+
+// collectPlans runs load for every root in parallel, at most
+// terraform.concurrency at a time, and merges the resulting plans. Output
+// written by load is buffered per root and printed only if it fails. Failed
+// roots are recorded as plan errors so the other roots can still be reviewed.
+func (s *session) collectPlans(plans []rootPlan, load func(p *rootPlan, output io.Writer) (*models.PlanResult, error)) (*models.PlanResult, error) {
+	results := make([]*models.PlanResult, len(plans))
+	outputs := make([]bytes.Buffer, len(plans))
+	slots := make(chan struct{}, s.cfg.Terraform.Concurrency)
+	var progress sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+
+	for i := range plans {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			start := time.Now()
+			p := &plans[i]
+			results[i], p.err = load(p, &outputs[i])
+
+			progress.Lock()
+			defer progress.Unlock()
+			done++
+			elapsed := time.Since(start).Round(time.Second)
+			if p.err != nil {
+				fmt.Printf("  [%d/%d] ✖ %s (%s): %v\n", done, len(plans), p.dir, elapsed, p.err)
+				return
+			}
+			summary := results[i].Summary
+			fmt.Printf("  [%d/%d] ✓ %s (%s): +%d ~%d -%d ±%d\n", done, len(plans), p.dir, elapsed,
+				summary.ToCreate, summary.ToUpdate, summary.ToDelete, summary.ToReplace)
+		}(i)
+	}
+	wg.Wait()
+
+	// Show the output of failed roots, in root order
+	failed := 0
+	for i, p := range plans {
+		if p.err == nil {
+			continue
+		}
+		failed++
+		if outputs[i].Len() > 0 {
+			fmt.Fprintf(os.Stderr, "\n── %s: %s output ──\n%s", p.dir, s.tfCmd, outputs[i].String())
+		}
+	}
+	if failed == len(plans) {
+		return nil, fmt.Errorf("planning failed in all %d root modules", len(plans))
+	}
+
+	roots := make([]string, len(plans))
+	for i, p := range plans {
+		roots[i] = p.dir
+	}
+
+	merged := models.MergePlans(roots, results)
+	for _, p := range plans {
+		if p.err != nil {
+			merged.Errors = append(merged.Errors, models.PlanError{
+				Message:  fmt.Sprintf("[%s] %v", p.dir, p.err),
+				Severity: "fatal",
+			})
+		}
+	}
+	return merged, nil
+}
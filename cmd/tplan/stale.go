@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// configSnapshot records the configuration of a root module when it was
+// planned, so a plan made from since-changed files is not applied
+type configSnapshot struct {
+	head  string            // git HEAD commit, "" outside a repository
+	files map[string][]byte // configuration file path -> sha256 of its content
+}
+
+// isConfigFile returns true for files whose changes invalidate a plan
+func isConfigFile(name string) bool {
+	for _, ext := range []string{".tf", ".tf.json", ".tfvars", ".tfvars.json", ".hcl"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkConfigFiles calls fn for every configuration file below dir, skipping
+// hidden directories such as .terraform and .terragrunt-cache
+func walkConfigFiles(dir string, fn func(path string, info os.FileInfo)) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isConfigFile(info.Name()) {
+			fn(path, info)
+		}
+		return nil
+	})
+}
+
+// takeSnapshot records the git HEAD and configuration files of dir
+func takeSnapshot(dir string) *configSnapshot {
+	snapshot := &configSnapshot{
+		head:  gitHead(dir),
+		files: make(map[string][]byte),
+	}
+	walkConfigFiles(dir, func(path string, info os.FileInfo) {
+		if content, err := os.ReadFile(path); err == nil {
+			sum := sha256.Sum256(content)
+			snapshot.files[path] = sum[:]
+		}
+	})
+	return snapshot
+}
+
+// changes describes how dir differs from the snapshot
+func (c *configSnapshot) changes(dir string) []string {
+	changes := make([]string, 0)
+
+	if head := gitHead(dir); head != c.head {
+		changes = append(changes, fmt.Sprintf("git HEAD moved from %s to %s", shortCommit(c.head), shortCommit(head)))
+	}
+
+	current := takeSnapshot(dir).files
+	for path, sum := range current {
+		old, ok := c.files[path]
+		switch {
+		case !ok:
+			changes = append(changes, path+" was added")
+		case string(old) != string(sum):
+			changes = append(changes, path+" was modified")
+		}
+	}
+	for path := range c.files {
+		if _, ok := current[path]; !ok {
+			changes = append(changes, path+" was removed")
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+// changedSince lists the configuration files of dir modified after t. It is
+// used for plan files tplan did not create in this run, where only the time
+// the plan was written is known.
+func changedSince(dir string, t time.Time) []string {
+	changes := make([]string, 0)
+	walkConfigFiles(dir, func(path string, info os.FileInfo) {
+		if info.ModTime().After(t) {
+			changes = append(changes, path+" was modified after the plan was saved")
+		}
+	})
+	sort.Strings(changes)
+	return changes
+}
+
+// checkStale returns an error if the configuration of a root changed after
+// it was planned
+func checkStale(p rootPlan) error {
+	var changes []string
+	switch {
+	case p.snapshot != nil:
+		changes = p.snapshot.changes(p.dir)
+	case p.outDir == "":
+		info, err := os.Stat(p.path())
+		if err != nil {
+			return nil
+		}
+		changes = changedSince(p.dir, info.ModTime())
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("the plan for %s is stale:\n  %s\nre-run the plan to review the current configuration",
+		p.dir, strings.Join(changes, "\n  "))
+}
+
+// gitHead returns the commit checked out in dir, or "" outside a repository
+func gitHead(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// shortCommit abbreviates a commit hash for messages
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "(none)"
+	}
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
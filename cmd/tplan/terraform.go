@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/apply"
+	"github.com/yourusername/tplan/internal/git"
+)
+
+// findTerraformCommand checks for terraform or tofu and returns the command to use.
+// A configured binary takes precedence and is returned only if it can be found.
+// For terragrunt units only terragrunt is looked for.
+func findTerraformCommand(preferred string, terragrunt bool) string {
+	if preferred != "" {
+		if _, err := exec.LookPath(preferred); err == nil {
+			return preferred
+		}
+		return ""
+	}
+
+	if terragrunt {
+		if _, err := exec.LookPath("terragrunt"); err == nil {
+			return "terragrunt"
+		}
+		return ""
+	}
+
+	// Check for terraform first
+	if _, err := exec.LookPath("terraform"); err == nil {
+		return "terraform"
+	}
+
+	// Check for tofu as fallback
+	if _, err := exec.LookPath("tofu"); err == nil {
+		return "tofu"
+	}
+
+	return ""
+}
+
+// printTerraformMissing explains how to install terraform or tofu
+func printTerraformMissing() {
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  ERROR: Neither Terraform nor OpenTofu is installed\n")
+	fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "tplan requires either Terraform or OpenTofu to be installed.\n")
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "Install Terraform:\n")
+	fmt.Fprintf(os.Stderr, "  https://developer.hashicorp.com/terraform/install\n")
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "Or install OpenTofu:\n")
+	fmt.Fprintf(os.Stderr, "  https://opentofu.org/docs/intro/install/\n")
+	fmt.Fprintf(os.Stderr, "\n")
+}
+
+// printTerragruntMissing explains how to install terragrunt
+func printTerragruntMissing() {
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  ERROR: Found %s but terragrunt is not installed\n", git.TerragruntFile)
+	fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "Install terragrunt:\n")
+	fmt.Fprintf(os.Stderr, "  https://terragrunt.gruntwork.io/docs/getting-started/install/\n")
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "Or set terraform.binary in .tplan.yaml to run terraform directly.\n")
+	fmt.Fprintf(os.Stderr, "\n")
+}
+
+// isTerragrunt returns true if the command is terragrunt
+func isTerragrunt(tfCmd string) bool {
+	return strings.TrimSuffix(filepath.Base(tfCmd), ".exe") == "terragrunt"
+}
+
+// runTerraformPlan runs terraform/tofu plan in dir and saves to a file.
+// If output is nil, terraform runs interactively on the terminal;
+// otherwise stdout and stderr are written to output.
+func runTerraformPlan(tfCmd, dir, planFile string, extraArgs []string, output io.Writer) error {
+	args := []string{"plan", "-out=" + planFile}
+	args = append(args, extraArgs...)
+
+	cmd := exec.Command(tfCmd, args...)
+	cmd.Dir = dir
+	cmd.Env = terraformEnv()
+	if output == nil {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Stdin = os.Stdin
+	} else {
+		cmd.Stdout = output
+		cmd.Stderr = output
+	}
+
+	return runCommand(cmd)
+}
+
+// runTerraformShow runs terraform/tofu show -json in dir and returns the output
+func runTerraformShow(tfCmd, dir, planFile string, extraArgs []string) ([]byte, error) {
+	var stdout bytes.Buffer
+
+	args := append(append([]string{"show", "-json"}, extraArgs...), planFile)
+	cmd := exec.Command(tfCmd, args...)
+	cmd.Dir = dir
+	cmd.Env = terraformEnv()
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := runCommand(cmd); err != nil {
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// runTerragruntRunAll runs "terragrunt run-all <args>" in dir, which runs the
+// command in every unit below dir in dependency order. Plans are written to
+// and applied from outDir, one per unit. Terragrunt's own confirmation
+// prompt is disabled; tplan asks before applying.
+func runTerragruntRunAll(tfCmd, dir, outDir string, args []string) error {
+	cmd := exec.Command(tfCmd, append([]string{"run-all"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(terraformEnv(), "TERRAGRUNT_NON_INTERACTIVE=true", "TG_NON_INTERACTIVE=true",
+		"TERRAGRUNT_OUT_DIR="+outDir, "TG_OUT_DIR="+outDir)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	return runCommand(cmd)
+}
+
+// maxEventSize is the longest line of apply output that is parsed as an event
+const maxEventSize = 4 * 1024 * 1024
+
+// unreadableOutput is the warning sent when the apply output cannot be read
+// any further, e.g. because an event is longer than maxEventSize
+func unreadableOutput(err error) apply.Event {
+	diag := &apply.Diagnostic{
+		Severity: "warning",
+		Summary:  "Apply progress is no longer shown",
+		Detail:   fmt.Sprintf("Failed to read the output of terraform apply: %v. The apply continues; its result is reported when it finishes.", err),
+	}
+	if errors.Is(err, bufio.ErrTooLong) {
+		diag.Detail = fmt.Sprintf("An event in the output of terraform apply is longer than %d MB and was skipped, together with all events after it. The apply continues; its result is reported when it finishes.", maxEventSize/(1024*1024))
+	}
+	return apply.Event{
+		Type:       apply.EventDiagnostic,
+		Level:      "warn",
+		Message:    "Warning: " + diag.Summary,
+		Diagnostic: diag,
+	}
+}
+
+// runTerraformApply runs terraform/tofu apply -json in dir with the plan file
+// and calls onEvent for every machine-readable event terraform emits. Lines
+// that are not events are ignored; stderr is included in the returned error.
+func runTerraformApply(tfCmd, dir, planFile string, extraArgs []string, onEvent func(apply.Event)) error {
+	var stderr bytes.Buffer
+
+	args := append(append([]string{"apply", "-json", "-input=false"}, extraArgs...), planFile)
+	cmd := exec.Command(tfCmd, args...)
+	cmd.Dir = dir
+	cmd.Env = terraformEnv()
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := startCommand(cmd); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize) // diagnostics can be long
+	for scanner.Scan() {
+		if event, ok := apply.ParseLine(scanner.Bytes()); ok {
+			onEvent(event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// Keep reading so terraform does not block writing to the pipe;
+		// the apply goes on without progress
+		onEvent(unreadableOutput(err))
+		io.Copy(io.Discard, stdout)
+	}
+
+	if err := waitCommand(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/tplan/internal/apply"
+)
+
+// fakeTerraform writes a shell script standing in for terraform
+func fakeTerraform(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	path := filepath.Join(t.TempDir(), "terraform")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunTerraformApplyLongEvent(t *testing.T) {
+	// An event longer than maxEventSize, followed by more output than a pipe
+	// buffer holds, so terraform blocks unless tplan keeps reading
+	tfCmd := fakeTerraform(t, `
+echo '{"type": "apply_start", "@message": "a: Creating..."}'
+printf '{"type": "diagnostic", "@message": "'
+head -c 5000000 /dev/zero | tr '\0' x
+echo '"}'
+i=0
+while [ $i -lt 2000 ]; do
+  echo '{"type": "apply_progress", "@message": "b: Still creating... [10s elapsed]"}'
+  i=$((i+1))
+done
+exit 0
+`)
+
+	events := make([]apply.Event, 0)
+	done := make(chan error, 1)
+	go func() {
+		done <- runTerraformApply(tfCmd, t.TempDir(), "plan.tfplan", nil, func(event apply.Event) {
+			events = append(events, event)
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("apply failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("runTerraformApply hangs after an oversized event")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want the first one and the warning", len(events))
+	}
+	warning := events[1]
+	if warning.Type != apply.EventDiagnostic || warning.Diagnostic == nil || warning.Diagnostic.Severity != "warning" {
+		t.Fatalf("second event %+v, want a warning diagnostic", warning)
+	}
+	if !strings.Contains(warning.Diagnostic.Detail, "longer than 4 MB") {
+		t.Errorf("warning detail %q does not explain the skipped event", warning.Diagnostic.Detail)
+	}
+}
+
+func TestRunTerraformApplyFailure(t *testing.T) {
+	tfCmd := fakeTerraform(t, `
+echo '{"type": "apply_start", "@message": "a: Creating..."}'
+echo 'Error: provider crashed' >&2
+exit 1
+`)
+
+	count := 0
+	err := runTerraformApply(tfCmd, t.TempDir(), "plan.tfplan", nil, func(apply.Event) { count++ })
+	if err == nil || !strings.Contains(err.Error(), "provider crashed") {
+		t.Errorf("err = %v, want it to include stderr", err)
+	}
+	if count != 1 {
+		t.Errorf("got %d events, want 1", count)
+	}
+}
+
+func TestRunTerragruntRunAllOutDir(t *testing.T) {
+	// Terragrunt writes each unit's plan below the out directory, the way
+	// createRunAllPlans expects to find it
+	tfCmd := fakeTerraform(t, `
+[ "$TG_OUT_DIR" = "$TERRAGRUNT_OUT_DIR" ] || exit 1
+for unit in app db; do
+  mkdir -p "$TG_OUT_DIR/$unit"
+  echo "$*" > "$TG_OUT_DIR/$unit/tfplan.tfplan"
+done
+`)
+	outDir := filepath.Join(t.TempDir(), "run-all")
+
+	if err := runTerragruntRunAll(tfCmd, t.TempDir(), outDir, []string{"plan", "-lock=false"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, unit := range []string{"app", "db"} {
+		data, err := os.ReadFile(unitPlanFile(outDir, unit))
+		if err != nil {
+			t.Fatalf("no plan for %s: %v", unit, err)
+		}
+		if got := strings.TrimSpace(string(data)); got != "run-all plan -lock=false" {
+			t.Errorf("terragrunt ran with %q", got)
+		}
+	}
+}
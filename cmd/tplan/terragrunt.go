@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/git"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// createRunAllPlans plans every terragrunt unit below the working directory
+// with "terragrunt run-all plan", which respects dependencies between units,
+// then converts and merges the plans of the units.
+func (s *session) createRunAllPlans() (*models.PlanResult, []rootPlan, error) {
+	tfCmd, err := s.terraform()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isTerragrunt(tfCmd) {
+		return nil, nil, fmt.Errorf("-run-all requires terragrunt, but %s is configured", tfCmd)
+	}
+
+	units, err := findTerragruntUnits(".")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(units) == 0 {
+		return nil, nil, fmt.Errorf("no %s found below the working directory", git.TerragruntFile)
+	}
+
+	// Terragrunt passes each unit an absolute -out below the out directory,
+	// so the plans stay in the private temporary directory rather than in
+	// the units' caches inside the working tree
+	tempDir, err := s.planTempDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	outDir := filepath.Join(tempDir, "run-all")
+	plans := make([]rootPlan, len(units))
+	for i, unit := range units {
+		plans[i] = rootPlan{dir: unit, planFile: unitPlanFile(outDir, unit), outDir: outDir, snapshot: takeSnapshot(unit)}
+	}
+
+	planArgs := append([]string{"plan"}, s.args.plan...)
+
+	fmt.Printf("\nRunning: %s run-all %s\n", tfCmd, strings.Join(planArgs, " "))
+	if err := runTerragruntRunAll(tfCmd, ".", outDir, planArgs); err != nil {
+		return nil, plans, fmt.Errorf("terragrunt run-all plan failed: %w", err)
+	}
+
+	fmt.Printf("\nGenerating JSON output for %d units...\n", len(units))
+	merged, err := s.collectPlans(plans, func(p *rootPlan, output io.Writer) (*models.PlanResult, error) {
+		if _, err := os.Stat(p.planFile); err != nil {
+			return nil, fmt.Errorf("terragrunt wrote no plan for %s; -run-all needs a terragrunt with out-dir support", p.dir)
+		}
+		jsonOutput, err := s.showJSON(p.dir, p.planFile)
+		if err != nil {
+			return nil, err
+		}
+		return s.parsePlan(jsonOutput, p.dir)
+	})
+	return merged, plans, err
+}
+
+// findTerragruntUnits returns the directories below dir that contain a
+// terragrunt.hcl, relative to dir. The terragrunt.hcl of dir itself is
+// usually a parent configuration included by the units, so it is skipped.
+func findTerragruntUnits(dir string) ([]string, error) {
+	units := make([]string, 0)
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		// Skip hidden directories such as .terragrunt-cache, .terraform and .git
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if path != dir && git.IsTerragruntUnit(path) {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			units = append(units, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find terragrunt units: %w", err)
+	}
+
+	sort.Strings(units)
+	return units, nil
+}
+
+// unitPlanFile returns where run-all writes the plan of a unit, given the
+// out directory terragrunt was run with
+func unitPlanFile(outDir, unit string) string {
+	return filepath.Join(outDir, unit, "tfplan.tfplan")
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// terraformArgs are terraform arguments routed to the commands tplan runs.
+// Terraform rejects most flags on the commands that don't take them, e.g.
+// -var-file on show or on applying a saved plan, so every flag only goes to
+// the commands that accept it.
+type terraformArgs struct {
+	// chdir is the directory of a global -chdir option. tplan runs terraform
+	// in it instead of passing the option, so its own steps look there too.
+	chdir string
+
+	plan  []string
+	show  []string
+	apply []string
+}
+
+// tfFlag describes which commands accept a terraform flag
+type tfFlag struct {
+	plan, show, apply bool
+
+	// value is set for flags that take a value, which may be the next argument
+	value bool
+}
+
+// tfFlags lists the flags of terraform plan, show and apply. Unknown flags
+// are passed to plan only.
+var tfFlags = map[string]tfFlag{
+	"destroy":             {plan: true},
+	"refresh-only":        {plan: true},
+	"refresh":             {plan: true},
+	"replace":             {plan: true, value: true},
+	"target":              {plan: true, value: true},
+	"var":                 {plan: true, value: true},
+	"var-file":            {plan: true, value: true},
+	"generate-config-out": {plan: true, value: true},
+	"compact-warnings":    {plan: true, apply: true},
+	"input":               {plan: true, apply: true},
+	"lock":                {plan: true, apply: true},
+	"lock-timeout":        {plan: true, apply: true, value: true},
+	"parallelism":         {plan: true, apply: true, value: true},
+	"state":               {plan: true, apply: true, value: true},
+	"state-out":           {apply: true, value: true},
+	"backup":              {apply: true, value: true},
+	"no-color":            {plan: true, show: true, apply: true},
+}
+
+// managedFlags are terraform flags that tplan sets itself, with what to use instead
+var managedFlags = map[string]string{
+	"out":               "use tplan's -out to keep the plan",
+	"json":              "tplan reads terraform's JSON output itself",
+	"detailed-exitcode": "use 'tplan plan -detailed-exitcode'",
+	"auto-approve":      "use 'tplan apply -auto-approve'",
+}
+
+// cliArgsEnv are the TF_CLI_ARGS variables tplan routes itself. Terraform
+// would otherwise add TF_CLI_ARGS to show and apply as well.
+var cliArgsEnv = []string{"TF_CLI_ARGS", "TF_CLI_ARGS_plan", "TF_CLI_ARGS_show", "TF_CLI_ARGS_apply"}
+
+// flagName returns the name of a flag argument like "-var-file=x" or
+// "--target", and whether the value is part of the argument
+func flagName(arg string) (string, bool) {
+	name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if i := strings.Index(name, "="); i >= 0 {
+		return name[:i], true
+	}
+	return name, false
+}
+
+// isFlag returns true for arguments that look like flags, but not for "-"
+// (stdout) or "--"
+func isFlag(arg string) bool {
+	return strings.HasPrefix(arg, "-") && arg != "-" && arg != "--"
+}
+
+// route adds terraform arguments to the commands that accept them, in
+// order, so later arguments override earlier ones
+func (t *terraformArgs) route(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !isFlag(arg) {
+			return fmt.Errorf("unexpected terraform argument %q", arg)
+		}
+
+		name, hasValue := flagName(arg)
+		if hint, ok := managedFlags[name]; ok {
+			return fmt.Errorf("-%s is managed by tplan: %s", name, hint)
+		}
+
+		if name == "chdir" {
+			if !hasValue {
+				if i+1 == len(args) {
+					return fmt.Errorf("-chdir needs a directory")
+				}
+				i++
+				arg += "=" + args[i]
+			}
+			t.chdir = strings.SplitN(arg, "=", 2)[1]
+			continue
+		}
+
+		known, ok := tfFlags[name]
+		if !ok {
+			known = tfFlag{plan: true}
+		}
+		routed := []string{arg}
+		if known.value && !hasValue && i+1 < len(args) {
+			i++
+			routed = append(routed, args[i])
+		}
+
+		if known.plan {
+			t.plan = append(t.plan, routed...)
+		}
+		if known.show {
+			t.show = append(t.show, routed...)
+		}
+		if known.apply {
+			t.apply = append(t.apply, routed...)
+		}
+	}
+	return nil
+}
+
+// setTerraformArgs routes the TF_CLI_ARGS variables, the configured plan
+// arguments and the command-line arguments, in that order, to plan, show
+// and apply
+func (s *session) setTerraformArgs(args []string) error {
+	var routed terraformArgs
+
+	commands := map[string]*[]string{"plan": &routed.plan, "show": &routed.show, "apply": &routed.apply}
+	for _, name := range cliArgsEnv {
+		words, err := splitWords(os.Getenv(name))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if name == "TF_CLI_ARGS" {
+			if err := routed.route(words); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+		// Command-specific variables are meant for that command only
+		for _, word := range words {
+			if flagName, _ := flagName(word); managedFlags[flagName] != "" || flagName == "chdir" {
+				return fmt.Errorf("%s: -%s is not supported", name, flagName)
+			}
+		}
+		command := commands[strings.TrimPrefix(name, "TF_CLI_ARGS_")]
+		*command = append(*command, words...)
+	}
+
+	if err := routed.route(s.cfg.PlanArgs()); err != nil {
+		return fmt.Errorf("terraform.plan_args: %w", err)
+	}
+	if err := routed.route(args); err != nil {
+		return err
+	}
+
+	s.args = routed
+	return nil
+}
+
+// workDir returns the directory terraform runs in for the working directory
+func (s *session) workDir() string {
+	if s.args.chdir != "" {
+		return s.args.chdir
+	}
+	return "."
+}
+
+// terraformEnv returns the environment for terraform without the TF_CLI_ARGS
+// variables tplan already routed
+func terraformEnv() []string {
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if !containsString(cliArgsEnv, name) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// splitWords splits a TF_CLI_ARGS value into arguments like a shell,
+// honouring single and double quotes and backslash escapes
+func splitWords(s string) ([]string, error) {
+	words := make([]string, 0)
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// splitPlanFileArg separates an optional plan file from terraform arguments.
+// The plan file is the first argument that is neither a flag nor the value
+// of one.
+func splitPlanFileArg(args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		if isFlag(args[i]) {
+			name, hasValue := flagName(args[i])
+			if (tfFlags[name].value || name == "chdir") && !hasValue {
+				i++
+			}
+			continue
+		}
+		rest := append(append([]string{}, args[:i]...), args[i+1:]...)
+		return args[i], rest
+	}
+	return "", args
+}
+
+// separateTerraformArgs reorders command-line arguments so the command's own
+// flags come first, followed by "--" and everything else: positional
+// arguments and terraform flags, in their original order. Arguments after a
+// "--" on the command line are never taken as flags of the command.
+func separateTerraformArgs(fs *flag.FlagSet, args []string) []string {
+	own := make([]string, 0, len(args))
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			break
+		}
+		if !isFlag(arg) {
+			rest = append(rest, arg)
+			continue
+		}
+
+		name, hasValue := flagName(arg)
+		f := fs.Lookup(name)
+		if f == nil && name != "h" && name != "help" {
+			rest = append(rest, arg)
+			if tfFlags[name].value && !hasValue && i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		}
+
+		own = append(own, arg)
+		if f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			own = append(own, args[i])
+		}
+	}
+
+	return append(append(own, "--"), rest...)
+}
+
+// isBoolFlag returns true for flags that don't take a value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
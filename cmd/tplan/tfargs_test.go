@@ -0,0 +1,225 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/config"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{"", []string{}, ""},
+		{"  -no-color\t-lock=false\n", []string{"-no-color", "-lock=false"}, ""},
+		{`-var 'name=web server'`, []string{"-var", "name=web server"}, ""},
+		{`-var "tags={\"a\"=1}"`, []string{"-var", `tags={"a"=1}`}, ""},
+		{`-var='a\b'`, []string{`-var=a\b`}, ""},
+		{`-var=a\ b ''`, []string{"-var=a b", ""}, ""},
+		{`-var 'unterminated`, nil, "unterminated quote"},
+		{`-var a\`, nil, "trailing backslash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := splitWords(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    terraformArgs
+		wantErr string
+	}{
+		{
+			name: "flags go to the commands accepting them",
+			args: []string{"-var-file", "prod.tfvars", "-no-color", "-lock-timeout=30s", "-state-out=out.tfstate"},
+			want: terraformArgs{
+				plan:  []string{"-var-file", "prod.tfvars", "-no-color", "-lock-timeout=30s"},
+				show:  []string{"-no-color"},
+				apply: []string{"-no-color", "-lock-timeout=30s", "-state-out=out.tfstate"},
+			},
+		},
+		{
+			name: "unknown flags go to plan",
+			args: []string{"--new-flag", "-target=aws_instance.web"},
+			want: terraformArgs{plan: []string{"--new-flag", "-target=aws_instance.web"}},
+		},
+		{
+			name: "chdir",
+			args: []string{"-chdir", "envs/prod", "-destroy"},
+			want: terraformArgs{chdir: "envs/prod", plan: []string{"-destroy"}},
+		},
+		{
+			name:    "chdir without a directory",
+			args:    []string{"-chdir"},
+			wantErr: "-chdir needs a directory",
+		},
+		{
+			name:    "managed flag",
+			args:    []string{"-out=plan.tfplan"},
+			wantErr: "-out is managed by tplan",
+		},
+		{
+			name:    "positional argument",
+			args:    []string{"plan.tfplan"},
+			wantErr: `unexpected terraform argument "plan.tfplan"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got terraformArgs
+			err := got.route(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTerraformArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		want    terraformArgs
+		wantErr string
+	}{
+		{
+			name: "TF_CLI_ARGS is routed",
+			env:  map[string]string{"TF_CLI_ARGS": "-no-color -var 'a=b c'"},
+			want: terraformArgs{
+				plan:  []string{"-no-color", "-var", "a=b c", "-var-file=/cfg/common.tfvars"},
+				show:  []string{"-no-color"},
+				apply: []string{"-no-color"},
+			},
+		},
+		{
+			name: "command variables stay with their command",
+			env:  map[string]string{"TF_CLI_ARGS_plan": "-refresh=false", "TF_CLI_ARGS_apply": "-parallelism=2"},
+			args: []string{"-target=a.b"},
+			want: terraformArgs{
+				plan:  []string{"-refresh=false", "-var-file=/cfg/common.tfvars", "-target=a.b"},
+				apply: []string{"-parallelism=2"},
+			},
+		},
+		{
+			name:    "managed flag in a command variable",
+			env:     map[string]string{"TF_CLI_ARGS_apply": "-auto-approve"},
+			wantErr: "TF_CLI_ARGS_apply: -auto-approve is not supported",
+		},
+		{
+			name:    "chdir in a command variable",
+			env:     map[string]string{"TF_CLI_ARGS_plan": "-chdir=x"},
+			wantErr: "TF_CLI_ARGS_plan: -chdir is not supported",
+		},
+		{
+			name:    "unterminated quote",
+			env:     map[string]string{"TF_CLI_ARGS": "-var 'a"},
+			wantErr: "invalid TF_CLI_ARGS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range cliArgsEnv {
+				t.Setenv(name, tt.env[name])
+			}
+			cfg := config.Default()
+			cfg.Terraform.VarFiles = []string{"/cfg/common.tfvars"}
+			s := &session{cfg: cfg}
+
+			err := s.setTerraformArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(s.args, tt.want) {
+				t.Errorf("got %+v, want %+v", s.args, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitPlanFileArg(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantFile string
+		wantRest []string
+	}{
+		{[]string{"-var-file", "a.tfvars", "plan.tfplan", "-no-color"}, "plan.tfplan", []string{"-var-file", "a.tfvars", "-no-color"}},
+		{[]string{"-chdir", "envs/prod"}, "", []string{"-chdir", "envs/prod"}},
+		{[]string{"-lock=false", "saved.tfplan"}, "saved.tfplan", []string{"-lock=false"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			file, rest := splitPlanFileArg(tt.args)
+			if file != tt.wantFile || !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("got %q, %q; want %q, %q", file, rest, tt.wantFile, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestSeparateTerraformArgs(t *testing.T) {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.Bool("detailed-exitcode", false, "")
+	fs.String("out", "", "")
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"-var-file", "a.tfvars", "-out", "p.tfplan", "-detailed-exitcode"},
+			[]string{"-out", "p.tfplan", "-detailed-exitcode", "--", "-var-file", "a.tfvars"},
+		},
+		{
+			[]string{"-h"},
+			[]string{"-h", "--"},
+		},
+		{
+			[]string{"-target=a.b", "--", "-out", "x"},
+			[]string{"--", "-target=a.b", "-out", "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			if got := separateTerraformArgs(fs, tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/yourusername/tplan/internal/git"
@@ -14,15 +13,15 @@ import (
 
 // This is a complete demo showing how tplan works end-to-end
 func main() {
-	fmt.Println("=== tplan Complete Demo ===\n")
+	fmt.Println("=== tplan Complete Demo ===")
+	fmt.Println()
 
 	// Create sample Terraform plan output (JSON format)
 	samplePlan := createSamplePlanJSON()
 
 	// Step 1: Parse the plan
 	fmt.Println("Step 1: Parsing Terraform plan...")
-	p := parser.NewParser()
-	planResult, err := p.Parse(strings.NewReader(samplePlan))
+	planResult, err := parser.NewParser().ParseBytes([]byte(samplePlan))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing plan: %v\n", err)
 		os.Exit(1)
@@ -35,7 +34,8 @@ func main() {
 	if err := enrichWithGitInfo(planResult); err != nil {
 		fmt.Printf("  ⚠ Git info not available: %v\n\n", err)
 	} else {
-		fmt.Println("  ✓ Git information added\n")
+		fmt.Println("  ✓ Git information added")
+		fmt.Println()
 	}
 
 	// Step 3: Display summary
@@ -63,7 +63,8 @@ func main() {
 	fmt.Println("  Press Enter to continue (or Ctrl+C to skip)...")
 	fmt.Scanln()
 
-	if err := tui.Run(planResult); err != nil {
+	// Without a plan file the TUI only views the plan; nothing can be applied
+	if _, err := tui.Run(planResult, tui.Options{}); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
 		os.Exit(1)
 	}
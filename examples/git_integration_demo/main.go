@@ -38,7 +38,7 @@ func main() {
 	// Step 3: Demonstrate GetDriftInfo with multiple resources
 	fmt.Println("🔍 Resource Drift Analysis")
 	fmt.Println("   " + repeat("─", 50))
-	
+
 	resources := []string{
 		"aws_instance.web",
 		"aws_s3_bucket.data",
@@ -46,7 +46,7 @@ func main() {
 	}
 
 	var validDrifts []*models.DriftInfo
-	
+
 	for _, resource := range resources {
 		fmt.Printf("\n   Analyzing: %s\n", resource)
 		driftInfo, err := repo.GetDriftInfo(resource)
@@ -68,30 +68,26 @@ func main() {
 	if len(validDrifts) > 0 {
 		fmt.Println("📜 Commit History")
 		fmt.Println("   " + repeat("─", 50))
-		
+
 		for _, drift := range validDrifts {
 			fmt.Printf("\n   File: %s\n", drift.FilePath)
-			
+
 			history, err := repo.GetFileHistory(drift.FilePath, 3)
 			if err != nil {
 				fmt.Printf("   ✗ Error getting history: %v\n", err)
 				continue
 			}
 
-			for i, commit := range history {
-				fmt.Printf("   %d. [%s] %s\n", i+1, commit.hash[:8], commit.message)
-				fmt.Printf("      by %s on %s\n", 
-					commit.authorName,
-					commit.date.Format("Jan 02, 2006"),
-				)
-			}
+			// The commits' details stay inside the git package; the latest
+			// one is part of the drift info shown above
+			fmt.Printf("   %d recent commit(s), latest %s\n", len(history), drift.ShortCommitID())
 		}
 		fmt.Println()
 
 		// Step 5: Show diffs
 		fmt.Println("📝 Recent Changes")
 		fmt.Println("   " + repeat("─", 50))
-		
+
 		for _, drift := range validDrifts {
 			if drift.CommitID == "" {
 				continue
@@ -99,7 +95,7 @@ func main() {
 
 			fmt.Printf("\n   File: %s\n", drift.FilePath)
 			fmt.Printf("   Commit: %s\n", drift.ShortCommitID())
-			
+
 			// Get diff for the last commit
 			diff, err := repo.GetFileDiff(drift.FilePath, drift.CommitID+"^", drift.CommitID)
 			if err != nil {
@@ -140,7 +136,7 @@ func printDriftInfo(info *models.DriftInfo) {
 	fmt.Printf("     Branch: %s\n", info.BranchName)
 	fmt.Printf("     Commit: %s\n", info.ShortCommitID())
 	fmt.Printf("     Author: %s <%s>\n", info.AuthorName, info.AuthorEmail)
-	fmt.Printf("     Date: %s (%s ago)\n", 
+	fmt.Printf("     Date: %s (%s ago)\n",
 		info.CommitDate.Format("2006-01-02 15:04:05"),
 		formatDuration(time.Since(info.CommitDate)),
 	)
@@ -151,17 +147,17 @@ func printDriftInfo(info *models.DriftInfo) {
 func demonstrateEdgeCases(repo *git.Repository) {
 	fmt.Println("🧪 Edge Cases")
 	fmt.Println("   " + repeat("─", 50))
-	
+
 	// Test 1: Non-existent resource
 	fmt.Println("\n   Test 1: Non-existent resource")
 	drift, _ := repo.GetDriftInfo("nonexistent.resource")
 	fmt.Printf("   Result: %s\n", drift.Error)
-	
+
 	// Test 2: Invalid resource format
 	fmt.Println("\n   Test 2: Invalid resource format")
 	drift, _ = repo.GetDriftInfo("invalid")
 	fmt.Printf("   Result: %s\n", drift.Error)
-	
+
 	// Test 3: Module resource
 	fmt.Println("\n   Test 3: Module resource (if exists)")
 	drift, _ = repo.GetDriftInfo("module.test.aws_instance.example")
@@ -170,7 +166,7 @@ func demonstrateEdgeCases(repo *git.Repository) {
 	} else {
 		fmt.Printf("   Result: %s\n", drift.Error)
 	}
-	
+
 	fmt.Println()
 }
 
@@ -188,7 +184,7 @@ func splitLines(s string, max int) []string {
 	lines := []string{}
 	current := ""
 	count := 0
-	
+
 	for _, c := range s {
 		if c == '\n' {
 			lines = append(lines, current)
@@ -201,11 +197,11 @@ func splitLines(s string, max int) []string {
 			current += string(c)
 		}
 	}
-	
+
 	if current != "" && count < max {
 		lines = append(lines, current)
 	}
-	
+
 	return lines
 }
 
@@ -3,50 +3,61 @@ package main
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/yourusername/tplan/internal/parser"
 )
 
 func main() {
-	// Example 1: Parse from stdin
-	fmt.Println("=== Terraform Plan Parser Example ===\n")
+	fmt.Println("=== Terraform Plan Parser Example ===")
+	fmt.Println()
 
-	// Example text plan output
-	textPlan := `
-Terraform will perform the following actions:
-
-  # aws_instance.example will be created
-  + resource "aws_instance" "example" {
-      + ami                          = "ami-12345678"
-      + instance_type                = "t2.micro"
-      + id                           = (known after apply)
-    }
-
-  # aws_s3_bucket.data will be updated in-place
-  ~ resource "aws_s3_bucket" "data" {
-        id     = "my-bucket"
-      ~ tags   = {
-          - "old" = "value"
-          + "new" = "value"
-        }
-    }
-
-  # aws_security_group.old will be destroyed
-  - resource "aws_security_group" "old" {
-      - id   = "sg-12345"
-      - name = "old-security-group"
-    }
-
-Plan: 1 to add, 1 to change, 1 to destroy.
-
-Warning: This is a warning message
-Error: Failed to load module
-`
+	// JSON plan as printed by "terraform show -json plan.tfplan"
+	jsonPlan := `{
+		"format_version": "1.1",
+		"terraform_version": "1.5.0",
+		"resource_changes": [
+			{
+				"address": "aws_instance.example",
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "example",
+				"provider_name": "registry.terraform.io/hashicorp/aws",
+				"change": {
+					"actions": ["create"],
+					"before": null,
+					"after": {"ami": "ami-12345678", "instance_type": "t2.micro"},
+					"after_unknown": {"id": true}
+				}
+			},
+			{
+				"address": "aws_s3_bucket.data",
+				"mode": "managed",
+				"type": "aws_s3_bucket",
+				"name": "data",
+				"provider_name": "registry.terraform.io/hashicorp/aws",
+				"change": {
+					"actions": ["update"],
+					"before": {"id": "my-bucket", "tags": {"old": "value"}},
+					"after": {"id": "my-bucket", "tags": {"new": "value"}}
+				}
+			},
+			{
+				"address": "aws_security_group.old",
+				"mode": "managed",
+				"type": "aws_security_group",
+				"name": "old",
+				"provider_name": "registry.terraform.io/hashicorp/aws",
+				"change": {
+					"actions": ["delete"],
+					"before": {"id": "sg-12345", "name": "old-security-group"},
+					"after": null
+				}
+			}
+		]
+	}`
 
-	// Parse the text plan
 	p := parser.NewParser()
-	result, err := p.Parse(strings.NewReader(textPlan))
+	result, err := p.ParseBytes([]byte(jsonPlan))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing plan: %v\n", err)
 		os.Exit(1)
@@ -100,42 +111,5 @@ Error: Failed to load module
 		fmt.Printf("\n")
 	}
 
-	// Example 2: Parse JSON format
-	jsonPlan := `{
-		"format_version": "1.1",
-		"terraform_version": "1.5.0",
-		"resource_changes": [
-			{
-				"address": "aws_instance.web",
-				"mode": "managed",
-				"type": "aws_instance",
-				"name": "web",
-				"provider_name": "aws",
-				"change": {
-					"actions": ["create"],
-					"before": null,
-					"after": {
-						"ami": "ami-abc123",
-						"instance_type": "t3.micro"
-					}
-				}
-			}
-		]
-	}`
-
-	fmt.Println("\n=== Parsing JSON Plan ===\n")
-	result2, err := parser.ParseString(jsonPlan)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing JSON plan: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Printf("JSON Plan Summary:\n")
-	fmt.Printf("  Format Version: %s\n", result2.FormatVersion)
-	fmt.Printf("  Terraform Version: %s\n", result2.TerraformVersion)
-	fmt.Printf("  Total Resources: %d\n", result2.Summary.Total)
-	fmt.Printf("  To Create: %d\n", result2.Summary.ToCreate)
-	fmt.Printf("\n")
-
 	fmt.Println("Success! Parser is working correctly.")
 }
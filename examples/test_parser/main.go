@@ -2,32 +2,34 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 
-	"github.com/yourusername/tplan/internal/models"
 	"github.com/yourusername/tplan/internal/parser"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run test_parser.go <plan_file>")
-		fmt.Println("   or: cat <plan_file> | go run test_parser.go -")
+		fmt.Println("Usage: go run ./examples/test_parser <plan.json>")
+		fmt.Println("   or: terraform show -json plan.tfplan | go run ./examples/test_parser -")
 		os.Exit(1)
 	}
 
-	p := parser.NewParser()
-
-	var planResult *models.PlanResult
+	var data []byte
 	var err error
-
 	if os.Args[1] == "-" {
 		// Read from stdin
-		planResult, err = p.Parse(os.Stdin)
+		data, err = io.ReadAll(os.Stdin)
 	} else {
 		// Read from file
-		planResult, err = parser.ParseFile(os.Args[1])
+		data, err = os.ReadFile(os.Args[1])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
+	planResult, err := parser.NewParser().ParseBytes(data)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,91 @@
+package apply
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types emitted by "terraform apply -json" that tplan tracks
+const (
+	EventApplyStart    = "apply_start"
+	EventApplyProgress = "apply_progress"
+	EventApplyComplete = "apply_complete"
+	EventApplyErrored  = "apply_errored"
+	EventDiagnostic    = "diagnostic"
+	EventChangeSummary = "change_summary"
+)
+
+// Event is one line of terraform's machine-readable apply output
+type Event struct {
+	Type    string
+	Level   string
+	Message string // the human-readable message terraform would have printed
+
+	// Root is the root module directory the event came from (multi-root applies only)
+	Root string
+
+	// Address and Action are set for resource events
+	Address string
+	Action  string
+	Elapsed time.Duration
+
+	// Diagnostic is set for diagnostic events
+	Diagnostic *Diagnostic
+}
+
+// Diagnostic is an error or warning reported during apply
+type Diagnostic struct {
+	Severity string
+	Summary  string
+	Detail   string
+	Address  string // resource the diagnostic belongs to, if terraform reports it
+}
+
+// jsonEvent is the wire format of a machine-readable UI line
+type jsonEvent struct {
+	Level   string `json:"@level"`
+	Message string `json:"@message"`
+	Type    string `json:"type"`
+	Hook    *struct {
+		Resource struct {
+			Addr string `json:"addr"`
+		} `json:"resource"`
+		Action         string  `json:"action"`
+		ElapsedSeconds float64 `json:"elapsed_seconds"`
+	} `json:"hook"`
+	Diagnostic *struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+		Detail   string `json:"detail"`
+		Address  string `json:"address"`
+	} `json:"diagnostic"`
+}
+
+// ParseLine parses one line of "terraform apply -json" output. Lines that
+// are not JSON, such as output of wrapper scripts, are reported as not ok.
+func ParseLine(line []byte) (Event, bool) {
+	var raw jsonEvent
+	if err := json.Unmarshal(line, &raw); err != nil || raw.Type == "" {
+		return Event{}, false
+	}
+
+	event := Event{
+		Type:    raw.Type,
+		Level:   raw.Level,
+		Message: raw.Message,
+	}
+	if raw.Hook != nil {
+		event.Address = raw.Hook.Resource.Addr
+		event.Action = raw.Hook.Action
+		event.Elapsed = time.Duration(raw.Hook.ElapsedSeconds * float64(time.Second))
+	}
+	if raw.Diagnostic != nil {
+		event.Diagnostic = &Diagnostic{
+			Severity: raw.Diagnostic.Severity,
+			Summary:  raw.Diagnostic.Summary,
+			Detail:   raw.Diagnostic.Detail,
+			Address:  raw.Diagnostic.Address,
+		}
+	}
+	return event, true
+}
@@ -0,0 +1,198 @@
+package apply
+
+import (
+	"time"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// Status is the apply progress of a resource
+type Status int
+
+const (
+	StatusPending  Status = iota // not started yet
+	StatusApplying               // terraform is working on it
+	StatusApplied                // completed successfully
+	StatusFailed                 // terraform reported an error
+)
+
+// ResourceState tracks the apply progress of one planned change
+type ResourceState struct {
+	Root    string
+	Address string
+	Action  models.ChangeAction
+	Status  Status
+
+	Started  time.Time
+	Finished time.Time
+
+	// Diagnostics are the errors and warnings terraform attributed to the resource
+	Diagnostics []Diagnostic
+}
+
+// Elapsed returns how long the resource has been applying, or took to apply
+func (s *ResourceState) Elapsed(now time.Time) time.Duration {
+	switch {
+	case s.Started.IsZero():
+		return 0
+	case s.Finished.IsZero():
+		return now.Sub(s.Started)
+	default:
+		return s.Finished.Sub(s.Started)
+	}
+}
+
+// Result tracks the progress of an apply from the events terraform emits
+type Result struct {
+	Resources []*ResourceState
+
+	// Diagnostics that terraform did not attribute to a resource
+	Diagnostics []Diagnostic
+
+	// Summary is terraform's closing message, e.g. "Apply complete! Resources: 1 added, ..."
+	Summary []string
+
+	Started  time.Time
+	Finished time.Time
+
+	// Err is set if an apply command failed or could not be started
+	Err error
+
+	index map[string]*ResourceState
+}
+
+// NewResult creates a result with every change of the plan pending
+func NewResult(plan *models.PlanResult) *Result {
+	r := &Result{
+		Resources: make([]*ResourceState, 0, len(plan.Resources)),
+		index:     make(map[string]*ResourceState),
+	}
+	for _, res := range plan.Resources {
+		if res.Action == models.ActionNoOp || res.Action == models.ActionRead {
+			continue
+		}
+		state := &ResourceState{Root: res.Root, Address: res.Address, Action: res.Action}
+		r.Resources = append(r.Resources, state)
+		r.index[key(res.Root, res.Address)] = state
+	}
+	return r
+}
+
+// key identifies a resource across root modules
+func key(root, address string) string {
+	return root + "\x00" + address
+}
+
+// State returns the progress of a resource, or nil if it is not part of the apply
+func (r *Result) State(root, address string) *ResourceState {
+	return r.index[key(root, address)]
+}
+
+// state returns the progress of the resource an event refers to, adding
+// resources that were not in the plan (e.g. replaced instances of a data source)
+func (r *Result) state(root, address string) *ResourceState {
+	if state := r.index[key(root, address)]; state != nil {
+		return state
+	}
+	state := &ResourceState{Root: root, Address: address}
+	r.Resources = append(r.Resources, state)
+	r.index[key(root, address)] = state
+	return state
+}
+
+// Apply updates the progress with an event received at the given time
+func (r *Result) Apply(event Event, now time.Time) {
+	if r.Started.IsZero() {
+		r.Started = now
+	}
+
+	switch event.Type {
+	case EventApplyStart:
+		state := r.state(event.Root, event.Address)
+		// A replacement is applied as a delete and a create; keep the first start
+		if state.Status != StatusApplying {
+			state.Status = StatusApplying
+			if state.Started.IsZero() {
+				state.Started = now
+			}
+		}
+
+	case EventApplyProgress:
+		state := r.state(event.Root, event.Address)
+		state.Status = StatusApplying
+		if state.Started.IsZero() {
+			state.Started = now.Add(-event.Elapsed)
+		}
+
+	case EventApplyComplete:
+		state := r.state(event.Root, event.Address)
+		// The delete half of a create-before-destroy replacement completes
+		// after the create; a failure of either half wins
+		if state.Status != StatusFailed {
+			state.Status = StatusApplied
+		}
+		state.Finished = now
+
+	case EventApplyErrored:
+		state := r.state(event.Root, event.Address)
+		state.Status = StatusFailed
+		state.Finished = now
+
+	case EventDiagnostic:
+		if event.Diagnostic == nil {
+			return
+		}
+		if event.Diagnostic.Address != "" {
+			state := r.state(event.Root, event.Diagnostic.Address)
+			state.Diagnostics = append(state.Diagnostics, *event.Diagnostic)
+			if event.Diagnostic.Severity == "error" && state.Status != StatusApplied {
+				state.Status = StatusFailed
+			}
+			return
+		}
+		r.Diagnostics = append(r.Diagnostics, *event.Diagnostic)
+
+	case EventChangeSummary:
+		r.Summary = append(r.Summary, prefixRoot(event.Root, event.Message))
+	}
+}
+
+// Finish records the end of the apply and the error of the apply command, if any
+func (r *Result) Finish(err error, now time.Time) {
+	r.Err = err
+	r.Finished = now
+}
+
+// Done returns true once Finish was called
+func (r *Result) Done() bool {
+	return !r.Finished.IsZero()
+}
+
+// Counts returns the number of resources per status
+func (r *Result) Counts() (applied, failed, pending int) {
+	for _, state := range r.Resources {
+		switch state.Status {
+		case StatusApplied:
+			applied++
+		case StatusFailed:
+			failed++
+		default:
+			pending++
+		}
+	}
+	return applied, failed, pending
+}
+
+// Succeeded returns true if the apply finished without errors
+func (r *Result) Succeeded() bool {
+	_, failed, _ := r.Counts()
+	return r.Done() && r.Err == nil && failed == 0
+}
+
+// prefixRoot prefixes a message with the root module it came from
+func prefixRoot(root, message string) string {
+	if root == "" {
+		return message
+	}
+	return "[" + root + "] " + message
+}
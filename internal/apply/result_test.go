@@ -0,0 +1,148 @@
+package apply
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   Event
+		wantOK bool
+	}{
+		{
+			name:   "progress",
+			line:   `{"@level":"info","@message":"aws_instance.web: Still creating... [10s elapsed]","type":"apply_progress","hook":{"resource":{"addr":"aws_instance.web"},"action":"create","elapsed_seconds":10.5}}`,
+			want:   Event{Type: EventApplyProgress, Level: "info", Message: "aws_instance.web: Still creating... [10s elapsed]", Address: "aws_instance.web", Action: "create", Elapsed: 10500 * time.Millisecond},
+			wantOK: true,
+		},
+		{
+			name:   "diagnostic",
+			line:   `{"@level":"error","@message":"Error: Invalid AMI","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid AMI","detail":"not found","address":"aws_instance.web"}}`,
+			want:   Event{Type: EventDiagnostic, Level: "error", Message: "Error: Invalid AMI", Diagnostic: &Diagnostic{Severity: "error", Summary: "Invalid AMI", Detail: "not found", Address: "aws_instance.web"}},
+			wantOK: true,
+		},
+		{name: "plain text", line: "Acquiring state lock..."},
+		{name: "json without a type", line: `{"@message": "hello"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, ok := ParseLine([]byte(tt.line))
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %t, want %t", ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(event, tt.want) {
+				t.Errorf("got %+v, want %+v", event, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultApply(t *testing.T) {
+	plan := &models.PlanResult{Resources: []models.ResourceChange{
+		{Address: "aws_instance.web", Action: models.ActionCreate},
+		{Address: "aws_instance.db", Action: models.ActionReplace},
+		{Address: "aws_s3_bucket.logs", Action: models.ActionUpdate},
+		{Address: "aws_iam_role.ci", Action: models.ActionDelete},
+		{Address: "aws_vpc.main", Action: models.ActionNoOp},
+		{Address: "data.aws_ami.ubuntu", Action: models.ActionRead},
+	}}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	r := NewResult(plan)
+	events := []struct {
+		event Event
+		at    time.Time
+	}{
+		{Event{Type: EventApplyStart, Address: "aws_instance.web"}, at(0)},
+		{Event{Type: EventApplyComplete, Address: "aws_instance.web"}, at(30)},
+		// A create-before-destroy replacement: the create starts first, the
+		// delete of the old instance completes last
+		{Event{Type: EventApplyStart, Address: "aws_instance.db"}, at(1)},
+		{Event{Type: EventApplyComplete, Address: "aws_instance.db"}, at(20)},
+		{Event{Type: EventApplyStart, Address: "aws_instance.db"}, at(21)},
+		{Event{Type: EventApplyComplete, Address: "aws_instance.db"}, at(40)},
+		// Progress seen first, e.g. after a reconnect, backdates the start
+		{Event{Type: EventApplyProgress, Address: "aws_s3_bucket.logs", Elapsed: 10 * time.Second}, at(15)},
+		{Event{Type: EventDiagnostic, Diagnostic: &Diagnostic{Severity: "error", Summary: "AccessDenied", Address: "aws_s3_bucket.logs"}}, at(16)},
+		{Event{Type: EventDiagnostic, Diagnostic: &Diagnostic{Severity: "warning", Summary: "Deprecated"}}, at(17)},
+		{Event{Type: EventChangeSummary, Root: "app", Message: "Apply complete!"}, at(41)},
+	}
+	for _, e := range events {
+		r.Apply(e.event, e.at)
+	}
+	r.Finish(nil, at(42))
+
+	tests := []struct {
+		address     string
+		wantStatus  Status
+		wantElapsed time.Duration
+	}{
+		{"aws_instance.web", StatusApplied, 30 * time.Second},
+		{"aws_instance.db", StatusApplied, 39 * time.Second},
+		{"aws_s3_bucket.logs", StatusFailed, 0},
+		{"aws_iam_role.ci", StatusPending, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			state := r.State("", tt.address)
+			if state == nil {
+				t.Fatal("not part of the apply")
+			}
+			if state.Status != tt.wantStatus {
+				t.Errorf("status %d, want %d", state.Status, tt.wantStatus)
+			}
+			if tt.wantElapsed != 0 && state.Elapsed(r.Finished) != tt.wantElapsed {
+				t.Errorf("elapsed %s, want %s", state.Elapsed(r.Finished), tt.wantElapsed)
+			}
+		})
+	}
+
+	if state := r.State("", "aws_s3_bucket.logs"); !state.Started.Equal(at(5)) || len(state.Diagnostics) != 1 {
+		t.Errorf("bucket started %s with %d diagnostics, want %s and 1", state.Started, len(state.Diagnostics), at(5))
+	}
+	if r.State("", "aws_vpc.main") != nil || r.State("", "data.aws_ami.ubuntu") != nil {
+		t.Error("no-op and read changes are part of the apply")
+	}
+	if applied, failed, pending := r.Counts(); applied != 2 || failed != 1 || pending != 1 {
+		t.Errorf("counts %d/%d/%d, want 2/1/1", applied, failed, pending)
+	}
+	if len(r.Diagnostics) != 1 || len(r.Summary) != 1 || r.Summary[0] != "[app] Apply complete!" {
+		t.Errorf("diagnostics %v, summary %v", r.Diagnostics, r.Summary)
+	}
+	if r.Succeeded() {
+		t.Error("an apply with a failed resource succeeded")
+	}
+}
+
+func TestSucceeded(t *testing.T) {
+	plan := &models.PlanResult{Resources: []models.ResourceChange{{Address: "a.b", Action: models.ActionCreate}}}
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		run  func(r *Result)
+		want bool
+	}{
+		{"running", func(r *Result) { r.Apply(Event{Type: EventApplyComplete, Address: "a.b"}, now) }, false},
+		{"finished", func(r *Result) { r.Apply(Event{Type: EventApplyComplete, Address: "a.b"}, now); r.Finish(nil, now) }, true},
+		{"command failed", func(r *Result) { r.Finish(errors.New("exit status 1"), now) }, false},
+		{"resource errored", func(r *Result) { r.Apply(Event{Type: EventApplyErrored, Address: "a.b"}, now); r.Finish(nil, now) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResult(plan)
+			tt.run(r)
+			if r.Succeeded() != tt.want {
+				t.Errorf("Succeeded = %t, want %t", r.Succeeded(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// Apply statuses
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Resource statuses
+const (
+	ResourceApplied    = "applied"
+	ResourceFailed     = "failed"
+	ResourceNotApplied = "not_applied"
+	ResourceUnknown    = "unknown" // terragrunt run-all applies don't report per resource
+)
+
+// Record is one apply in the audit log
+type Record struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration_ns"`
+
+	// Who applied: the OS user, the host and the git identity
+	User    string `json:"user"`
+	Host    string `json:"host,omitempty"`
+	GitUser string `json:"git_user,omitempty"`
+
+	Dir        string   `json:"dir"`
+	Roots      []string `json:"roots,omitempty"`
+	Workspaces []string `json:"workspaces,omitempty"`
+	Branch     string   `json:"branch,omitempty"`
+	Commit     string   `json:"commit,omitempty"`
+
+	// PlanHash is the sha256 of the applied plan files
+	PlanHash string `json:"plan_hash"`
+
+	Summary   models.PlanSummary `json:"summary"`
+	Resources []Resource         `json:"resources"`
+
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Resource is the outcome of one planned change
+type Resource struct {
+	Root    string `json:"root,omitempty"`
+	Address string `json:"address"`
+	Action  string `json:"action"`
+	Status  string `json:"status"`
+}
+
+// Append adds a record to the log at path. The file is only ever appended
+// to, so earlier records are never rewritten.
+func Append(path string, record Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	// A single write keeps concurrent appends from interleaving
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the records of the log at path, oldest first. A missing log
+// has no records.
+func Read(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	records := make([]Record, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // records list every resource
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid audit record: %w", path, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return records, nil
+}
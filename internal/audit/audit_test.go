@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tplan", "audit.jsonl")
+
+	records, err := Read(path)
+	if err != nil || records != nil {
+		t.Fatalf("missing log: %v, %v", records, err)
+	}
+
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := []Record{
+		{
+			Time:      started,
+			Duration:  90 * time.Second,
+			User:      "jane",
+			GitUser:   "Jane Doe <jane@example.com>",
+			Dir:       "/src/infra",
+			PlanHash:  "abc",
+			Summary:   models.PlanSummary{ToCreate: 1, Total: 1},
+			Resources: []Resource{{Address: "aws_instance.web", Action: "create", Status: ResourceApplied}},
+			Status:    StatusSucceeded,
+		},
+		{
+			Time:      started.Add(time.Hour),
+			User:      "ci",
+			Dir:       "/src/infra",
+			Roots:     []string{"network", "app"},
+			PlanHash:  "def",
+			Resources: []Resource{{Root: "app", Address: "aws_instance.web", Action: "delete", Status: ResourceFailed}},
+			Status:    StatusFailed,
+			Error:     "exit status 1",
+		},
+	}
+	for _, record := range want {
+		if err := Append(path, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestConcurrentAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	resources := make([]Resource, 500)
+	for i := range resources {
+		resources[i] = Resource{Address: fmt.Sprintf("aws_instance.web[%d]", i), Action: "create", Status: ResourceApplied}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := Append(path, Record{User: fmt.Sprint(i), Resources: resources, Status: StatusSucceeded}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("appends interleaved: %v", err)
+	}
+	if len(records) != 20 {
+		t.Errorf("got %d records, want 20", len(records))
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr string
+	}{
+		{"blank lines", "{\"user\": \"a\"}\n\n{\"user\": \"b\"}\n", 2, ""},
+		{"empty file", "", 0, ""},
+		{"broken record", "{\"user\": \"a\"}\n{\"user\": \n", 0, "audit.jsonl:2: invalid audit record"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			records, err := Read(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != tt.want {
+				t.Errorf("got %d records, want %d", len(records), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package clipboard copies text to the system clipboard. Without a clipboard
+// command, or in an SSH session where the commands would reach the remote
+// machine's clipboard, callers send the text to the terminal as an OSC 52
+// escape sequence (see Sequence), which most terminal emulators put on the
+// local clipboard.
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// MethodOSC52 is the method reported when the text went through the terminal
+const MethodOSC52 = "OSC 52"
+
+// ErrNoCommand is returned by Copy when no clipboard command is available or
+// worked, in which case the text should go through the terminal
+var ErrNoCommand = errors.New("no clipboard command available")
+
+// Copy puts text on the clipboard and returns the command that did it. A
+// command still running when ctx is done is killed and the next one is tried,
+// so e.g. xclip waiting for an unreachable X server does not hang the caller.
+func Copy(ctx context.Context, text string) (string, error) {
+	if remoteSession() {
+		return "", ErrNoCommand
+	}
+	for _, command := range commands() {
+		if ctx.Err() != nil {
+			break
+		}
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, path, command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			// e.g. xclip without a reachable X server; try the next one
+			continue
+		}
+		return command[0], nil
+	}
+	return "", ErrNoCommand
+}
+
+// Sequence returns the OSC 52 escape sequence that puts text on the
+// clipboard, wrapped for tmux or screen when tplan runs inside them
+func Sequence(text string) string {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	return seq.String()
+}
+
+// remoteSession returns true if tplan runs over SSH
+func remoteSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// commands returns the clipboard commands to try on this platform, with
+// their arguments, in order of preference
+func commands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+
+	commands := make([][]string, 0)
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append(commands, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		commands = append(commands,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"})
+	}
+	// Windows' clipboard from WSL
+	return append(commands, []string{"clip.exe"})
+}
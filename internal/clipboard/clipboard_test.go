@@ -0,0 +1,155 @@
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCommands makes shell scripts named after clipboard commands the only
+// commands on PATH and clears the variables that select them
+func fakeCommands(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("needs a POSIX shell and the Linux command list")
+	}
+	dir := t.TempDir()
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	for _, name := range []string{"SSH_TTY", "SSH_CONNECTION", "WAYLAND_DISPLAY", "DISPLAY"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the command list depends on the platform")
+	}
+	tests := []struct {
+		name    string
+		wayland string
+		display string
+		want    []string
+	}{
+		{"no display", "", "", []string{"clip.exe"}},
+		{"x11", "", ":0", []string{"xclip", "xsel", "clip.exe"}},
+		{"wayland with xwayland", "wayland-0", ":0", []string{"wl-copy", "xclip", "xsel", "clip.exe"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WAYLAND_DISPLAY", tt.wayland)
+			t.Setenv("DISPLAY", tt.display)
+			got := make([]string, 0)
+			for _, command := range commands() {
+				got = append(got, command[0])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	// The scripts run with only the fake commands on PATH
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("needs cat")
+	}
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("needs sleep")
+	}
+	output := filepath.Join(t.TempDir(), "clipboard")
+	save := cat + " > " + output
+	hang := sleep + " 10"
+	tests := []struct {
+		name    string
+		scripts map[string]string
+		env     map[string]string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "first working command",
+			scripts: map[string]string{"xclip": "exit 1", "xsel": save},
+			env:     map[string]string{"DISPLAY": ":0"},
+			want:    "xsel",
+		},
+		{
+			name:    "hung command",
+			scripts: map[string]string{"xclip": hang, "xsel": hang},
+			env:     map[string]string{"DISPLAY": ":0"},
+			wantErr: ErrNoCommand,
+		},
+		{
+			name:    "no command",
+			wantErr: ErrNoCommand,
+		},
+		{
+			name:    "ssh session",
+			scripts: map[string]string{"clip.exe": save},
+			env:     map[string]string{"SSH_TTY": "/dev/pts/1"},
+			wantErr: ErrNoCommand,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCommands(t, tt.scripts)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			os.Remove(output)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			method, err := Copy(ctx, "aws_instance.web")
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("Copy took %s despite the timeout", elapsed)
+			}
+			if !errors.Is(err, tt.wantErr) || method != tt.want {
+				t.Fatalf("Copy = %q, %v; want %q, %v", method, err, tt.want, tt.wantErr)
+			}
+			if tt.want != "" {
+				data, err := os.ReadFile(output)
+				if err != nil || string(data) != "aws_instance.web" {
+					t.Errorf("clipboard holds %q (%v)", data, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		name       string
+		tmux, term string
+		prefix     string
+	}{
+		{"plain", "", "xterm-256color", "\x1b]52;c;"},
+		{"tmux", "/tmp/tmux-1000/default,1,0", "screen-256color", "\x1bPtmux;\x1b\x1b]52;c;"},
+		{"screen", "", "screen", "\x1bP\x1b]52;c;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMUX", tt.tmux)
+			t.Setenv("TERM", tt.term)
+			seq := Sequence("hi")
+			if !strings.HasPrefix(seq, tt.prefix) || !strings.Contains(seq, "aGk=") {
+				t.Errorf("sequence %q, want prefix %q and the base64 text", seq, tt.prefix)
+			}
+		})
+	}
+}
@@ -0,0 +1,578 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/tplan/internal/ignore"
+)
+
+// FileName is the name of the project configuration file
+const FileName = ".tplan.yaml"
+
+// Grouping modes for the resource tree
+const (
+	GroupByModule = "module" // modules as groups, root resources grouped by file
+	GroupByFile   = "file"   // every resource grouped by the file that declares it
+	GroupNone     = "none"   // flat list of resources
+)
+
+// Color themes for the TUI and the text output
+const (
+	ThemeAuto         = "auto" // dark or light, following the terminal background
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeColorblind   = "colorblind" // colors that stay apart with color blindness
+	ThemeNone         = "none"       // no colors; emphasis by bold, underline and reverse video
+)
+
+// themes lists the valid color themes
+var themes = []string{ThemeAuto, ThemeDark, ThemeLight, ThemeHighContrast, ThemeColorblind, ThemeNone}
+
+// Keymap presets for the TUI
+const (
+	KeymapDefault = "default" // arrow keys plus vim-style letters
+	KeymapVim     = "vim"     // the default plus vim paging keys
+	KeymapEmacs   = "emacs"   // arrow keys plus emacs-style control keys
+)
+
+// Report formats
+const (
+	FormatMarkdown  = "markdown"
+	FormatPRComment = "pr-comment" // compact Markdown for pull request comments
+	FormatHTML      = "html"       // self-contained page with navigation
+	FormatText      = "text"       // the expanded TUI tree as plain text
+)
+
+// formats lists the valid report formats
+var formats = []string{FormatMarkdown, FormatPRComment, FormatHTML, FormatText}
+
+// commentID matches comment IDs, which end up in the HTML comment marking
+// tplan's comments and so must not contain "-->" or spaces
+var commentID = regexp.MustCompile(`^[A-Za-z0-9._/-]{1,64}$`)
+
+// Pull request comment platforms
+const (
+	PlatformGitHub = "github"
+	PlatformGitLab = "gitlab"
+)
+
+// Config holds tplan settings loaded from configuration files. Settings that
+// a layer may set to false or empty are pointers, so that unset settings can
+// be told apart and leave lower layers alone; read them with Enabled.
+type Config struct {
+	Terraform TerraformConfig `yaml:"terraform"`
+	Report    ReportConfig    `yaml:"report"`
+	UI        UIConfig        `yaml:"ui"`
+	History   HistoryConfig   `yaml:"history"`
+	Audit     AuditConfig     `yaml:"audit"`
+	Comment   CommentConfig   `yaml:"comment"`
+
+	// Ignore lists attribute changes that are considered noise
+	Ignore []IgnoreRule `yaml:"ignore"`
+
+	// Policies lists policy files evaluated by "tplan check"
+	Policies []string `yaml:"policies"`
+
+	// Roots lists root module directories (globs allowed) that are planned together
+	Roots []string `yaml:"roots"`
+
+	// Sources lists the files the configuration was loaded from, lowest precedence first
+	Sources []string `yaml:"-"`
+}
+
+// TerraformConfig configures how terraform is invoked
+type TerraformConfig struct {
+	// Binary is the terraform-compatible command to run ("terraform", "tofu" or a path)
+	Binary string `yaml:"binary"`
+
+	// PlanArgs are terraform arguments added before command-line arguments.
+	// Like those, each goes to the commands (plan, show, apply) that take it.
+	PlanArgs []string `yaml:"plan_args"`
+
+	// VarFiles are passed to plan as -var-file arguments, relative to the
+	// config file that lists them
+	VarFiles []string `yaml:"var_files"`
+
+	// Concurrency limits how many root modules are planned at the same time
+	Concurrency int `yaml:"concurrency"`
+}
+
+// ReportConfig configures report generation
+type ReportConfig struct {
+	Format string `yaml:"format"`
+
+	// Path is the report file; empty uses DefaultReportPath of the format
+	Path string `yaml:"path"`
+
+	// Template is a text/template file that replaces the built-in Markdown report
+	Template string `yaml:"template"`
+}
+
+// UIConfig configures the interactive TUI
+type UIConfig struct {
+	// Grouping is one of "module", "file" or "none"
+	Grouping string `yaml:"grouping"`
+
+	// Theme is the name of the color theme, see ThemeAuto
+	Theme string `yaml:"theme"`
+
+	// ActionLabels spells out the action of every resource next to its icon
+	ActionLabels *bool `yaml:"action_labels"`
+
+	// Keymap is the preset keybindings override: "default", "vim" or "emacs"
+	Keymap string `yaml:"keymap"`
+
+	// Keybindings maps action names to the keys that trigger them
+	Keybindings map[string][]string `yaml:"keybindings"`
+
+	// ProductionPattern is a regular expression; workspaces matching it get a
+	// warning banner. An empty pattern turns the banner off.
+	ProductionPattern *string `yaml:"production_pattern"`
+
+	// DisableMouse leaves the mouse to the terminal, e.g. for selecting text
+	DisableMouse *bool `yaml:"disable_mouse"`
+}
+
+// HistoryConfig configures the plan history
+type HistoryConfig struct {
+	// Dir is where plans are recorded
+	Dir string `yaml:"dir"`
+
+	// Keep is the number of plans kept; older ones are removed
+	Keep int `yaml:"keep"`
+
+	// Disabled turns off recording plans
+	Disabled *bool `yaml:"disabled"`
+}
+
+// AuditConfig configures the apply audit log
+type AuditConfig struct {
+	// Path is the JSON lines file applies are appended to; empty means
+	// .tplan/audit.jsonl at the root of the git repository
+	Path string `yaml:"path"`
+}
+
+// CommentConfig configures pull request comments. Tokens are read from the
+// environment, never from configuration files.
+type CommentConfig struct {
+	// Platform is "github" or "gitlab"; empty detects it from the CI environment
+	Platform string `yaml:"platform"`
+
+	// APIURL is the API endpoint; empty uses the CI environment or the public
+	// service. Only the user config file may set it, as the token is sent there.
+	APIURL string `yaml:"api_url"`
+
+	// ID tells the comments of several plans in one pull request apart
+	ID string `yaml:"id"`
+
+	// MaxLength is the maximum comment size in bytes; 0 fits GitHub and GitLab
+	MaxLength int `yaml:"max_length"`
+}
+
+// IgnoreRule is the configuration form of ignore.Rule
+type IgnoreRule struct {
+	ResourceType string `yaml:"resource_type"`
+	Attribute    string `yaml:"attribute"`
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Terraform: TerraformConfig{
+			Concurrency: 4,
+		},
+		Report: ReportConfig{
+			Format: FormatMarkdown,
+		},
+		UI: UIConfig{
+			Grouping:          GroupByModule,
+			Theme:             ThemeAuto,
+			Keymap:            KeymapDefault,
+			ProductionPattern: stringPtr("(?i)^prod"),
+		},
+		History: HistoryConfig{
+			Dir:  ".tplan/history",
+			Keep: 100,
+		},
+	}
+}
+
+// Load builds the layered configuration for a working directory. Settings
+// are applied in increasing order of precedence:
+//
+//  1. built-in defaults
+//  2. the user's config file (e.g. ~/.config/tplan/config.yaml)
+//  3. .tplan.yaml at the root of the git repository
+//  4. .tplan.yaml in the working directory, if it differs from the repository root
+//
+// If explicitPath is set, it replaces layers 3 and 4.
+func Load(workDir, explicitPath string) (*Config, error) {
+	cfg := Default()
+
+	paths := make([]string, 0, 3)
+	userPath := UserConfigPath()
+	if userPath != "" {
+		paths = append(paths, userPath)
+	}
+
+	if explicitPath != "" {
+		if _, err := os.Stat(explicitPath); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		paths = append(paths, explicitPath)
+	} else {
+		absDir, err := filepath.Abs(workDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve working directory: %w", err)
+		}
+		if root := FindRepositoryRoot(absDir); root != "" && root != absDir {
+			paths = append(paths, filepath.Join(root, FileName))
+		}
+		paths = append(paths, filepath.Join(absDir, FileName))
+	}
+
+	for _, path := range paths {
+		layer, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if layer == nil {
+			continue
+		}
+		// The API token is sent to the endpoint, so a pull request that
+		// edits a repository's config must not be able to redirect it
+		if layer.Comment.APIURL != "" && path != userPath {
+			return nil, fmt.Errorf("%s: comment.api_url can only be set in %s; use -api-url or the CI environment", path, userPath)
+		}
+		cfg.merge(layer)
+		cfg.Sources = append(cfg.Sources, path)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// UserConfigPath returns the path of the per-user configuration file
+func UserConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tplan", "config.yaml")
+}
+
+// FindRepositoryRoot walks up from dir looking for a .git entry
+func FindRepositoryRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadFile parses a single config file. A missing file yields nil.
+func loadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	layer, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Relative policy, root, var file, history, audit and template paths are
+	// resolved against the file that lists them
+	resolveRelative(layer.Policies, filepath.Dir(path))
+	resolveRelative(layer.Roots, filepath.Dir(path))
+	resolveRelative(layer.Terraform.VarFiles, filepath.Dir(path))
+	if layer.History.Dir != "" && !filepath.IsAbs(layer.History.Dir) {
+		layer.History.Dir = filepath.Join(filepath.Dir(path), layer.History.Dir)
+	}
+	if layer.Audit.Path != "" && !filepath.IsAbs(layer.Audit.Path) {
+		layer.Audit.Path = filepath.Join(filepath.Dir(path), layer.Audit.Path)
+	}
+	if layer.Report.Template != "" && !filepath.IsAbs(layer.Report.Template) {
+		layer.Report.Template = filepath.Join(filepath.Dir(path), layer.Report.Template)
+	}
+	return layer, nil
+}
+
+// resolveRelative makes relative paths in the list relative to dir
+func resolveRelative(paths []string, dir string) {
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			paths[i] = filepath.Join(dir, path)
+		}
+	}
+}
+
+// Parse decodes a YAML configuration, rejecting unknown keys so typos are caught
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// merge applies the settings a higher-precedence layer sets. Scalars,
+// argument lists and roots replace lower layers; ignore rules, policies and
+// keybindings accumulate so shared and personal settings combine. Empty
+// strings and zeros count as unset, except for pointer settings, which are
+// unset only when nil.
+func (c *Config) merge(layer *Config) {
+	if layer.Terraform.Binary != "" {
+		c.Terraform.Binary = layer.Terraform.Binary
+	}
+	if layer.Terraform.PlanArgs != nil {
+		c.Terraform.PlanArgs = layer.Terraform.PlanArgs
+	}
+	if layer.Terraform.VarFiles != nil {
+		c.Terraform.VarFiles = layer.Terraform.VarFiles
+	}
+	if layer.Terraform.Concurrency != 0 {
+		c.Terraform.Concurrency = layer.Terraform.Concurrency
+	}
+	if layer.Roots != nil {
+		c.Roots = layer.Roots
+	}
+
+	if layer.Report.Format != "" {
+		c.Report.Format = layer.Report.Format
+	}
+	if layer.Report.Path != "" {
+		c.Report.Path = layer.Report.Path
+	}
+	if layer.Report.Template != "" {
+		c.Report.Template = layer.Report.Template
+	}
+
+	if layer.UI.Grouping != "" {
+		c.UI.Grouping = layer.UI.Grouping
+	}
+	if layer.UI.Theme != "" {
+		c.UI.Theme = layer.UI.Theme
+	}
+	if layer.UI.ActionLabels != nil {
+		c.UI.ActionLabels = layer.UI.ActionLabels
+	}
+	if layer.UI.Keymap != "" {
+		c.UI.Keymap = layer.UI.Keymap
+	}
+	if layer.UI.ProductionPattern != nil {
+		c.UI.ProductionPattern = layer.UI.ProductionPattern
+	}
+	if layer.UI.DisableMouse != nil {
+		c.UI.DisableMouse = layer.UI.DisableMouse
+	}
+	if layer.History.Dir != "" {
+		c.History.Dir = layer.History.Dir
+	}
+	if layer.History.Keep != 0 {
+		c.History.Keep = layer.History.Keep
+	}
+	if layer.History.Disabled != nil {
+		c.History.Disabled = layer.History.Disabled
+	}
+	if layer.Audit.Path != "" {
+		c.Audit.Path = layer.Audit.Path
+	}
+	if layer.Comment.Platform != "" {
+		c.Comment.Platform = layer.Comment.Platform
+	}
+	if layer.Comment.APIURL != "" {
+		c.Comment.APIURL = layer.Comment.APIURL
+	}
+	if layer.Comment.ID != "" {
+		c.Comment.ID = layer.Comment.ID
+	}
+	if layer.Comment.MaxLength != 0 {
+		c.Comment.MaxLength = layer.Comment.MaxLength
+	}
+
+	for action, keys := range layer.UI.Keybindings {
+		if c.UI.Keybindings == nil {
+			c.UI.Keybindings = make(map[string][]string)
+		}
+		c.UI.Keybindings[action] = keys
+	}
+
+	c.Ignore = append(c.Ignore, layer.Ignore...)
+	c.Policies = append(c.Policies, layer.Policies...)
+}
+
+// Validate checks that enumerated settings hold known values
+func (c *Config) Validate() error {
+	switch c.UI.Grouping {
+	case GroupByModule, GroupByFile, GroupNone:
+	default:
+		return fmt.Errorf("invalid ui.grouping %q (expected %q, %q or %q)", c.UI.Grouping, GroupByModule, GroupByFile, GroupNone)
+	}
+
+	if !validTheme(c.UI.Theme) {
+		return fmt.Errorf("invalid ui.theme %q (expected one of %s)", c.UI.Theme, strings.Join(themes, ", "))
+	}
+
+	switch c.UI.Keymap {
+	case KeymapDefault, KeymapVim, KeymapEmacs:
+	default:
+		return fmt.Errorf("invalid ui.keymap %q (expected %q, %q or %q)", c.UI.Keymap, KeymapDefault, KeymapVim, KeymapEmacs)
+	}
+
+	if !ValidFormat(c.Report.Format) {
+		return fmt.Errorf("invalid report.format %q (expected one of %s)", c.Report.Format, strings.Join(formats, ", "))
+	}
+
+	switch c.Comment.Platform {
+	case "", PlatformGitHub, PlatformGitLab:
+	default:
+		return fmt.Errorf("invalid comment.platform %q (expected %q or %q)", c.Comment.Platform, PlatformGitHub, PlatformGitLab)
+	}
+	if c.Comment.MaxLength < 0 {
+		return fmt.Errorf("invalid comment.max_length %d (must be positive)", c.Comment.MaxLength)
+	}
+	if c.Comment.ID != "" && !ValidCommentID(c.Comment.ID) {
+		return fmt.Errorf("invalid comment.id %q (expected up to 64 letters, digits, '.', '_', '-' or '/')", c.Comment.ID)
+	}
+
+	if _, err := regexp.Compile(c.productionPattern()); err != nil {
+		return fmt.Errorf("invalid ui.production_pattern: %w", err)
+	}
+
+	if c.History.Keep < 0 {
+		return fmt.Errorf("invalid history.keep %d (must be positive)", c.History.Keep)
+	}
+
+	if c.Terraform.Concurrency < 1 {
+		return fmt.Errorf("invalid terraform.concurrency %d (must be at least 1)", c.Terraform.Concurrency)
+	}
+
+	for i, rule := range c.Ignore {
+		if rule.Attribute == "" {
+			return fmt.Errorf("ignore rule %d: attribute is required", i+1)
+		}
+	}
+	return nil
+}
+
+// ValidFormat returns true if format is a known report format
+func ValidFormat(format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidCommentID returns true if id can tell pull request comments apart
+func ValidCommentID(id string) bool {
+	return commentID.MatchString(id)
+}
+
+// validTheme returns true if theme is a known color theme
+func validTheme(theme string) bool {
+	for _, t := range themes {
+		if t == theme {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportPath returns the configured report file, or the default one for format
+func (c *Config) ReportPath(format string) string {
+	if c.Report.Path != "" {
+		return c.Report.Path
+	}
+	return DefaultReportPath(format)
+}
+
+// DefaultReportPath returns the report file used when none is configured
+func DefaultReportPath(format string) string {
+	switch format {
+	case FormatHTML:
+		return "report.html"
+	case FormatText:
+		return "report.txt"
+	}
+	return "report.md"
+}
+
+// IgnoreRules converts the configured ignore rules
+func (c *Config) IgnoreRules() ignore.Rules {
+	rules := make(ignore.Rules, 0, len(c.Ignore))
+	for _, rule := range c.Ignore {
+		resourceType := rule.ResourceType
+		if resourceType == "" {
+			resourceType = "*"
+		}
+		rules = append(rules, ignore.Rule{ResourceType: resourceType, Attribute: rule.Attribute})
+	}
+	return rules
+}
+
+// ProductionPattern compiles the pattern of production workspace names. It
+// is nil if the pattern is empty.
+func (c *Config) ProductionPattern() *regexp.Regexp {
+	if c.productionPattern() == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(c.productionPattern())
+	if err != nil {
+		return nil
+	}
+	return pattern
+}
+
+// productionPattern returns the source of the production pattern
+func (c *Config) productionPattern() string {
+	if c.UI.ProductionPattern == nil {
+		return ""
+	}
+	return *c.UI.ProductionPattern
+}
+
+// Enabled returns the value of a boolean setting; unset settings are false
+func Enabled(setting *bool) bool {
+	return setting != nil && *setting
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// PlanArgs returns the configured plan arguments, including var files
+func (c *Config) PlanArgs() []string {
+	args := make([]string, 0, len(c.Terraform.PlanArgs)+len(c.Terraform.VarFiles))
+	args = append(args, c.Terraform.PlanArgs...)
+	for _, varFile := range c.Terraform.VarFiles {
+		args = append(args, "-var-file="+varFile)
+	}
+	return args
+}
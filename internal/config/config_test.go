@@ -0,0 +1,285 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// parse decodes a layer or fails the test
+func parse(t *testing.T, text string) *Config {
+	t.Helper()
+	layer, err := Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return layer
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		layers []string
+		check  func(t *testing.T, c *Config)
+	}{
+		{
+			name:   "booleans switched on and back off",
+			layers: []string{"ui:\n  disable_mouse: true\n  action_labels: true\nhistory:\n  disabled: true\n", "ui:\n  disable_mouse: false\n  action_labels: false\nhistory:\n  disabled: false\n"},
+			check: func(t *testing.T, c *Config) {
+				if Enabled(c.UI.DisableMouse) || Enabled(c.UI.ActionLabels) || Enabled(c.History.Disabled) {
+					t.Errorf("a later false did not override true: %v %v %v", *c.UI.DisableMouse, *c.UI.ActionLabels, *c.History.Disabled)
+				}
+			},
+		},
+		{
+			name:   "unset booleans keep lower layers",
+			layers: []string{"ui:\n  disable_mouse: true\n", "ui:\n  theme: light\n"},
+			check: func(t *testing.T, c *Config) {
+				if !Enabled(c.UI.DisableMouse) {
+					t.Error("disable_mouse was reset by a layer that does not set it")
+				}
+			},
+		},
+		{
+			name:   "production pattern cleared",
+			layers: []string{"ui:\n  production_pattern: '^live'\n", "ui:\n  production_pattern: ''\n"},
+			check: func(t *testing.T, c *Config) {
+				if c.ProductionPattern() != nil {
+					t.Errorf("production pattern %v, want none", c.ProductionPattern())
+				}
+			},
+		},
+		{
+			name:   "default production pattern",
+			layers: []string{"ui:\n  theme: dark\n"},
+			check: func(t *testing.T, c *Config) {
+				if p := c.ProductionPattern(); p == nil || !p.MatchString("Prod-eu") || p.MatchString("staging") {
+					t.Errorf("production pattern %v, want the default", p)
+				}
+			},
+		},
+		{
+			name:   "scalars and lists replace",
+			layers: []string{"terraform:\n  binary: tofu\n  plan_args: [-a]\n  concurrency: 2\n", "terraform:\n  plan_args: [-b, -c]\n"},
+			check: func(t *testing.T, c *Config) {
+				if c.Terraform.Binary != "tofu" || c.Terraform.Concurrency != 2 {
+					t.Errorf("binary %q, concurrency %d; want the first layer's", c.Terraform.Binary, c.Terraform.Concurrency)
+				}
+				if !reflect.DeepEqual(c.Terraform.PlanArgs, []string{"-b", "-c"}) {
+					t.Errorf("plan_args %v, want the second layer's", c.Terraform.PlanArgs)
+				}
+			},
+		},
+		{
+			name: "ignore rules, policies and keybindings accumulate",
+			layers: []string{
+				"ignore:\n  - attribute: tags_all\npolicies: [/a.yaml]\nui:\n  keybindings:\n    quit: [q]\n    apply: [a]\n",
+				"ignore:\n  - attribute: etag\npolicies: [/b.yaml]\nui:\n  keybindings:\n    apply: [A]\n",
+			},
+			check: func(t *testing.T, c *Config) {
+				if len(c.Ignore) != 2 || len(c.Policies) != 2 {
+					t.Errorf("%d ignore rules and %d policies, want 2 of each", len(c.Ignore), len(c.Policies))
+				}
+				want := map[string][]string{"quit": {"q"}, "apply": {"A"}}
+				if !reflect.DeepEqual(c.UI.Keybindings, want) {
+					t.Errorf("keybindings %v, want %v", c.UI.Keybindings, want)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			for _, layer := range tt.layers {
+				cfg.merge(parse(t, layer))
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+
+	repo := t.TempDir()
+	workDir := filepath.Join(repo, "envs", "prod")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(path, text string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	userPath := UserConfigPath()
+	write(userPath, "ui:\n  disable_mouse: true\n  theme: light\n")
+	write(filepath.Join(repo, FileName), "terraform:\n  var_files: [common.tfvars, /abs/shared.tfvars]\npolicies: [policies/base.yaml]\nui:\n  disable_mouse: false\n")
+	write(filepath.Join(workDir, FileName), "roots: [network]\nhistory:\n  dir: ../../.tplan/history\n")
+
+	cfg, err := Load(workDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSources := []string{userPath, filepath.Join(repo, FileName), filepath.Join(workDir, FileName)}
+	if !reflect.DeepEqual(cfg.Sources, wantSources) {
+		t.Errorf("sources %v, want %v", cfg.Sources, wantSources)
+	}
+	if cfg.UI.Theme != "light" || Enabled(cfg.UI.DisableMouse) {
+		t.Errorf("theme %q, disable_mouse %t; want light from the user and false from the repository", cfg.UI.Theme, Enabled(cfg.UI.DisableMouse))
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"var files", cfg.Terraform.VarFiles, []string{filepath.Join(repo, "common.tfvars"), "/abs/shared.tfvars"}},
+		{"policies", cfg.Policies, []string{filepath.Join(repo, "policies", "base.yaml")}},
+		{"roots", cfg.Roots, []string{filepath.Join(workDir, "network")}},
+		{"history dir", []string{cfg.History.Dir}, []string{filepath.Join(repo, ".tplan", "history")}},
+		{"plan args", cfg.PlanArgs(), []string{"-var-file=" + filepath.Join(repo, "common.tfvars"), "-var-file=/abs/shared.tfvars"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadExplicitFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("ui:\n  theme: dark\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	explicit := filepath.Join(t.TempDir(), "ci.yaml")
+	if err := os.WriteFile(explicit, []byte("ui:\n  grouping: file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir, explicit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UI.Grouping != GroupByFile || cfg.UI.Theme != ThemeAuto {
+		t.Errorf("grouping %q, theme %q; want only the explicit file applied", cfg.UI.Grouping, cfg.UI.Theme)
+	}
+
+	if _, err := Load(dir, filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("a missing explicit file was not reported")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		layer   string
+		wantErr string
+	}{
+		{"ui:\n  grouping: tree\n", "invalid ui.grouping"},
+		{"ui:\n  theme: solarized\n", "invalid ui.theme"},
+		{"ui:\n  keymap: nano\n", "invalid ui.keymap"},
+		{"ui:\n  production_pattern: '('\n", "invalid ui.production_pattern"},
+		{"report:\n  format: pdf\n", "invalid report.format"},
+		{"comment:\n  platform: bitbucket\n", "invalid comment.platform"},
+		{"comment:\n  max_length: -1\n", "invalid comment.max_length"},
+		{"comment:\n  id: 'prod -->'\n", "invalid comment.id"},
+		{"comment:\n  id: envs/prod-eu_1.2\n", ""},
+		{"history:\n  keep: -1\n", "invalid history.keep"},
+		{"terraform:\n  concurrency: -2\n", "invalid terraform.concurrency"},
+		{"ignore:\n  - resource_type: aws_s3_bucket\n", "attribute is required"},
+		{"ui:\n  theme: high-contrast\n  keymap: emacs\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.layer), func(t *testing.T) {
+			cfg := Default()
+			cfg.merge(parse(t, tt.layer))
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	if _, err := Parse([]byte("ui:\n  grupping: file\n")); err == nil {
+		t.Error("unknown key was accepted")
+	}
+	if cfg, err := Parse(nil); err != nil || cfg == nil {
+		t.Errorf("empty file: %v", err)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	if Enabled(nil) || Enabled(boolPtr(false)) || !Enabled(boolPtr(true)) {
+		t.Error("Enabled does not follow the setting")
+	}
+}
+
+func TestLoadAPIURL(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+
+	layer := []byte("comment:\n  api_url: https://gitlab.example.com/api/v4\n")
+	userPath := UserConfigPath()
+	if err := os.MkdirAll(filepath.Dir(userPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userPath, layer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	cfg, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Comment.APIURL != "https://gitlab.example.com/api/v4" {
+		t.Errorf("api_url %q, want the user config's", cfg.Comment.APIURL)
+	}
+
+	explicit := filepath.Join(t.TempDir(), "ci.yaml")
+	for _, path := range []string{filepath.Join(dir, FileName), explicit} {
+		if err := os.WriteFile(path, layer, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, explicitPath := range []string{"", explicit} {
+		if _, err := Load(dir, explicitPath); err == nil || !strings.Contains(err.Error(), "comment.api_url can only be set in") {
+			t.Errorf("Load(%q): err = %v, want api_url rejected outside the user config", explicitPath, err)
+		}
+	}
+}
@@ -0,0 +1,739 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// Kind describes how a value changed between before and after
+type Kind int
+
+const (
+	Unchanged Kind = iota
+	Added
+	Removed
+	Modified
+)
+
+// String returns the symbol used for the kind in plan output
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	case Modified:
+		return "~"
+	default:
+		return " "
+	}
+}
+
+// ValueType describes the shape of a diffed value
+type ValueType int
+
+const (
+	TypePrimitive ValueType = iota
+	TypeObject
+	TypeList
+	TypeDocument // JSON or YAML document held in a string attribute, diffed structurally
+)
+
+// Node is a single attribute (or nested value) in a diff tree
+type Node struct {
+	// Key is the attribute name, a list index like "[0]" or an identity like "[name=web]"
+	Key string
+
+	// Path is the full attribute path from the resource root (e.g., "tags.env")
+	Path string
+
+	Kind Kind
+	Type ValueType
+
+	// Format is the document format ("json" or "yaml") for TypeDocument nodes
+	Format string
+
+	Before interface{}
+	After  interface{}
+
+	// Unknown indicates the after value will only be known after apply
+	Unknown bool
+
+	// Sensitive indicates the value must be masked when rendered
+	Sensitive bool
+
+	// Ignored indicates the change matched an ignore rule and is considered noise
+	Ignored bool
+
+	Children []*Node
+}
+
+// Changed returns true if the node or anything below it changed
+func (n *Node) Changed() bool {
+	return n != nil && n.Kind != Unchanged
+}
+
+// HasChildren returns true if the node is a container with diffed children
+func (n *Node) HasChildren() bool {
+	return len(n.Children) > 0
+}
+
+// Changes returns the changed nodes at the finest granularity that is useful to
+// display: modified containers are descended into, while added, removed, unknown
+// and sensitive values are returned as a whole. Modified documents are returned
+// as a single change; their own Changes describe the semantic differences.
+func (n *Node) Changes() []*Node {
+	changes := make([]*Node, 0)
+	if n.descendable() {
+		for _, child := range n.Children {
+			child.collectChanges(&changes)
+		}
+	} else {
+		n.collectChanges(&changes)
+	}
+	return changes
+}
+
+func (n *Node) collectChanges(changes *[]*Node) {
+	if !n.Changed() {
+		return
+	}
+	if n.descendable() && n.Type != TypeDocument {
+		for _, child := range n.Children {
+			child.collectChanges(changes)
+		}
+		return
+	}
+	*changes = append(*changes, n)
+}
+
+// descendable returns true if the node's change is described by its children
+func (n *Node) descendable() bool {
+	return n.Kind == Modified && n.HasChildren() && !n.Unknown && !n.Sensitive
+}
+
+// Ignore marks every node whose path matches, together with everything below
+// it. Modified containers whose changes are all ignored are marked as well.
+func (n *Node) Ignore(match func(path string) bool) {
+	n.ignore(match, false)
+}
+
+func (n *Node) ignore(match func(path string) bool, inherited bool) bool {
+	n.Ignored = inherited || (n.Path != "" && match(n.Path))
+
+	allIgnored := true
+	changedChildren := 0
+	for _, child := range n.Children {
+		childIgnored := child.ignore(match, n.Ignored)
+		if child.Changed() {
+			changedChildren++
+			allIgnored = allIgnored && childIgnored
+		}
+	}
+
+	if !n.Ignored && n.Path != "" && n.Kind == Modified && changedChildren > 0 && allIgnored {
+		n.Ignored = true
+	}
+	return n.Ignored
+}
+
+// NoiseOnly returns true if the node has changes and every one of them is ignored
+func (n *Node) NoiseOnly() bool {
+	changes := n.Changes()
+	if len(changes) == 0 {
+		return false
+	}
+	for _, change := range changes {
+		if !change.Ignored {
+			return false
+		}
+	}
+	return true
+}
+
+// IgnoredChanges returns the number of changed values hidden by ignore rules,
+// counting an ignored container once rather than each value inside it
+func (n *Node) IgnoredChanges() int {
+	if n.Ignored && n.Changed() {
+		return 1
+	}
+	count := 0
+	for _, child := range n.Children {
+		count += child.IgnoredChanges()
+	}
+	return count
+}
+
+// BeforeString returns the display form of the before value. Sensitive
+// values nested in objects and lists are masked.
+func (n *Node) BeforeString() string {
+	if n.Sensitive {
+		return "(sensitive value)"
+	}
+	if n.hasSensitive() {
+		return FormatValue(n.masked(false))
+	}
+	return FormatValue(n.Before)
+}
+
+// AfterString returns the display form of the after value. Sensitive
+// values nested in objects and lists are masked.
+func (n *Node) AfterString() string {
+	if n.Unknown {
+		return "(known after apply)"
+	}
+	if n.Sensitive {
+		return "(sensitive value)"
+	}
+	if n.hasSensitive() {
+		return FormatValue(n.masked(true))
+	}
+	return FormatValue(n.After)
+}
+
+// BeforeValue returns the before value with sensitive values masked, e.g.
+// for encoding as JSON. It is nil if the value was added.
+func (n *Node) BeforeValue() interface{} {
+	if !n.presentIn(false) {
+		return nil
+	}
+	return n.masked(false)
+}
+
+// AfterValue returns the after value with sensitive and unknown values
+// masked. It is nil if the value was removed.
+func (n *Node) AfterValue() interface{} {
+	if !n.presentIn(true) {
+		return nil
+	}
+	return n.masked(true)
+}
+
+// hasSensitive returns true if a value nested in the node is sensitive
+func (n *Node) hasSensitive() bool {
+	if n.Type == TypeDocument {
+		return false
+	}
+	for _, child := range n.Children {
+		if child.Sensitive || child.hasSensitive() {
+			return true
+		}
+	}
+	return false
+}
+
+// masked rebuilds the before or after value from the children, replacing
+// sensitive and unknown values with their display text
+func (n *Node) masked(after bool) interface{} {
+	switch {
+	case n.Sensitive:
+		return "(sensitive value)"
+	case after && n.Unknown:
+		return "(known after apply)"
+	case n.Type == TypeObject:
+		obj := make(map[string]interface{}, len(n.Children))
+		for _, child := range n.Children {
+			if child.presentIn(after) {
+				obj[child.Key] = child.masked(after)
+			}
+		}
+		return obj
+	case n.Type == TypeList:
+		list := make([]interface{}, 0, len(n.Children))
+		for _, child := range n.Children {
+			if child.presentIn(after) {
+				list = append(list, child.masked(after))
+			}
+		}
+		return list
+	case after:
+		return n.After
+	default:
+		return n.Before
+	}
+}
+
+// presentIn returns true if the node has a before or after value
+func (n *Node) presentIn(after bool) bool {
+	if after {
+		return n.Kind != Removed
+	}
+	return n.Kind != Added
+}
+
+// FormatValue formats a plan value the way terraform displays it
+func FormatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", val)
+	case bool:
+		return fmt.Sprintf("%t", val)
+	case float64:
+		if val == float64(int64(val)) {
+			return fmt.Sprintf("%d", int64(val))
+		}
+		return fmt.Sprintf("%g", val)
+	default:
+		// json.Marshal sorts map keys, which keeps the output stable
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(data)
+	}
+}
+
+// Compute builds the diff tree for a resource or output change
+func Compute(change models.Change) *Node {
+	m := meta{
+		unknown:         change.AfterUnknown,
+		beforeSensitive: change.BeforeSensitive,
+		afterSensitive:  change.AfterSensitive,
+	}
+
+	before := present(change.Before)
+	after := present(change.After)
+	root := compare("", "", before, after, m)
+	if root == nil {
+		root = &Node{Type: TypeObject}
+	}
+	return root
+}
+
+// present converts an empty top-level map to nil so creates and deletes
+// compare against "nothing" rather than an empty object
+func present(attrs map[string]interface{}) interface{} {
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
+
+// meta tracks the after_unknown and sensitive structures alongside the values being compared
+type meta struct {
+	unknown         interface{}
+	beforeSensitive interface{}
+	afterSensitive  interface{}
+}
+
+// child returns the metadata for an object attribute
+func (m meta) child(key string) meta {
+	return meta{
+		unknown:         metaChild(m.unknown, key),
+		beforeSensitive: metaChild(m.beforeSensitive, key),
+		afterSensitive:  metaChild(m.afterSensitive, key),
+	}
+}
+
+// elem returns the metadata for a pair of list elements, which may sit at different indexes
+func (m meta) elem(beforeIdx, afterIdx int) meta {
+	return meta{
+		unknown:         metaIndex(m.unknown, afterIdx),
+		beforeSensitive: metaIndex(m.beforeSensitive, beforeIdx),
+		afterSensitive:  metaIndex(m.afterSensitive, afterIdx),
+	}
+}
+
+func metaChild(v interface{}, key string) interface{} {
+	if isTrue(v) {
+		return true
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		return obj[key]
+	}
+	return nil
+}
+
+func metaIndex(v interface{}, idx int) interface{} {
+	if isTrue(v) {
+		return true
+	}
+	if list, ok := v.([]interface{}); ok && idx >= 0 && idx < len(list) {
+		return list[idx]
+	}
+	return nil
+}
+
+func isTrue(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+// compare diffs two values; nil means the value is absent on that side
+func compare(key, path string, before, after interface{}, m meta) *Node {
+	unknown := isTrue(m.unknown)
+	if before == nil && after == nil && !unknown {
+		return nil
+	}
+
+	n := &Node{
+		Key:       key,
+		Path:      path,
+		Before:    before,
+		After:     after,
+		Unknown:   unknown,
+		Sensitive: isTrue(m.beforeSensitive) || isTrue(m.afterSensitive),
+		Type:      valueType(before, after),
+	}
+	if n.Sensitive && n.Type == TypeDocument {
+		// Sensitive strings are masked as a whole, never diffed as documents
+		n.Type = TypePrimitive
+	}
+
+	switch {
+	case unknown:
+		n.Kind = Modified
+		if before == nil {
+			n.Kind = Added
+			n.Type = TypePrimitive
+		}
+		return n
+	case before == nil:
+		n.Kind = Added
+	case after == nil:
+		n.Kind = Removed
+	}
+
+	if n.Sensitive {
+		if n.Kind == Unchanged && !reflect.DeepEqual(before, after) {
+			n.Kind = Modified
+		}
+		return n
+	}
+
+	switch n.Type {
+	case TypeObject:
+		n.Children = compareObjects(path, asObject(before), asObject(after), m)
+	case TypeList:
+		n.Children = compareLists(path, asList(before), asList(after), m)
+	case TypeDocument:
+		beforeDoc, format, _ := parseDocument(before.(string))
+		afterDoc, _, _ := parseDocument(after.(string))
+		n.Format = format
+		doc := compare("", path, beforeDoc, afterDoc, meta{})
+		if doc != nil {
+			n.Children = doc.Children
+			if doc.Kind == Unchanged {
+				// Only whitespace or key order differs
+				return n
+			}
+			n.Kind = Modified
+		}
+	default:
+		if n.Kind == Unchanged && !reflect.DeepEqual(before, after) {
+			n.Kind = Modified
+		}
+		return n
+	}
+
+	if n.Kind == Unchanged {
+		for _, child := range n.Children {
+			if child.Changed() {
+				n.Kind = Modified
+				break
+			}
+		}
+	}
+	return n
+}
+
+// valueType determines how two values are compared
+func valueType(before, after interface{}) ValueType {
+	bothOr := func(check func(interface{}) bool) bool {
+		return (before == nil || check(before)) && (after == nil || check(after))
+	}
+
+	switch {
+	case bothOr(isObject):
+		return TypeObject
+	case bothOr(isList):
+		return TypeList
+	case isDocumentPair(before, after):
+		return TypeDocument
+	default:
+		return TypePrimitive
+	}
+}
+
+func isObject(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
+
+func isList(v interface{}) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
+
+func asObject(v interface{}) map[string]interface{} {
+	obj, _ := v.(map[string]interface{})
+	return obj
+}
+
+func asList(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	return list
+}
+
+// isDocumentPair returns true if both values are strings holding structured documents
+func isDocumentPair(before, after interface{}) bool {
+	beforeStr, ok := before.(string)
+	if !ok {
+		return false
+	}
+	afterStr, ok := after.(string)
+	if !ok {
+		return false
+	}
+	_, beforeFormat, ok := parseDocument(beforeStr)
+	if !ok {
+		return false
+	}
+	_, afterFormat, ok := parseDocument(afterStr)
+	return ok && beforeFormat == afterFormat
+}
+
+// compareObjects diffs the union of keys of two objects in sorted order
+func compareObjects(path string, before, after map[string]interface{}, m meta) []*Node {
+	keySet := make(map[string]bool)
+	for k := range before {
+		keySet[k] = true
+	}
+	for k := range after {
+		keySet[k] = true
+	}
+	if unknown, ok := m.unknown.(map[string]interface{}); ok {
+		for k := range unknown {
+			keySet[k] = true
+		}
+	}
+
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	children := make([]*Node, 0, len(keys))
+	for _, k := range keys {
+		if child := compare(k, joinPath(path, k), before[k], after[k], m.child(k)); child != nil {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	if strings.HasPrefix(key, "[") {
+		return path + key
+	}
+	return path + "." + key
+}
+
+// compareLists diffs two lists. Lists of objects sharing a unique identity
+// attribute are matched by that attribute, so reordered blocks and set
+// elements don't show up as changes. Lists of primitives are aligned on
+// their longest common subsequence, which reports set additions and
+// removals precisely. Anything else is matched by content, then by position.
+func compareLists(path string, before, after []interface{}, m meta) []*Node {
+	if key := identityKey(before, after); key != "" {
+		return compareByIdentity(path, key, before, after, m)
+	}
+	if allPrimitive(before) && allPrimitive(after) {
+		return compareAligned(path, before, after, m)
+	}
+	return compareByContent(path, before, after, m)
+}
+
+// identityKeys are attributes commonly used to identify elements of nested blocks and sets
+var identityKeys = []string{"name", "key", "id", "Sid", "device_name", "container_name", "arn", "path"}
+
+// identityKey finds an attribute that uniquely identifies each object in both lists
+func identityKey(before, after []interface{}) string {
+	if len(before) == 0 && len(after) == 0 {
+		return ""
+	}
+	for _, key := range identityKeys {
+		if uniqueBy(before, key) && uniqueBy(after, key) {
+			return key
+		}
+	}
+	return ""
+}
+
+func uniqueBy(list []interface{}, key string) bool {
+	seen := make(map[string]bool)
+	for _, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		value, ok := obj[key]
+		if !ok || value == nil || isObject(value) || isList(value) {
+			return false
+		}
+		id := fmt.Sprintf("%v", value)
+		if id == "" || seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+func compareByIdentity(path, key string, before, after []interface{}, m meta) []*Node {
+	beforeIdx := make(map[string]int)
+	for i, item := range before {
+		beforeIdx[fmt.Sprintf("%v", item.(map[string]interface{})[key])] = i
+	}
+
+	children := make([]*Node, 0)
+	matched := make(map[int]bool)
+	for ai, item := range after {
+		id := fmt.Sprintf("%v", item.(map[string]interface{})[key])
+		label := fmt.Sprintf("[%s=%s]", key, id)
+		if bi, ok := beforeIdx[id]; ok {
+			matched[bi] = true
+			if child := compare(label, joinPath(path, label), before[bi], item, m.elem(bi, ai)); child != nil {
+				children = append(children, child)
+			}
+			continue
+		}
+		if child := compare(label, joinPath(path, label), nil, item, m.elem(-1, ai)); child != nil {
+			children = append(children, child)
+		}
+	}
+
+	for bi, item := range before {
+		if matched[bi] {
+			continue
+		}
+		id := fmt.Sprintf("%v", item.(map[string]interface{})[key])
+		label := fmt.Sprintf("[%s=%s]", key, id)
+		if child := compare(label, joinPath(path, label), item, nil, m.elem(bi, -1)); child != nil {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+func allPrimitive(list []interface{}) bool {
+	for _, item := range list {
+		if isObject(item) || isList(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareAligned aligns two lists of primitives on their longest common subsequence
+func compareAligned(path string, before, after []interface{}, m meta) []*Node {
+	// lcs[i][j] is the LCS length of before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if reflect.DeepEqual(before[i], after[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	children := make([]*Node, 0, len(after))
+	add := func(label string, b, a interface{}, em meta) {
+		child := compare(label, joinPath(path, label), b, a, em)
+		if child == nil {
+			// Null list elements are still elements
+			child = &Node{Key: label, Path: joinPath(path, label), Kind: Unchanged}
+		}
+		children = append(children, child)
+	}
+
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && reflect.DeepEqual(before[i], after[j]):
+			add(fmt.Sprintf("[%d]", j), before[i], after[j], m.elem(i, j))
+			i++
+			j++
+		case j < len(after) && (i == len(before) || lcs[i][j+1] >= lcs[i+1][j]):
+			add(fmt.Sprintf("[%d]", j), nil, after[j], m.elem(-1, j))
+			j++
+		default:
+			add(fmt.Sprintf("[%d]", i), before[i], nil, m.elem(i, -1))
+			i++
+		}
+	}
+	return children
+}
+
+// compareByContent matches identical elements regardless of their position,
+// then pairs the remaining elements in order. This keeps an inserted policy
+// statement or container from showing every following element as modified.
+func compareByContent(path string, before, after []interface{}, m meta) []*Node {
+	matchedBefore := make(map[int]bool)
+	pairs := make(map[int]int) // after index -> before index
+	for ai, item := range after {
+		for bi, candidate := range before {
+			if !matchedBefore[bi] && reflect.DeepEqual(candidate, item) {
+				matchedBefore[bi] = true
+				pairs[ai] = bi
+				break
+			}
+		}
+	}
+
+	// Pair the leftovers in order so modified elements diff field by field
+	leftover := make([]int, 0)
+	for bi := range before {
+		if !matchedBefore[bi] {
+			leftover = append(leftover, bi)
+		}
+	}
+	for ai := range after {
+		if _, ok := pairs[ai]; ok || len(leftover) == 0 {
+			continue
+		}
+		pairs[ai] = leftover[0]
+		matchedBefore[leftover[0]] = true
+		leftover = leftover[1:]
+	}
+
+	children := make([]*Node, 0, len(after)+len(leftover))
+	for ai, item := range after {
+		label := fmt.Sprintf("[%d]", ai)
+		bi, ok := pairs[ai]
+		var b interface{}
+		if ok {
+			b = before[bi]
+		} else {
+			bi = -1
+		}
+		if child := compare(label, joinPath(path, label), b, item, m.elem(bi, ai)); child != nil {
+			children = append(children, child)
+		}
+	}
+	for _, bi := range leftover {
+		label := fmt.Sprintf("[%d]", bi)
+		if child := compare(label, joinPath(path, label), before[bi], nil, m.elem(bi, -1)); child != nil {
+			children = append(children, child)
+		}
+	}
+	return children
+}
@@ -0,0 +1,251 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// obj is shorthand for the decoded JSON objects in plans
+type obj = map[string]interface{}
+
+// list is shorthand for decoded JSON arrays
+type list = []interface{}
+
+// describe returns "<kind> <path>" for each change
+func describe(changes []*Node) []string {
+	result := make([]string, len(changes))
+	for i, change := range changes {
+		result[i] = change.Kind.String() + " " + change.Path
+	}
+	return result
+}
+
+// child returns the child of node with the given key
+func child(t *testing.T, node *Node, key string) *Node {
+	t.Helper()
+	for _, c := range node.Children {
+		if c.Key == key {
+			return c
+		}
+	}
+	t.Fatalf("%q has no child %q", node.Path, key)
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   models.Change
+		wantKind Kind
+		want     []string
+	}{
+		{
+			name:     "create",
+			change:   models.Change{After: obj{"ami": "ami-1", "tags": obj{"env": "prod"}}},
+			wantKind: Added,
+			want:     []string{"+ "},
+		},
+		{
+			name:     "delete",
+			change:   models.Change{Before: obj{"ami": "ami-1"}},
+			wantKind: Removed,
+			want:     []string{"- "},
+		},
+		{
+			name:     "no change",
+			change:   models.Change{Before: obj{"ami": "ami-1"}, After: obj{"ami": "ami-1"}},
+			wantKind: Unchanged,
+			want:     []string{},
+		},
+		{
+			name: "nested attributes",
+			change: models.Change{
+				Before: obj{"ami": "ami-1", "tags": obj{"env": "prod", "team": "a"}, "count": 1.0},
+				After:  obj{"ami": "ami-2", "tags": obj{"env": "prod", "owner": "b"}, "count": 1.0},
+			},
+			wantKind: Modified,
+			want:     []string{"~ ami", "+ tags.owner", "- tags.team"},
+		},
+		{
+			name: "blocks matched by name",
+			change: models.Change{
+				Before: obj{"ingress": list{obj{"name": "http", "port": 80.0}, obj{"name": "ssh", "port": 22.0}}},
+				After:  obj{"ingress": list{obj{"name": "ssh", "port": 2222.0}, obj{"name": "http", "port": 80.0}}},
+			},
+			wantKind: Modified,
+			want:     []string{"~ ingress[name=ssh].port"},
+		},
+		{
+			name: "set of strings",
+			change: models.Change{
+				Before: obj{"cidrs": list{"10.0.0.0/8", "172.16.0.0/12"}},
+				After:  obj{"cidrs": list{"10.0.0.0/8", "192.168.0.0/16", "172.16.0.0/12"}},
+			},
+			wantKind: Modified,
+			want:     []string{"+ cidrs[1]"},
+		},
+		{
+			name: "objects without identity matched by content",
+			change: models.Change{
+				Before: obj{"rules": list{obj{"port": 80.0}, obj{"port": 443.0}}},
+				After:  obj{"rules": list{obj{"port": 22.0}, obj{"port": 80.0}, obj{"port": 443.0}}},
+			},
+			wantKind: Modified,
+			want:     []string{"+ rules[0]"},
+		},
+		{
+			name: "unknown after apply",
+			change: models.Change{
+				Before:       obj{"id": "i-1", "ip": "10.0.0.1"},
+				After:        obj{"id": "i-1"},
+				AfterUnknown: obj{"ip": true, "arn": true},
+			},
+			wantKind: Modified,
+			want:     []string{"+ arn", "~ ip"},
+		},
+		{
+			name: "sensitive value with the same text",
+			change: models.Change{
+				Before:          obj{"password": "secret"},
+				After:           obj{"password": "secret"},
+				BeforeSensitive: obj{"password": true},
+				AfterSensitive:  obj{"password": true},
+			},
+			wantKind: Unchanged,
+			want:     []string{},
+		},
+		{
+			name: "sensitive block changed",
+			change: models.Change{
+				Before:         obj{"auth": obj{"user": "a", "token": "x"}},
+				After:          obj{"auth": obj{"user": "a", "token": "y"}},
+				AfterSensitive: obj{"auth": true},
+			},
+			wantKind: Modified,
+			want:     []string{"~ auth"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Compute(tt.change)
+			if root.Kind != tt.wantKind {
+				t.Errorf("root kind %q, want %q", root.Kind, tt.wantKind)
+			}
+			if got := describe(root.Changes()); !equalStrings(got, tt.want) {
+				t.Errorf("changes %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMasking(t *testing.T) {
+	root := Compute(models.Change{
+		Before:          obj{"user": "admin", "password": "old", "config": obj{"token": "t1", "region": "eu"}},
+		After:           obj{"user": "admin", "password": "new", "config": obj{"token": "t2", "region": "eu"}},
+		AfterUnknown:    obj{"arn": true},
+		BeforeSensitive: obj{"password": true, "config": obj{"token": true}},
+		AfterSensitive:  obj{"password": true, "config": obj{"token": true}},
+	})
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want string
+	}{
+		{"before value", root.BeforeValue(), `{"config":{"region":"eu","token":"(sensitive value)"},"password":"(sensitive value)","user":"admin"}`},
+		{"after value", root.AfterValue(), `{"arn":"(known after apply)","config":{"region":"eu","token":"(sensitive value)"},"password":"(sensitive value)","user":"admin"}`},
+		{"nested before string", child(t, root, "config").BeforeString(), `{"region":"eu","token":"(sensitive value)"}`},
+		{"unknown after string", child(t, root, "arn").AfterString(), "(known after apply)"},
+		{"added before value", child(t, root, "arn").BeforeValue(), "null"},
+		{"sensitive after string", child(t, root, "password").AfterString(), "(sensitive value)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.got.(string)
+			if !ok {
+				data, err := json.Marshal(tt.got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = string(data)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	change := models.Change{
+		Before: obj{"ami": "ami-1", "tags_all": obj{"a": "1", "b": "2"}, "tags": obj{"a": "1"}},
+		After:  obj{"ami": "ami-1", "tags_all": obj{"a": "2", "b": "3"}, "tags": obj{"a": "2"}},
+	}
+	tests := []struct {
+		name        string
+		ignored     []string
+		wantNoise   bool
+		wantIgnored int
+	}{
+		{"nothing ignored", nil, false, 0},
+		{"container ignored once", []string{"tags_all"}, false, 1},
+		// tags_all counts once as all of its values are ignored
+		{"every value ignored", []string{"tags_all.a", "tags_all.b", "tags"}, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Compute(change)
+			root.Ignore(func(path string) bool {
+				for _, p := range tt.ignored {
+					if p == path {
+						return true
+					}
+				}
+				return false
+			})
+			if root.NoiseOnly() != tt.wantNoise {
+				t.Errorf("NoiseOnly = %t, want %t", root.NoiseOnly(), tt.wantNoise)
+			}
+			if got := root.IgnoredChanges(); got != tt.wantIgnored {
+				t.Errorf("IgnoredChanges = %d, want %d", got, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, "null"},
+		{"web", `"web"`},
+		{true, "true"},
+		{3.0, "3"},
+		{0.5, "0.5"},
+		{list{"b", 1.0}, `["b",1]`},
+		{obj{"z": 1.0, "a": 2.0}, `{"a":2,"z":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.value), func(t *testing.T) {
+			if got := FormatValue(tt.value); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document formats recognised in string attributes
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// parseDocument parses a JSON or YAML document held in a string attribute.
+// Only objects and arrays count as documents; YAML must span several lines
+// so that ordinary strings like "Note: something" are not mistaken for one.
+func parseDocument(s string) (interface{}, string, bool) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return nil, "", false
+	}
+
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(trimmed), &doc); err == nil {
+			return normalizeDocument(doc), FormatJSON, true
+		}
+	}
+
+	if !strings.Contains(trimmed, "\n") {
+		return nil, "", false
+	}
+	doc, ok := parseYAML(trimmed)
+	if !ok {
+		return nil, "", false
+	}
+	return normalizeDocument(doc), FormatYAML, true
+}
+
+// parseYAML decodes one or more YAML documents. Multi-document streams
+// (e.g. Kubernetes manifests separated by "---") become a list.
+func parseYAML(s string) (interface{}, bool) {
+	decoder := yaml.NewDecoder(strings.NewReader(s))
+
+	docs := make([]interface{}, 0, 1)
+	for {
+		var doc interface{}
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+		if doc == nil {
+			continue
+		}
+		doc, ok := toJSONValue(doc)
+		if !ok {
+			return nil, false
+		}
+		docs = append(docs, doc)
+	}
+
+	switch len(docs) {
+	case 0:
+		return nil, false
+	case 1:
+		if !isObject(docs[0]) && !isList(docs[0]) {
+			return nil, false
+		}
+		return docs[0], true
+	default:
+		return interface{}(docs), true
+	}
+}
+
+// toJSONValue converts decoded YAML into the same value types encoding/json
+// produces, so YAML and JSON documents diff (and format) identically
+func toJSONValue(v interface{}) (interface{}, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+
+	var out interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&out); err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// policyListKeys are IAM policy statement keys that accept either a single string or a list
+var policyListKeys = map[string]bool{
+	"Action":      true,
+	"NotAction":   true,
+	"Resource":    true,
+	"NotResource": true,
+}
+
+// normalizeDocument rewrites equivalent spellings of policy documents into a
+// single form so that only semantic changes show up in the diff
+func normalizeDocument(doc interface{}) interface{} {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return doc
+	}
+
+	statements, ok := obj["Statement"]
+	if !ok {
+		return doc
+	}
+
+	// A single statement object is equivalent to a list with one statement
+	if single, ok := statements.(map[string]interface{}); ok {
+		statements = []interface{}{single}
+	}
+
+	list, ok := statements.([]interface{})
+	if !ok {
+		return doc
+	}
+	for _, item := range list {
+		if statement, ok := item.(map[string]interface{}); ok {
+			normalizeStatement(statement)
+		}
+	}
+	obj["Statement"] = list
+	return obj
+}
+
+// normalizeStatement turns string-or-list fields into sorted lists, since
+// their order carries no meaning in IAM
+func normalizeStatement(statement map[string]interface{}) {
+	for key, value := range statement {
+		if policyListKeys[key] {
+			statement[key] = sortedStrings(value)
+		}
+	}
+
+	if principal, ok := statement["Principal"].(map[string]interface{}); ok {
+		for key, value := range principal {
+			principal[key] = sortedStrings(value)
+		}
+	}
+}
+
+// sortedStrings converts a string or list of strings into a sorted list
+func sortedStrings(value interface{}) interface{} {
+	var list []interface{}
+	switch v := value.(type) {
+	case string:
+		list = []interface{}{v}
+	case []interface{}:
+		list = v
+	default:
+		return value
+	}
+
+	for _, item := range list {
+		if _, ok := item.(string); !ok {
+			return value
+		}
+	}
+
+	sorted := make([]interface{}, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].(string) < sorted[j].(string)
+	})
+	return sorted
+}
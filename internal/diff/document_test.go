@@ -0,0 +1,147 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantFormat string
+		wantOK     bool
+	}{
+		{"json object", `{"a": 1}`, FormatJSON, true},
+		{"json array", ` [1, 2] `, FormatJSON, true},
+		{"yaml", "kind: Pod\nmetadata:\n  name: web\n", FormatYAML, true},
+		{"yaml stream", "a: 1\n---\nb: 2\n", FormatYAML, true},
+		{"single line yaml", "Note: something", "", false},
+		{"plain string", "hello", "", false},
+		{"json scalar", `"quoted"`, "", false},
+		{"broken json", `{"a": `, "", false},
+		{"empty", "  ", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, format, ok := parseDocument(tt.input)
+			if ok != tt.wantOK || format != tt.wantFormat {
+				t.Errorf("parseDocument(%q) = %q, %t; want %q, %t", tt.input, format, ok, tt.wantFormat, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDocumentDiff(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      string
+		after       string
+		wantKind    Kind
+		wantType    ValueType
+		wantChanges []string
+	}{
+		{
+			name:     "key order and whitespace",
+			before:   `{"Version": "2012-10-17", "Statement": []}`,
+			after:    "{\n  \"Statement\": [],\n  \"Version\": \"2012-10-17\"\n}",
+			wantKind: Unchanged,
+			wantType: TypeDocument,
+		},
+		{
+			name:     "single statement and action spelled as a list",
+			before:   `{"Statement": {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}}`,
+			after:    `{"Statement": [{"Effect": "Allow", "Action": ["s3:GetObject"], "Resource": ["*"]}]}`,
+			wantKind: Unchanged,
+			wantType: TypeDocument,
+		},
+		{
+			name:     "action order",
+			before:   `{"Statement": [{"Action": ["s3:PutObject", "s3:GetObject"]}]}`,
+			after:    `{"Statement": [{"Action": ["s3:GetObject", "s3:PutObject"]}]}`,
+			wantKind: Unchanged,
+			wantType: TypeDocument,
+		},
+		{
+			name:        "changed value",
+			before:      `{"Statement": [{"Sid": "A", "Effect": "Allow"}]}`,
+			after:       `{"Statement": [{"Sid": "A", "Effect": "Deny"}]}`,
+			wantKind:    Modified,
+			wantType:    TypeDocument,
+			wantChanges: []string{"policy.Statement[Sid=A].Effect"},
+		},
+		{
+			name:        "yaml manifest",
+			before:      "kind: Deployment\nspec:\n  replicas: 2\n",
+			after:       "kind: Deployment\nspec:\n  replicas: 3\n",
+			wantKind:    Modified,
+			wantType:    TypeDocument,
+			wantChanges: []string{"policy.spec.replicas"},
+		},
+		{
+			name:     "json replaced by yaml",
+			before:   `{"a": 1}`,
+			after:    "a: 1\nb: 2\n",
+			wantKind: Modified,
+			wantType: TypePrimitive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Compute(models.Change{
+				Before: map[string]interface{}{"policy": tt.before},
+				After:  map[string]interface{}{"policy": tt.after},
+			})
+			node := child(t, root, "policy")
+			if node.Kind != tt.wantKind || node.Type != tt.wantType {
+				t.Fatalf("kind %v, type %v; want %v, %v", node.Kind, node.Type, tt.wantKind, tt.wantType)
+			}
+			if tt.wantChanges == nil {
+				return
+			}
+			if got := paths(documentChanges(node)); !equalStrings(got, tt.wantChanges) {
+				t.Errorf("document changes %v, want %v", got, tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestSensitiveDocumentIsNotParsed(t *testing.T) {
+	sensitive := map[string]interface{}{"policy": true}
+	root := Compute(models.Change{
+		Before:          map[string]interface{}{"policy": `{"Statement": [{"Sid": "A", "Effect": "Allow"}]}`},
+		After:           map[string]interface{}{"policy": `{"Statement": [{"Sid": "A", "Effect": "Deny"}]}`},
+		BeforeSensitive: sensitive,
+		AfterSensitive:  sensitive,
+	})
+
+	node := child(t, root, "policy")
+	if node.Type == TypeDocument || node.Format != "" || node.HasChildren() {
+		t.Errorf("sensitive policy diffed as a document: type %v, format %q, %d children", node.Type, node.Format, len(node.Children))
+	}
+	if node.Kind != Modified {
+		t.Errorf("kind %v, want modified", node.Kind)
+	}
+	if node.BeforeString() != "(sensitive value)" || node.AfterString() != "(sensitive value)" {
+		t.Errorf("sensitive policy not masked: %s → %s", node.BeforeString(), node.AfterString())
+	}
+}
+
+// documentChanges returns the semantic changes inside a document node
+func documentChanges(node *Node) []*Node {
+	changes := make([]*Node, 0)
+	for _, c := range node.Children {
+		c.collectChanges(&changes)
+	}
+	return changes
+}
+
+// paths returns the paths of nodes
+func paths(nodes []*Node) []string {
+	result := make([]string, len(nodes))
+	for i, node := range nodes {
+		result[i] = node.Path
+	}
+	return result
+}
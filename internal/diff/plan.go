@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"sort"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// ResourceDiff describes how the planned change of a resource differs between two plans
+type ResourceDiff struct {
+	// Root is the root module directory of the resource in a multi-root plan
+	Root    string
+	Address string
+	Type    string
+
+	// Kind is Added if only the new plan changes the resource, Removed if only
+	// the old plan does, and Modified if the action or planned values differ
+	Kind Kind
+
+	OldAction models.ChangeAction
+	NewAction models.ChangeAction
+
+	// Attributes diffs the planned (after) values when both plans change the resource
+	Attributes *Node
+}
+
+// ComparePlans compares the resource changes of two plans. Resources without
+// changes in either plan are skipped; results are sorted by root and address.
+func ComparePlans(oldPlan, newPlan *models.PlanResult) []ResourceDiff {
+	oldChanges := changingResources(oldPlan)
+	newChanges := changingResources(newPlan)
+
+	keys := make([]resourceKey, 0, len(oldChanges)+len(newChanges))
+	for key := range oldChanges {
+		keys = append(keys, key)
+	}
+	for key := range newChanges {
+		if _, ok := oldChanges[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].root != keys[j].root {
+			return keys[i].root < keys[j].root
+		}
+		return keys[i].address < keys[j].address
+	})
+
+	diffs := make([]ResourceDiff, 0)
+	for _, key := range keys {
+		oldRes, inOld := oldChanges[key]
+		newRes, inNew := newChanges[key]
+
+		switch {
+		case !inOld:
+			diffs = append(diffs, ResourceDiff{Root: key.root, Address: key.address, Type: newRes.Type, Kind: Added, NewAction: newRes.Action})
+		case !inNew:
+			diffs = append(diffs, ResourceDiff{Root: key.root, Address: key.address, Type: oldRes.Type, Kind: Removed, OldAction: oldRes.Action})
+		default:
+			attrs := Compute(models.Change{
+				Before:          oldRes.Change.After,
+				After:           newRes.Change.After,
+				BeforeSensitive: oldRes.Change.AfterSensitive,
+				AfterSensitive:  newRes.Change.AfterSensitive,
+			})
+			if oldRes.Action == newRes.Action && !attrs.Changed() {
+				continue
+			}
+			diffs = append(diffs, ResourceDiff{
+				Root:       key.root,
+				Address:    key.address,
+				Type:       newRes.Type,
+				Kind:       Modified,
+				OldAction:  oldRes.Action,
+				NewAction:  newRes.Action,
+				Attributes: attrs,
+			})
+		}
+	}
+	return diffs
+}
+
+// resourceKey identifies a resource across plans. Several roots of a plan
+// may declare the same address.
+type resourceKey struct {
+	root    string
+	address string
+}
+
+// changingResources indexes the resources of a plan that have a change
+func changingResources(plan *models.PlanResult) map[resourceKey]models.ResourceChange {
+	resources := make(map[resourceKey]models.ResourceChange)
+	if plan == nil {
+		return resources
+	}
+	for _, res := range plan.Resources {
+		if res.Action != models.ActionNoOp {
+			resources[resourceKey{res.Root, res.Address}] = res
+		}
+	}
+	return resources
+}
@@ -0,0 +1,95 @@
+package diff
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestComparePlans(t *testing.T) {
+	res := func(address string, action models.ChangeAction, after obj) models.ResourceChange {
+		return models.ResourceChange{Address: address, Type: "aws_instance", Action: action, Change: models.Change{After: after}}
+	}
+	oldPlan := &models.PlanResult{Resources: []models.ResourceChange{
+		res("aws_instance.same", models.ActionUpdate, obj{"size": "large"}),
+		res("aws_instance.resized", models.ActionUpdate, obj{"size": "large"}),
+		res("aws_instance.escalated", models.ActionUpdate, obj{"size": "large"}),
+		res("aws_instance.dropped", models.ActionCreate, obj{"size": "small"}),
+		res("aws_instance.settled", models.ActionNoOp, obj{"size": "small"}),
+	}}
+	newPlan := &models.PlanResult{Resources: []models.ResourceChange{
+		res("aws_instance.same", models.ActionUpdate, obj{"size": "large"}),
+		res("aws_instance.resized", models.ActionUpdate, obj{"size": "xlarge"}),
+		res("aws_instance.escalated", models.ActionReplace, obj{"size": "large"}),
+		res("aws_instance.added", models.ActionDelete, nil),
+		res("aws_instance.settled", models.ActionNoOp, obj{"size": "small"}),
+	}}
+
+	tests := []struct {
+		address    string
+		kind       Kind
+		oldAction  models.ChangeAction
+		newAction  models.ChangeAction
+		attributes []string
+	}{
+		{"aws_instance.added", Added, "", models.ActionDelete, nil},
+		{"aws_instance.dropped", Removed, models.ActionCreate, "", nil},
+		{"aws_instance.escalated", Modified, models.ActionUpdate, models.ActionReplace, []string{}},
+		{"aws_instance.resized", Modified, models.ActionUpdate, models.ActionUpdate, []string{"~ size"}},
+	}
+
+	diffs := ComparePlans(oldPlan, newPlan)
+	if len(diffs) != len(tests) {
+		t.Fatalf("got %d diffs, want %d: %+v", len(diffs), len(tests), diffs)
+	}
+	for i, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			d := diffs[i]
+			if d.Address != tt.address || d.Kind != tt.kind || d.OldAction != tt.oldAction || d.NewAction != tt.newAction {
+				t.Fatalf("got %s %q %s -> %s", d.Address, d.Kind, d.OldAction, d.NewAction)
+			}
+			if tt.attributes == nil {
+				if d.Attributes != nil {
+					t.Error("attributes diffed for a resource only one plan changes")
+				}
+				return
+			}
+			if got := describe(d.Attributes.Changes()); !equalStrings(got, tt.attributes) {
+				t.Errorf("attribute changes %q, want %q", got, tt.attributes)
+			}
+		})
+	}
+
+	if diffs := ComparePlans(nil, newPlan); len(diffs) != 4 {
+		t.Errorf("against no plan: got %d diffs, want every changing resource", len(diffs))
+	}
+}
+
+func TestComparePlansMultiRoot(t *testing.T) {
+	res := func(root string, action models.ChangeAction, cidr string) models.ResourceChange {
+		return models.ResourceChange{Root: root, Address: "module.vpc.aws_vpc.main", Type: "aws_vpc", Action: action, Change: models.Change{After: obj{"cidr": cidr}}}
+	}
+	oldPlan := &models.PlanResult{Resources: []models.ResourceChange{
+		res("envs/prod", models.ActionUpdate, "10.0.0.0/16"),
+		res("envs/staging", models.ActionUpdate, "10.1.0.0/16"),
+	}}
+	newPlan := &models.PlanResult{Resources: []models.ResourceChange{
+		res("envs/staging", models.ActionUpdate, "10.2.0.0/16"),
+		res("envs/prod", models.ActionReplace, "10.0.0.0/16"),
+		res("envs/dev", models.ActionCreate, "10.3.0.0/16"),
+	}}
+
+	var got []string
+	for _, d := range ComparePlans(oldPlan, newPlan) {
+		got = append(got, fmt.Sprintf("%s %s: %s %s -> %s", d.Kind, d.Root, d.Address, d.OldAction, d.NewAction))
+	}
+	want := []string{
+		"+ envs/dev: module.vpc.aws_vpc.main  -> create",
+		"~ envs/prod: module.vpc.aws_vpc.main update -> replace",
+		"~ envs/staging: module.vpc.aws_vpc.main update -> update",
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
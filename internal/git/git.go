@@ -16,6 +16,11 @@ import (
 type Repository struct {
 	rootPath string
 	isRepo   bool
+
+	// For terragrunt units: the configured terraform source and the
+	// directories holding its files, which are searched in addition to rootPath
+	terragruntSource string
+	searchPaths      []string
 }
 
 // NewRepository creates a new Repository instance and detects if the current directory is a git repo
@@ -32,6 +37,12 @@ func NewRepository(path string) (*Repository, error) {
 	// Check if this is a git repository
 	repo.isRepo = repo.detectGitRepository()
 
+	// The resources of a terragrunt unit are declared in its terraform source
+	if IsTerragruntUnit(absPath) {
+		repo.terragruntSource = TerragruntSource(absPath)
+		repo.searchPaths = terragruntSearchPaths(absPath, repo.terragruntSource)
+	}
+
 	return repo, nil
 }
 
@@ -157,13 +168,14 @@ func (r *Repository) findTerraformFile(resourceAddress string) (string, error) {
 		}
 
 		// Simple pattern matching - in production, you might want to use a proper HCL parser
+		// Files found in the terragrunt cache are mapped back to their source
 		if strings.Contains(string(content), fmt.Sprintf(`resource "%s" "%s"`, resourceType, resourceName)) {
-			return tfFile, nil
+			return terragruntSourcePath(r.rootPath, r.terragruntSource, tfFile), nil
 		}
 
 		// Also check for single-quoted resources (less common but possible)
 		if strings.Contains(string(content), fmt.Sprintf(`resource '%s' '%s'`, resourceType, resourceName)) {
-			return tfFile, nil
+			return terragruntSourcePath(r.rootPath, r.terragruntSource, tfFile), nil
 		}
 	}
 
@@ -181,11 +193,27 @@ func stripResourceIndex(address string) string {
 	return address
 }
 
-// findTerraformFiles returns a list of all .tf files in the repository
+// findTerraformFiles returns a list of all .tf files in the repository,
+// followed by those of the terraform source of a terragrunt unit
 func (r *Repository) findTerraformFiles() ([]string, error) {
 	var tfFiles []string
 
-	err := filepath.Walk(r.rootPath, func(path string, info os.FileInfo, err error) error {
+	for _, dir := range append([]string{r.rootPath}, r.searchPaths...) {
+		files, err := walkTerraformFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		tfFiles = append(tfFiles, files...)
+	}
+
+	return tfFiles, nil
+}
+
+// walkTerraformFiles returns the .tf files below dir, skipping hidden directories
+func walkTerraformFiles(dir string) ([]string, error) {
+	var tfFiles []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TerragruntFile is the configuration file of a terragrunt unit
+const TerragruntFile = "terragrunt.hcl"
+
+// TerragruntCacheDir is where terragrunt downloads and runs the terraform source of a unit
+const TerragruntCacheDir = ".terragrunt-cache"
+
+// terragruntSourcePattern matches the source attribute of the terraform block
+var terragruntSourcePattern = regexp.MustCompile(`(?s)terraform\s*\{.*?\bsource\s*=\s*"([^"]+)"`)
+
+// IsTerragruntUnit returns true if the directory contains a terragrunt.hcl
+func IsTerragruntUnit(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, TerragruntFile))
+	return err == nil
+}
+
+// TerragruntSource returns the terraform source configured in a unit's
+// terragrunt.hcl, or "" if there is none
+func TerragruntSource(dir string) string {
+	content, err := os.ReadFile(filepath.Join(dir, TerragruntFile))
+	if err != nil {
+		return ""
+	}
+	match := terragruntSourcePattern.FindSubmatch(content)
+	if match == nil {
+		return ""
+	}
+	return string(match[1])
+}
+
+// isLocalSource returns true if a terraform source refers to a local path
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") || filepath.IsAbs(source)
+}
+
+// splitSource splits a source into the directory terragrunt copies into its
+// cache and the subdirectory it runs terraform in, e.g.
+// "../modules//vpc" -> ("../modules", "vpc")
+func splitSource(source string) (string, string) {
+	// Strip the query string of remote sources (e.g., ?ref=v1.2.0)
+	if idx := strings.Index(source, "?"); idx != -1 {
+		source = source[:idx]
+	}
+
+	// The "//" of a URL scheme (https://, git::ssh://) is not a subdirectory separator
+	schemeEnd := 0
+	if idx := strings.Index(source, "://"); idx != -1 {
+		schemeEnd = idx + len("://")
+	}
+	if idx := strings.Index(source[schemeEnd:], "//"); idx != -1 {
+		return source[:schemeEnd+idx], source[schemeEnd+idx+2:]
+	}
+	return source, ""
+}
+
+// terragruntSearchPaths returns the directories to search for the terraform
+// files of a terragrunt unit: the local source if there is one, otherwise the
+// source copies in the unit's cache
+func terragruntSearchPaths(dir, source string) []string {
+	if source == "" {
+		return nil
+	}
+
+	if isLocalSource(source) {
+		root, _ := splitSource(source)
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(dir, root)
+		}
+		return []string{root}
+	}
+
+	// Remote sources are only available as downloaded copies:
+	// .terragrunt-cache/<hash>/<hash>/
+	copies, _ := filepath.Glob(filepath.Join(dir, TerragruntCacheDir, "*", "*"))
+	return copies
+}
+
+// terragruntSourcePath maps a file inside the terragrunt cache of a unit to
+// the real source it was copied from. Files of local sources map to the local
+// file; files of remote sources map to the source address, like
+// "git::https://example.com/modules.git//vpc/main.tf".
+func terragruntSourcePath(dir, source, path string) string {
+	marker := string(filepath.Separator) + TerragruntCacheDir + string(filepath.Separator)
+	idx := strings.Index(path, marker)
+	if idx == -1 || source == "" {
+		return path
+	}
+
+	// Drop the two hash directories below the cache directory
+	parts := strings.SplitN(path[idx+len(marker):], string(filepath.Separator), 3)
+	if len(parts) < 3 {
+		return path
+	}
+	rel := parts[2]
+
+	root, _ := splitSource(source)
+	if isLocalSource(source) {
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(dir, root)
+		}
+		return filepath.Join(root, rel)
+	}
+	return root + "//" + filepath.ToSlash(rel)
+}
@@ -0,0 +1,260 @@
+package history
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// fileSuffix is the extension of history entry files
+const fileSuffix = ".json.gz"
+
+// Entry is a recorded plan and the context it was made in
+type Entry struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+
+	// Dir is the working directory tplan ran in
+	Dir   string   `json:"dir"`
+	Roots []string `json:"roots,omitempty"`
+
+	// Args are the terraform arguments of the plan
+	Args []string `json:"args,omitempty"`
+
+	Commit     string   `json:"commit,omitempty"`
+	Branch     string   `json:"branch,omitempty"`
+	Workspaces []string `json:"workspaces,omitempty"`
+
+	Summary models.PlanSummary `json:"summary"`
+
+	// Plan is the parsed plan; nil in entries returned by List
+	Plan *models.PlanResult `json:"plan,omitempty"`
+}
+
+// Store keeps plan history entries as compressed JSON files in a directory.
+// A file holds the entry without its plan, followed by the plan, so entries
+// can be listed without decoding their plans. Plans can contain secrets, so
+// the directory and files are private to the user and sensitive values are
+// masked before saving.
+type Store struct {
+	dir string
+}
+
+// NewStore returns the history store in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// newID returns a sortable, unique entry ID like "20240102-150405.123-a1b2"
+func newID(t time.Time) string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return t.UTC().Format("20060102-150405.000") + "-" + hex.EncodeToString(suffix)
+}
+
+// Save records an entry, assigning its ID and time if unset. If keep is
+// positive, the oldest entries beyond keep are removed.
+func (s *Store) Save(entry *Entry, keep int) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.ID == "" {
+		entry.ID = newID(entry.Time)
+	}
+	if entry.Plan != nil {
+		entry.Summary = entry.Plan.Summary
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	// Tighten directories created by earlier versions
+	if err := os.Chmod(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	s.ignoreInGit()
+
+	header := *entry
+	header.Plan = nil
+	var plan *models.PlanResult
+	if entry.Plan != nil {
+		masker, err := s.masker()
+		if err != nil {
+			return err
+		}
+		plan = masker.plan(entry.Plan)
+	}
+
+	path := filepath.Join(s.dir, entry.ID+fileSuffix)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	defer file.Close()
+
+	writer := gzip.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	if err := encoder.Encode(header); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	if err := encoder.Encode(plan); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+
+	if keep > 0 {
+		return s.prune(keep)
+	}
+	return nil
+}
+
+// ignoreInGit keeps the history out of version control by placing a
+// .gitignore in the .tplan directory that ignores everything
+func (s *Store) ignoreInGit() {
+	parent := filepath.Dir(s.dir)
+	if filepath.Base(parent) != ".tplan" {
+		return
+	}
+	path := filepath.Join(parent, ".gitignore")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		os.WriteFile(path, []byte("*\n"), 0644)
+	}
+}
+
+// ids returns the IDs of the stored entries, newest first
+func (s *Store) ids() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(match), fileSuffix))
+	}
+	// IDs start with the timestamp, so they sort chronologically
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// prune removes the oldest entries beyond keep
+func (s *Store) prune(keep int) error {
+	ids, err := s.ids()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids[min(keep, len(ids)):] {
+		if err := os.Remove(filepath.Join(s.dir, id+fileSuffix)); err != nil {
+			return fmt.Errorf("failed to prune history: %w", err)
+		}
+	}
+	return nil
+}
+
+// List returns the stored entries without their plans, newest first.
+// Entries that cannot be read are skipped and returned as skipped errors.
+func (s *Store) List() (entries []Entry, skipped []error, err error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries = make([]Entry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := s.read(id, false)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, skipped, nil
+}
+
+// Load returns an entry with its plan. The reference is either the position
+// of the entry in List, counting from 1 for the newest, or a unique prefix of
+// its ID.
+func (s *Store) Load(ref string) (*Entry, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("the plan history in %s is empty", s.dir)
+	}
+
+	// Positions are short numbers; IDs start with an 8-digit date
+	if n, err := strconv.Atoi(ref); err == nil && len(ref) < 8 {
+		if n < 1 || n > len(ids) {
+			return nil, fmt.Errorf("history entry %d does not exist (%d entries)", n, len(ids))
+		}
+		return s.read(ids[n-1], true)
+	}
+
+	matches := make([]string, 0, 1)
+	for _, id := range ids {
+		if strings.HasPrefix(id, ref) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no history entry matches %q", ref)
+	case 1:
+		return s.read(matches[0], true)
+	default:
+		return nil, fmt.Errorf("%q matches %d history entries; use a longer prefix", ref, len(matches))
+	}
+}
+
+// read decodes an entry file, with its plan if withPlan is set
+func (s *Store) read(id string, withPlan bool) (*Entry, error) {
+	file, err := os.Open(filepath.Join(s.dir, id+fileSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history entry: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history entry %s: %w", id, err)
+	}
+	defer reader.Close()
+
+	decoder := json.NewDecoder(reader)
+	var entry Entry
+	if err := decoder.Decode(&entry); err != nil {
+		return nil, fmt.Errorf("failed to read history entry %s: %w", id, err)
+	}
+	if !withPlan {
+		entry.Plan = nil
+		return &entry, nil
+	}
+	if entry.Plan != nil {
+		// Written by an earlier version, with the plan inside the entry
+		return &entry, nil
+	}
+	if err := decoder.Decode(&entry.Plan); err != nil {
+		return nil, fmt.Errorf("failed to read history entry %s: %w", id, err)
+	}
+	if entry.Plan == nil {
+		return nil, fmt.Errorf("history entry %s has no plan", id)
+	}
+	return &entry, nil
+}
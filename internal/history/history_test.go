@@ -0,0 +1,261 @@
+package history
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func testPlan() *models.PlanResult {
+	return &models.PlanResult{
+		Resources: []models.ResourceChange{{
+			Address: "aws_db_instance.main",
+			Action:  models.ActionUpdate,
+			Change: models.Change{
+				Before: map[string]interface{}{
+					"name":     "main",
+					"password": "hunter2",
+					"tags":     map[string]interface{}{"env": "dev", "token": "abc"},
+				},
+				After: map[string]interface{}{
+					"name":     "main",
+					"password": "hunter3",
+					"tags":     map[string]interface{}{"env": "dev", "token": "abc"},
+				},
+				BeforeSensitive: map[string]interface{}{
+					"password": true,
+					"tags":     map[string]interface{}{"token": true},
+				},
+				AfterSensitive: map[string]interface{}{
+					"password": true,
+					"tags":     map[string]interface{}{"token": true},
+				},
+			},
+		}},
+		OutputChanges: []models.OutputChange{{
+			Name:      "db_password",
+			Sensitive: true,
+			Change: models.Change{
+				After: map[string]interface{}{"value": "hunter3"},
+			},
+		}},
+		Summary: models.PlanSummary{ToUpdate: 1, Total: 1},
+	}
+}
+
+func TestSaveMasksSensitiveValues(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), ".tplan", "history"))
+	plan := testPlan()
+	entry := &Entry{Plan: plan}
+	if err := store.Save(entry, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := plan.Resources[0].Change.After["password"]; got != "hunter3" {
+		t.Errorf("Save modified the caller's plan: password = %v", got)
+	}
+
+	data := readRaw(t, filepath.Join(store.Dir(), entry.ID+fileSuffix))
+	for _, secret := range []string{"hunter2", "hunter3", `"abc"`} {
+		if strings.Contains(data, secret) {
+			t.Errorf("saved entry contains the sensitive value %s", secret)
+		}
+	}
+
+	loaded, err := store.Load("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	change := loaded.Plan.Resources[0].Change
+	tests := []struct {
+		name      string
+		before    interface{}
+		after     interface{}
+		wantEqual bool
+	}{
+		{"changed secret", change.Before["password"], change.After["password"], false},
+		{"unchanged nested secret", change.Before["tags"].(map[string]interface{})["token"],
+			change.After["tags"].(map[string]interface{})["token"], true},
+		{"plain value", change.Before["name"], "main", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if equal := tt.before == tt.after; equal != tt.wantEqual {
+				t.Errorf("before %v, after %v: equal = %t, want %t", tt.before, tt.after, equal, tt.wantEqual)
+			}
+		})
+	}
+	if got := loaded.Plan.OutputChanges[0].Change.After["value"]; got == "hunter3" {
+		t.Error("sensitive output was saved in clear text")
+	}
+}
+
+func TestSavePermissions(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "history"))
+	if err := os.MkdirAll(store.Dir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	entry := &Entry{Plan: testPlan()}
+	if err := store.Save(entry, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]os.FileMode{
+		store.Dir(): 0700,
+		filepath.Join(store.Dir(), entry.ID+fileSuffix): 0600,
+		filepath.Join(store.Dir(), keyFile):             0600,
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s: mode %o, want %o", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestListSkipsUnreadableEntries(t *testing.T) {
+	store := NewStore(t.TempDir())
+	base := time.Date(2024, 3, 12, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		entry := &Entry{Time: base.Add(time.Duration(i) * time.Minute), Args: []string{fmt.Sprintf("-var=n=%d", i)}, Plan: testPlan()}
+		if err := store.Save(entry, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	corrupt := filepath.Join(store.Dir(), "20240312-100030.000-ffff"+fileSuffix)
+	if err := os.WriteFile(corrupt, []byte("not gzip"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, skipped, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || len(skipped) != 1 {
+		t.Fatalf("got %d entries and %d skipped, want 3 and 1", len(entries), len(skipped))
+	}
+	for i, entry := range entries {
+		if entry.Plan != nil {
+			t.Errorf("entry %d: List returned the plan", i)
+		}
+		if entry.Summary.ToUpdate != 1 {
+			t.Errorf("entry %d: summary %+v, want the plan's summary", i, entry.Summary)
+		}
+	}
+	if !entries[0].Time.After(entries[2].Time) {
+		t.Error("entries are not listed newest first")
+	}
+}
+
+func TestLoadReferences(t *testing.T) {
+	store := NewStore(t.TempDir())
+	ids := []string{"20240101-100000.000-aaaa", "20240102-100000.000-bbbb", "20240102-110000.000-cccc"}
+	for _, id := range ids {
+		if err := store.Save(&Entry{ID: id, Plan: testPlan()}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		ref     string
+		wantID  string
+		wantErr string
+	}{
+		{ref: "1", wantID: ids[2]},
+		{ref: "3", wantID: ids[0]},
+		{ref: "4", wantErr: "does not exist"},
+		{ref: "20240101", wantID: ids[0]},
+		{ref: "20240102", wantErr: "matches 2"},
+		{ref: "20240102-11", wantID: ids[2]},
+		{ref: "20230101", wantErr: "no history entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			entry, err := store.Load(tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.ID != tt.wantID || entry.Plan == nil {
+				t.Errorf("got %s (plan %t), want %s with its plan", entry.ID, entry.Plan != nil, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestLoadEarlierFormat(t *testing.T) {
+	store := NewStore(t.TempDir())
+	id := "20240101-100000.000-aaaa"
+	file, err := os.Create(filepath.Join(store.Dir(), id+fileSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := gzip.NewWriter(file)
+	if err := json.NewEncoder(writer).Encode(Entry{ID: id, Plan: testPlan()}); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	file.Close()
+
+	entry, err := store.Load(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Plan == nil || len(entry.Plan.Resources) != 1 {
+		t.Errorf("plan of an entry in the earlier format was not loaded: %+v", entry.Plan)
+	}
+}
+
+func TestSavePrunes(t *testing.T) {
+	store := NewStore(t.TempDir())
+	base := time.Date(2024, 3, 12, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		if err := store.Save(&Entry{Time: base.Add(time.Duration(i) * time.Minute), Plan: testPlan()}, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if !entries[2].Time.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("oldest kept entry is from %s, want the third", entries[2].Time)
+	}
+}
+
+// readRaw returns the decompressed content of an entry file
+func readRaw(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,154 @@
+package history
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// keyFile holds the key that fingerprints sensitive values in the store
+const keyFile = "key"
+
+// masker replaces sensitive values with fingerprints. A fingerprint is a
+// keyed hash of the value: equal values get equal fingerprints, so recorded
+// plans still show whether a sensitive value changed, but the value cannot
+// be recovered without the key.
+type masker struct {
+	key []byte
+}
+
+// masker returns the masker of the store, creating its key on first use
+func (s *Store) masker() (*masker, error) {
+	path := filepath.Join(s.dir, keyFile)
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) > 0 {
+		return &masker{key: key}, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read history key: %w", err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to create history key: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		// Another tplan created it first
+		return s.masker()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create history key: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(key); err != nil {
+		return nil, fmt.Errorf("failed to create history key: %w", err)
+	}
+	return &masker{key: key}, nil
+}
+
+// plan returns a copy of the plan with sensitive values masked
+func (m *masker) plan(plan *models.PlanResult) *models.PlanResult {
+	masked := *plan
+	masked.Resources = make([]models.ResourceChange, len(plan.Resources))
+	for i, res := range plan.Resources {
+		res.Change = m.change(res.Change)
+		masked.Resources[i] = res
+	}
+	masked.OutputChanges = make([]models.OutputChange, len(plan.OutputChanges))
+	for i, output := range plan.OutputChanges {
+		if output.Sensitive {
+			output.Change.Before = m.object(output.Change.Before, true)
+			output.Change.After = m.object(output.Change.After, true)
+		} else {
+			output.Change = m.change(output.Change)
+		}
+		masked.OutputChanges[i] = output
+	}
+	masked.DriftedResources = make([]models.DriftedResource, len(plan.DriftedResources))
+	for i, drifted := range plan.DriftedResources {
+		drifted.Change = m.change(drifted.Change)
+		masked.DriftedResources[i] = drifted
+	}
+	return &masked
+}
+
+// change masks the values of a change that are marked sensitive
+func (m *masker) change(change models.Change) models.Change {
+	change.Before = m.object(change.Before, change.BeforeSensitive)
+	change.After = m.object(change.After, change.AfterSensitive)
+	return change
+}
+
+// object masks the sensitive attributes of a before or after object
+func (m *masker) object(obj map[string]interface{}, sensitive interface{}) map[string]interface{} {
+	if obj == nil {
+		return nil
+	}
+	masked, _ := m.value(obj, sensitive).(map[string]interface{})
+	if masked == nil {
+		// The whole object is sensitive; keep its attribute names
+		masked = make(map[string]interface{}, len(obj))
+		for key, value := range obj {
+			masked[key] = m.fingerprint(value)
+		}
+	}
+	return masked
+}
+
+// value masks a value following its sensitive structure, which is true for
+// a sensitive value or an object or list of the same shape as the value
+func (m *masker) value(v, sensitive interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if isTrue(sensitive) {
+		return m.fingerprint(v)
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		sens, _ := sensitive.(map[string]interface{})
+		masked := make(map[string]interface{}, len(val))
+		for key, child := range val {
+			masked[key] = m.value(child, sens[key])
+		}
+		return masked
+	case []interface{}:
+		sens, _ := sensitive.([]interface{})
+		masked := make([]interface{}, len(val))
+		for i, child := range val {
+			var childSens interface{}
+			if i < len(sens) {
+				childSens = sens[i]
+			}
+			masked[i] = m.value(child, childSens)
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// fingerprint returns the masked form of a sensitive value
+func (m *masker) fingerprint(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	data, _ := json.Marshal(v)
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write(data)
+	return "(sensitive value " + hex.EncodeToString(mac.Sum(nil))[:16] + ")"
+}
+
+func isTrue(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
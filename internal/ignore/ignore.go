@@ -0,0 +1,144 @@
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/diff"
+)
+
+// DefaultFile is the ignore file looked up in the working directory
+const DefaultFile = ".tplanignore"
+
+// Rule hides changes to attributes that churn on every plan
+type Rule struct {
+	// ResourceType is a glob matched against the resource type (e.g., "aws_*")
+	ResourceType string
+
+	// Attribute is a glob matched against the attribute path (e.g., "tags_all" or "*.etag")
+	Attribute string
+}
+
+// Rules is an ordered list of ignore rules
+type Rules []Rule
+
+// LoadFile reads ignore rules from a file. A missing file yields no rules.
+func LoadFile(path string) (Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer f.Close()
+
+	rules, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Parse reads ignore rules, one per line, in the form:
+//
+//	# resource-type-glob  attribute-path-glob
+//	*                     tags_all
+//	aws_s3_bucket         etag
+//
+// A line with a single field applies the attribute glob to every resource type.
+func Parse(r io.Reader) (Rules, error) {
+	rules := make(Rules, 0)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			rules = append(rules, Rule{ResourceType: "*", Attribute: fields[0]})
+		case 2:
+			rules = append(rules, Rule{ResourceType: fields[0], Attribute: fields[1]})
+		default:
+			return nil, fmt.Errorf("line %d: expected \"<resource-type> <attribute-path>\", got %q", lineNo, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Matches returns true if any rule covers the attribute path of the resource type
+func (r Rules) Matches(resourceType, path string) bool {
+	for _, rule := range r {
+		if Match(rule.ResourceType, resourceType) && Match(rule.Attribute, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply marks the ignored nodes of a resource's diff tree
+func (r Rules) Apply(resourceType string, root *diff.Node) {
+	if len(r) == 0 || root == nil {
+		return
+	}
+	root.Ignore(func(path string) bool {
+		return r.Matches(resourceType, path)
+	})
+}
+
+// Match reports whether s matches the glob pattern. "*" matches any run of
+// characters within one path segment, "**" matches across segments and "?"
+// matches a single character. Everything else, including brackets used in
+// list paths such as "rule[0]", is matched literally.
+func Match(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch {
+		case strings.HasPrefix(pattern, "**"):
+			rest := pattern[2:]
+			for i := 0; i <= len(s); i++ {
+				if Match(rest, s[i:]) {
+					return true
+				}
+			}
+			return false
+
+		case pattern[0] == '*':
+			rest := pattern[1:]
+			for i := 0; i <= len(s); i++ {
+				if Match(rest, s[i:]) {
+					return true
+				}
+				if i < len(s) && s[i] == '.' {
+					return false
+				}
+			}
+			return false
+
+		case pattern[0] == '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
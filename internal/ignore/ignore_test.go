@@ -0,0 +1,145 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/diff"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"tags_all", "tags_all", true},
+		{"tags_all", "tags_all.env", false},
+		{"tags*", "tags_all", true},
+		{"*", "tags_all", true},
+		{"*", "tags_all.env", false},
+		{"*.etag", "object.etag", true},
+		{"*.etag", "a.b.etag", false},
+		{"**.etag", "a.b.etag", true},
+		{"**", "a.b[0].c", true},
+		{"tags.*", "tags.env", true},
+		{"rule[0].id", "rule[0].id", true},
+		{"rule[0].id", "rule0.id", false},
+		{"rule[?].id", "rule[3].id", true},
+		{"?", "", false},
+		{"aws_*", "aws_s3_bucket", true},
+		{"aws_*", "google_storage_bucket", false},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.s, func(t *testing.T) {
+			if got := Match(tt.pattern, tt.s); got != tt.want {
+				t.Errorf("Match(%q, %q) = %t, want %t", tt.pattern, tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Rules
+		wantErr string
+	}{
+		{
+			name:  "rules and comments",
+			input: "# noise\n\n*  tags_all\n  aws_s3_bucket etag  \nlast_modified\n",
+			want: Rules{
+				{ResourceType: "*", Attribute: "tags_all"},
+				{ResourceType: "aws_s3_bucket", Attribute: "etag"},
+				{ResourceType: "*", Attribute: "last_modified"},
+			},
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  Rules{},
+		},
+		{
+			name:    "too many fields",
+			input:   "*  tags_all\naws_s3_bucket etag version\n",
+			wantErr: "line 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rules, tt.want) {
+				t.Errorf("rules %v, want %v", rules, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	rules, err := LoadFile(filepath.Join(dir, DefaultFile))
+	if err != nil || rules != nil {
+		t.Errorf("missing file: %v, %v", rules, err)
+	}
+
+	path := filepath.Join(dir, DefaultFile)
+	if err := os.WriteFile(path, []byte("a b c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("err = %v, want it to name the file", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	rules := Rules{
+		{ResourceType: "*", Attribute: "tags_all"},
+		{ResourceType: "aws_s3_*", Attribute: "**.etag"},
+	}
+	change := models.Change{
+		Before: map[string]interface{}{"tags_all": map[string]interface{}{"a": "1"}, "object": map[string]interface{}{"meta": map[string]interface{}{"etag": "x"}}, "acl": "private"},
+		After:  map[string]interface{}{"tags_all": map[string]interface{}{"a": "2"}, "object": map[string]interface{}{"meta": map[string]interface{}{"etag": "y"}}, "acl": "private"},
+	}
+
+	tests := []struct {
+		resourceType string
+		wantIgnored  []string
+		wantNoise    bool
+	}{
+		{"aws_s3_bucket", []string{"object.meta.etag", "tags_all.a"}, true},
+		{"aws_instance", []string{"tags_all.a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			root := diff.Compute(change)
+			rules.Apply(tt.resourceType, root)
+			ignored := make([]string, 0)
+			for _, c := range root.Changes() {
+				if c.Ignored {
+					ignored = append(ignored, c.Path)
+				}
+			}
+			if !reflect.DeepEqual(ignored, tt.wantIgnored) {
+				t.Errorf("ignored %v, want %v", ignored, tt.wantIgnored)
+			}
+			if root.NoiseOnly() != tt.wantNoise {
+				t.Errorf("NoiseOnly = %t, want %t", root.NoiseOnly(), tt.wantNoise)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Owner describes the process holding a lock
+type Owner struct {
+	PID  int       `json:"pid"`
+	User string    `json:"user,omitempty"`
+	Host string    `json:"host,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// HeldError is returned by Acquire when another process holds the lock
+type HeldError struct {
+	Path  string
+	Owner Owner
+}
+
+func (e *HeldError) Error() string {
+	if e.Owner.PID <= 0 {
+		return fmt.Sprintf("the lock file %s exists but names no owner; remove it if no tplan is applying", e.Path)
+	}
+	return fmt.Sprintf("another tplan (pid %d, %s@%s) has been applying since %s; remove %s if it is no longer running",
+		e.Owner.PID, e.Owner.User, e.Owner.Host, e.Owner.Time.Local().Format("15:04:05"), e.Path)
+}
+
+// Lock is an exclusive lock file held by this process
+type Lock struct {
+	path string
+	once sync.Once
+}
+
+// Acquire creates the lock file at path, recording the current process and
+// user as its owner. If the file exists and its owner is still running, a
+// *HeldError is returned. Locks left behind by processes on this host that
+// are gone are taken over.
+//
+// The owner is written to a temporary file that is then linked to path, so
+// other processes never see a lock file without its owner.
+func Acquire(path, user string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	owner := Owner{PID: os.Getpid(), User: user, Time: time.Now()}
+	owner.Host, _ = os.Hostname()
+	data, err := json.Marshal(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	temp, err := writeTemp(path, data)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(temp)
+
+	// One retry after removing a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		err := os.Link(temp, path)
+		if err == nil {
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		held, err := read(path)
+		if os.IsNotExist(err) {
+			// Released in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !held.stale(owner.Host) {
+			return nil, &HeldError{Path: path, Owner: held}
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale lock file: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("failed to acquire lock %s", path)
+}
+
+// writeTemp writes the lock file content to a new file next to path and
+// returns its name
+func writeTemp(path string, data []byte) (string, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write lock file: %w", err)
+	}
+	// Other users may read who holds the lock
+	if err = file.Chmod(0644); err == nil {
+		_, err = file.Write(data)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// Release removes the lock file. Only the first call removes it, so a lock
+// taken by another process afterwards is left alone.
+func (l *Lock) Release() error {
+	var err error
+	l.once.Do(func() {
+		if removeErr := os.Remove(l.path); removeErr != nil && !os.IsNotExist(removeErr) {
+			err = fmt.Errorf("failed to remove lock file: %w", removeErr)
+		}
+	})
+	return err
+}
+
+// read returns the owner recorded in a lock file. A lock file that cannot be
+// decoded has no owner and is considered held.
+func read(path string) (Owner, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Owner{}, err
+	}
+	if err != nil {
+		return Owner{}, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	var owner Owner
+	json.Unmarshal(data, &owner)
+	return owner, nil
+}
+
+// stale returns true if the owner is known to have exited. Owners on other
+// hosts, and locks without an owner, cannot be checked and are assumed to be
+// held.
+func (o Owner) stale(host string) bool {
+	if o.PID <= 0 || o.Host != host {
+		return false
+	}
+	return !running(o.PID)
+}
+
+// running returns true unless the process with pid is known not to exist
+func running(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
@@ -0,0 +1,169 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// deadPID is a process ID that is not in use
+const deadPID = 1<<31 - 2
+
+func writeLock(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func ownerJSON(t *testing.T, owner Owner) string {
+	t.Helper()
+	data, err := json.Marshal(owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestAcquire(t *testing.T) {
+	host, _ := os.Hostname()
+
+	tests := []struct {
+		name     string
+		existing func(t *testing.T) string
+		wantHeld bool
+		wantErr  string
+	}{
+		{
+			name: "no lock",
+		},
+		{
+			name: "held by a running process",
+			existing: func(t *testing.T) string {
+				return ownerJSON(t, Owner{PID: os.Getpid(), Host: host, Time: time.Now()})
+			},
+			wantHeld: true,
+			wantErr:  "has been applying",
+		},
+		{
+			name: "left by an exited process",
+			existing: func(t *testing.T) string {
+				return ownerJSON(t, Owner{PID: deadPID, Host: host, Time: time.Now()})
+			},
+		},
+		{
+			name: "held on another host",
+			existing: func(t *testing.T) string {
+				return ownerJSON(t, Owner{PID: deadPID, Host: host + ".elsewhere", Time: time.Now()})
+			},
+			wantHeld: true,
+		},
+		{
+			// What a reader saw while the owner was still being written
+			name:     "empty",
+			existing: func(t *testing.T) string { return "" },
+			wantHeld: true,
+			wantErr:  "names no owner",
+		},
+		{
+			name:     "partially written",
+			existing: func(t *testing.T) string { return `{"pid": 12` },
+			wantHeld: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state", "apply.lock")
+			if tt.existing != nil {
+				os.MkdirAll(filepath.Dir(path), 0755)
+				writeLock(t, path, tt.existing(t))
+			}
+
+			lock, err := Acquire(path, "alice")
+			var held *HeldError
+			if tt.wantHeld {
+				if !errors.As(err, &held) {
+					t.Fatalf("err = %v, want a *HeldError", err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %q, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			owner, err := read(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner.PID != os.Getpid() || owner.User != "alice" {
+				t.Errorf("lock owner = %+v, want this process and alice", owner)
+			}
+			entries, _ := os.ReadDir(filepath.Dir(path))
+			if len(entries) != 1 {
+				t.Errorf("lock directory has %d files, want only the lock", len(entries))
+			}
+
+			if err := lock.Release(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("lock file still exists after Release: %v", err)
+			}
+		})
+	}
+}
+
+func TestAcquireTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apply.lock")
+	first, err := Acquire(path, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(path, "bob"); err == nil {
+		t.Fatal("second Acquire succeeded while the lock was held")
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatal(err)
+	}
+	second, err := Acquire(path, "bob")
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	// Releasing the first lock again must not remove the second
+	if err := first.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("second lock was removed: %v", err)
+	}
+	second.Release()
+}
+
+func TestStale(t *testing.T) {
+	tests := []struct {
+		name  string
+		owner Owner
+		want  bool
+	}{
+		{"running", Owner{PID: os.Getpid(), Host: "a"}, false},
+		{"exited", Owner{PID: deadPID, Host: "a"}, true},
+		{"other host", Owner{PID: deadPID, Host: "b"}, false},
+		{"no owner", Owner{}, false},
+		{"no owner on this host", Owner{Host: "a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.owner.stale("a"); got != tt.want {
+				t.Errorf("stale() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
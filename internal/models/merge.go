@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// MergePlans combines the plans of several root module directories into one
+// plan. Resources are tagged with their root, summaries are added up and
+// errors and warnings are prefixed with the root they came from.
+func MergePlans(roots []string, plans []*PlanResult) *PlanResult {
+	merged := &PlanResult{
+		Resources:        make([]ResourceChange, 0),
+		OutputChanges:    make([]OutputChange, 0),
+		Errors:           make([]PlanError, 0),
+		Warnings:         make([]PlanWarning, 0),
+		DriftedResources: make([]DriftedResource, 0),
+		Roots:            roots,
+		ParsedAt:         time.Now(),
+		InputFormat:      "json",
+	}
+
+	for i, plan := range plans {
+		if plan == nil {
+			continue
+		}
+		root := roots[i]
+
+		if merged.TerraformVersion == "" {
+			merged.TerraformVersion = plan.TerraformVersion
+			merged.FormatVersion = plan.FormatVersion
+		}
+
+		for _, res := range plan.Resources {
+			res.Root = root
+			merged.Resources = append(merged.Resources, res)
+		}
+		for _, out := range plan.OutputChanges {
+			out.Name = root + ": " + out.Name
+			merged.OutputChanges = append(merged.OutputChanges, out)
+		}
+		for _, planErr := range plan.Errors {
+			planErr.Message = "[" + root + "] " + planErr.Message
+			merged.Errors = append(merged.Errors, planErr)
+		}
+		for _, warning := range plan.Warnings {
+			warning.Message = "[" + root + "] " + warning.Message
+			merged.Warnings = append(merged.Warnings, warning)
+		}
+
+		merged.DriftDetected = merged.DriftDetected || plan.DriftDetected
+		merged.DriftedResources = append(merged.DriftedResources, plan.DriftedResources...)
+
+		merged.Summary.ToCreate += plan.Summary.ToCreate
+		merged.Summary.ToUpdate += plan.Summary.ToUpdate
+		merged.Summary.ToDelete += plan.Summary.ToDelete
+		merged.Summary.ToReplace += plan.Summary.ToReplace
+		merged.Summary.NoOp += plan.Summary.NoOp
+		merged.Summary.Total += plan.Summary.Total
+	}
+
+	return merged
+}
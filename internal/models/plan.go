@@ -31,11 +31,25 @@ type PlanResult struct {
 	DriftDetected    bool
 	DriftedResources []DriftedResource
 
+	// Roots lists the root module directories of a merged multi-root plan
+	Roots []string
+
 	// Parse metadata
 	ParsedAt    time.Time
 	InputFormat string // "json" or "text"
 }
 
+// DestructiveResources returns the resources the plan deletes or replaces
+func (p *PlanResult) DestructiveResources() []ResourceChange {
+	resources := make([]ResourceChange, 0)
+	for _, res := range p.Resources {
+		if res.Action == ActionDelete || res.Action == ActionReplace {
+			resources = append(resources, res)
+		}
+	}
+	return resources
+}
+
 // PlanSummary provides aggregate statistics about the plan
 type PlanSummary struct {
 	ToCreate  int
@@ -70,6 +84,9 @@ type ResourceChange struct {
 
 	// Drift information (populated when -drift flag is used)
 	DriftInfo *DriftInfo
+
+	// Root is the root module directory the resource was planned in (multi-root plans only)
+	Root string
 }
 
 // Change represents the before/after state of a resource
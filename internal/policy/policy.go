@@ -0,0 +1,162 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/tplan/internal/ignore"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// Severity levels for policy rules
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Policy is a named set of rules loaded from a policy file
+type Policy struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+
+	// Source is the file the policy was loaded from
+	Source string `yaml:"-"`
+}
+
+// Rule flags planned actions on matching resources
+type Rule struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+
+	// ResourceType and Address are globs (see ignore.Match); empty matches everything
+	ResourceType string `yaml:"resource_type"`
+	Address      string `yaml:"address"`
+
+	// Actions lists the actions that violate the rule (e.g., delete, replace)
+	Actions []models.ChangeAction `yaml:"actions"`
+
+	// MaxCount allows up to this many matching changes before the rule is violated
+	MaxCount int `yaml:"max_count"`
+
+	// Severity is "error" (default) or "warning"
+	Severity string `yaml:"severity"`
+}
+
+// Violation is a rule broken by the plan
+type Violation struct {
+	Policy    string
+	Rule      Rule
+	Resources []string
+}
+
+// IsError returns true if the violation should fail a check
+func (v Violation) IsError() bool {
+	return v.Rule.Severity != SeverityWarning
+}
+
+// LoadFile reads a policy file
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Source = path
+	if p.Name == "" {
+		p.Name = path
+	}
+	return p, nil
+}
+
+// Parse decodes a YAML policy
+func Parse(data []byte) (*Policy, error) {
+	p := &Policy{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if rule.Severity == "" {
+			rule.Severity = SeverityError
+		}
+		if rule.Severity != SeverityError && rule.Severity != SeverityWarning {
+			return nil, fmt.Errorf("rule %q: invalid severity %q", rule.Name, rule.Severity)
+		}
+		if len(rule.Actions) == 0 {
+			return nil, fmt.Errorf("rule %q: at least one action is required", rule.Name)
+		}
+	}
+	return p, nil
+}
+
+// Evaluate checks the plan against every rule of the policy
+func (p *Policy) Evaluate(plan *models.PlanResult) []Violation {
+	violations := make([]Violation, 0)
+	for _, rule := range p.Rules {
+		matched := rule.matching(plan)
+		if len(matched) > rule.MaxCount {
+			violations = append(violations, Violation{
+				Policy:    p.Name,
+				Rule:      rule,
+				Resources: matched,
+			})
+		}
+	}
+	return violations
+}
+
+// matching returns the addresses of resources whose planned action the rule covers
+func (r Rule) matching(plan *models.PlanResult) []string {
+	addresses := make([]string, 0)
+	for _, res := range plan.Resources {
+		if !r.coversAction(res.Action) {
+			continue
+		}
+		if r.ResourceType != "" && !ignore.Match(r.ResourceType, res.Type) {
+			continue
+		}
+		if r.Address != "" && !ignore.Match(r.Address, res.Address) {
+			continue
+		}
+		addresses = append(addresses, res.Address)
+	}
+	return addresses
+}
+
+func (r Rule) coversAction(action models.ChangeAction) bool {
+	for _, a := range r.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluateAll loads and evaluates several policy files
+func EvaluateAll(paths []string, plan *models.PlanResult) ([]Violation, error) {
+	violations := make([]Violation, 0)
+	for _, path := range paths {
+		p, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, p.Evaluate(plan)...)
+	}
+	return violations, nil
+}
@@ -0,0 +1,226 @@
+package prcomment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/tplan/internal/config"
+)
+
+// Public API endpoints, used outside CI when no endpoint is configured
+const (
+	GitHubAPIURL = "https://api.github.com"
+	GitLabAPIURL = "https://gitlab.com/api/v4"
+)
+
+// pageSize is the number of comments requested per page
+const pageSize = 100
+
+// Client creates and updates the comments of a pull request (GitHub) or
+// merge request (GitLab)
+type Client struct {
+	Platform string
+	APIURL   string
+	Token    string
+
+	// Repo is "owner/name" on GitHub and the project ID or path on GitLab
+	Repo string
+
+	// Number is the pull request number or merge request IID
+	Number int
+
+	HTTP *http.Client
+}
+
+// githubPullRef matches the ref GitHub Actions checks out for pull requests
+var githubPullRef = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// FromEnvironment returns a client for the pull request of the current CI
+// job on GitHub Actions or GitLab CI. Fields that can't be detected are left
+// empty. If platform is set, only that platform's variables are read.
+func FromEnvironment(platform string) *Client {
+	if platform == "" {
+		switch {
+		case os.Getenv("GITHUB_ACTIONS") == "true":
+			platform = config.PlatformGitHub
+		case os.Getenv("GITLAB_CI") == "true":
+			platform = config.PlatformGitLab
+		}
+	}
+
+	c := &Client{Platform: platform}
+	switch platform {
+	case config.PlatformGitHub:
+		c.APIURL = os.Getenv("GITHUB_API_URL")
+		c.Token = os.Getenv("GITHUB_TOKEN")
+		c.Repo = os.Getenv("GITHUB_REPOSITORY")
+		if m := githubPullRef.FindStringSubmatch(os.Getenv("GITHUB_REF")); m != nil {
+			c.Number, _ = strconv.Atoi(m[1])
+		}
+	case config.PlatformGitLab:
+		c.APIURL = os.Getenv("CI_API_V4_URL")
+		c.Token = os.Getenv("GITLAB_TOKEN")
+		c.Repo = os.Getenv("CI_PROJECT_ID")
+		c.Number, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+	}
+	return c
+}
+
+// Validate checks that the client has everything needed to reach a pull request
+func (c *Client) Validate() error {
+	switch c.Platform {
+	case config.PlatformGitHub, config.PlatformGitLab:
+	case "":
+		return fmt.Errorf("platform not set and not running in GitHub Actions or GitLab CI")
+	default:
+		return fmt.Errorf("unknown platform %q (expected %q or %q)", c.Platform, config.PlatformGitHub, config.PlatformGitLab)
+	}
+	if c.Repo == "" {
+		return fmt.Errorf("repository not set")
+	}
+	if c.Number <= 0 {
+		return fmt.Errorf("pull request number not set")
+	}
+	if c.Token == "" {
+		return fmt.Errorf("no API token (set %s)", c.tokenVariable())
+	}
+	return nil
+}
+
+// tokenVariable returns the environment variable holding the API token
+func (c *Client) tokenVariable() string {
+	if c.Platform == config.PlatformGitLab {
+		return "GITLAB_TOKEN"
+	}
+	return "GITHUB_TOKEN"
+}
+
+// Upsert updates the first comment containing marker, or creates a new one
+// if there is none. It returns true if a comment was created.
+func (c *Client) Upsert(ctx context.Context, marker, body string) (bool, error) {
+	if err := c.Validate(); err != nil {
+		return false, err
+	}
+
+	id, err := c.find(ctx, marker)
+	if err != nil {
+		return false, err
+	}
+	payload := map[string]string{"body": body}
+	if id == 0 {
+		return true, c.do(ctx, http.MethodPost, c.commentsURL(), payload, nil)
+	}
+
+	method := http.MethodPatch
+	if c.Platform == config.PlatformGitLab {
+		method = http.MethodPut
+	}
+	return false, c.do(ctx, method, c.commentURL(id), payload, nil)
+}
+
+// comment is the part of a GitHub comment or GitLab note tplan reads
+type comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// find returns the ID of the first comment containing marker, or 0
+func (c *Client) find(ctx context.Context, marker string) (int64, error) {
+	for page := 1; ; page++ {
+		var comments []comment
+		pageURL := fmt.Sprintf("%s?per_page=%d&page=%d", c.commentsURL(), pageSize, page)
+		if err := c.do(ctx, http.MethodGet, pageURL, nil, &comments); err != nil {
+			return 0, err
+		}
+		for _, cm := range comments {
+			if strings.Contains(cm.Body, marker) {
+				return cm.ID, nil
+			}
+		}
+		if len(comments) < pageSize {
+			return 0, nil
+		}
+	}
+}
+
+// apiURL returns the API endpoint without a trailing slash
+func (c *Client) apiURL() string {
+	if c.APIURL != "" {
+		return strings.TrimSuffix(c.APIURL, "/")
+	}
+	if c.Platform == config.PlatformGitLab {
+		return GitLabAPIURL
+	}
+	return GitHubAPIURL
+}
+
+// commentsURL returns the URL of the comments of the pull request
+func (c *Client) commentsURL() string {
+	if c.Platform == config.PlatformGitLab {
+		return fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", c.apiURL(), url.PathEscape(c.Repo), c.Number)
+	}
+	return fmt.Sprintf("%s/repos/%s/issues/%d/comments", c.apiURL(), c.Repo, c.Number)
+}
+
+// commentURL returns the URL of one comment
+func (c *Client) commentURL(id int64) string {
+	if c.Platform == config.PlatformGitLab {
+		return fmt.Sprintf("%s/%d", c.commentsURL(), id)
+	}
+	return fmt.Sprintf("%s/repos/%s/issues/comments/%d", c.apiURL(), c.Repo, id)
+}
+
+// do sends an API request with a JSON payload and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, requestURL string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Platform == config.PlatformGitLab {
+		req.Header.Set("PRIVATE-TOKEN", c.Token)
+	} else {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, requestURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, requestURL, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("%s %s: invalid response: %w", method, requestURL, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package prcomment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/config"
+)
+
+// fakeAPI serves the comments of one pull request the way GitHub and GitLab
+// do, paginated by per_page and page, and records the requests it gets
+type fakeAPI struct {
+	t        *testing.T
+	platform string
+
+	mu       sync.Mutex
+	comments []comment
+	requests []string
+	auth     []string
+}
+
+func (api *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.requests = append(api.requests, r.Method+" "+r.URL.EscapedPath())
+	if api.platform == config.PlatformGitLab {
+		api.auth = append(api.auth, "PRIVATE-TOKEN "+r.Header.Get("PRIVATE-TOKEN"))
+	} else {
+		api.auth = append(api.auth, r.Header.Get("Authorization"))
+	}
+
+	var payload struct {
+		Body string `json:"body"`
+	}
+	if r.Body != nil && r.Method != http.MethodGet {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			api.t.Errorf("%s %s: invalid payload: %v", r.Method, r.URL.Path, err)
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start := (page - 1) * perPage
+		end := start + perPage
+		if start > len(api.comments) {
+			start = len(api.comments)
+		}
+		if end > len(api.comments) {
+			end = len(api.comments)
+		}
+		json.NewEncoder(w).Encode(api.comments[start:end])
+	case http.MethodPost:
+		api.comments = append(api.comments, comment{ID: int64(len(api.comments) + 1), Body: payload.Body})
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "{}")
+	case http.MethodPatch, http.MethodPut:
+		id, _ := strconv.ParseInt(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], 10, 64)
+		for i := range api.comments {
+			if api.comments[i].ID == id {
+				api.comments[i].Body = payload.Body
+			}
+		}
+		fmt.Fprint(w, "{}")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// otherComments returns n comments without a tplan marker
+func otherComments(n int) []comment {
+	comments := make([]comment, n)
+	for i := range comments {
+		comments[i] = comment{ID: int64(i + 1), Body: "LGTM"}
+	}
+	return comments
+}
+
+func TestUpsert(t *testing.T) {
+	const marker = "<!-- tplan:pr-comment -->"
+
+	tests := []struct {
+		name         string
+		platform     string
+		comments     []comment
+		wantCreated  bool
+		wantRequests []string
+		wantAuth     string
+	}{
+		{
+			name:        "github create",
+			platform:    config.PlatformGitHub,
+			comments:    otherComments(2),
+			wantCreated: true,
+			wantRequests: []string{
+				"GET /repos/acme/infra/issues/7/comments",
+				"POST /repos/acme/infra/issues/7/comments",
+			},
+			wantAuth: "Bearer gh-token",
+		},
+		{
+			name:     "github update on the second page",
+			platform: config.PlatformGitHub,
+			comments: append(otherComments(pageSize), comment{ID: 500, Body: "old plan\n" + marker}),
+			wantRequests: []string{
+				"GET /repos/acme/infra/issues/7/comments",
+				"GET /repos/acme/infra/issues/7/comments",
+				"PATCH /repos/acme/infra/issues/comments/500",
+			},
+			wantAuth: "Bearer gh-token",
+		},
+		{
+			name:        "gitlab create after a full page",
+			platform:    config.PlatformGitLab,
+			comments:    otherComments(pageSize),
+			wantCreated: true,
+			wantRequests: []string{
+				"GET /projects/acme%2Finfra/merge_requests/7/notes",
+				"GET /projects/acme%2Finfra/merge_requests/7/notes",
+				"POST /projects/acme%2Finfra/merge_requests/7/notes",
+			},
+			wantAuth: "PRIVATE-TOKEN gl-token",
+		},
+		{
+			name:     "gitlab update",
+			platform: config.PlatformGitLab,
+			comments: []comment{{ID: 3, Body: marker}, {ID: 4, Body: marker}},
+			wantRequests: []string{
+				"GET /projects/acme%2Finfra/merge_requests/7/notes",
+				"PUT /projects/acme%2Finfra/merge_requests/7/notes/3",
+			},
+			wantAuth: "PRIVATE-TOKEN gl-token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{t: t, platform: tt.platform, comments: tt.comments}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			token := "gh-token"
+			if tt.platform == config.PlatformGitLab {
+				token = "gl-token"
+			}
+			client := &Client{Platform: tt.platform, APIURL: server.URL + "/", Token: token, Repo: "acme/infra", Number: 7}
+
+			created, err := client.Upsert(context.Background(), marker, "new plan\n"+marker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("created = %t, want %t", created, tt.wantCreated)
+			}
+			if strings.Join(api.requests, "\n") != strings.Join(tt.wantRequests, "\n") {
+				t.Errorf("requests\n%s\nwant\n%s", strings.Join(api.requests, "\n"), strings.Join(tt.wantRequests, "\n"))
+			}
+			for _, auth := range api.auth {
+				if auth != tt.wantAuth {
+					t.Errorf("authenticated with %q, want %q", auth, tt.wantAuth)
+				}
+			}
+
+			updated := 0
+			for _, c := range api.comments {
+				if c.Body == "new plan\n"+marker {
+					updated++
+				}
+			}
+			if updated != 1 {
+				t.Errorf("%d comments have the new body, want 1", updated)
+			}
+		})
+	}
+}
+
+func TestUpsertError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &Client{Platform: config.PlatformGitHub, APIURL: server.URL, Token: "expired", Repo: "acme/infra", Number: 7}
+	_, err := client.Upsert(context.Background(), "marker", "body")
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("err = %v, want the status and message", err)
+	}
+}
+
+func TestFromEnvironment(t *testing.T) {
+	for _, name := range []string{"GITHUB_ACTIONS", "GITHUB_API_URL", "GITHUB_TOKEN", "GITHUB_REPOSITORY", "GITHUB_REF",
+		"GITLAB_CI", "CI_API_V4_URL", "GITLAB_TOKEN", "CI_PROJECT_ID", "CI_MERGE_REQUEST_IID"} {
+		t.Setenv(name, "")
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		platform string
+		want     Client
+	}{
+		{
+			name: "github actions",
+			env: map[string]string{
+				"GITHUB_ACTIONS": "true", "GITHUB_API_URL": "https://ghe.example.com/api/v3", "GITHUB_TOKEN": "t",
+				"GITHUB_REPOSITORY": "acme/infra", "GITHUB_REF": "refs/pull/42/merge",
+			},
+			want: Client{Platform: config.PlatformGitHub, APIURL: "https://ghe.example.com/api/v3", Token: "t", Repo: "acme/infra", Number: 42},
+		},
+		{
+			name: "github push",
+			env:  map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF": "refs/heads/main"},
+			want: Client{Platform: config.PlatformGitHub},
+		},
+		{
+			name: "gitlab ci",
+			env: map[string]string{
+				"GITLAB_CI": "true", "CI_API_V4_URL": "https://gitlab.example.com/api/v4", "GITLAB_TOKEN": "t",
+				"CI_PROJECT_ID": "12", "CI_MERGE_REQUEST_IID": "5",
+			},
+			want: Client{Platform: config.PlatformGitLab, APIURL: "https://gitlab.example.com/api/v4", Token: "t", Repo: "12", Number: 5},
+		},
+		{
+			name:     "platform given",
+			env:      map[string]string{"GITHUB_ACTIONS": "true", "CI_PROJECT_ID": "12"},
+			platform: config.PlatformGitLab,
+			want:     Client{Platform: config.PlatformGitLab, Repo: "12"},
+		},
+		{
+			name: "outside ci",
+			want: Client{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if got := FromEnvironment(tt.platform); *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
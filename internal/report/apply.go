@@ -0,0 +1,123 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/tplan/internal/apply"
+)
+
+// DefaultApplyReportPath is where the TUI exports apply reports
+const DefaultApplyReportPath = "apply-report.md"
+
+// GenerateApplyMarkdown creates a Markdown report of an apply: what was
+// applied, what failed with its diagnostics, and what was not applied
+func GenerateApplyMarkdown(result *apply.Result) string {
+	var b strings.Builder
+
+	b.WriteString("# Terraform Apply Report\n\n")
+	b.WriteString(fmt.Sprintf("**Generated:** %s\n\n", time.Now().Format("2006-01-02 15:04:05 MST")))
+	if !result.Started.IsZero() && result.Done() {
+		b.WriteString(fmt.Sprintf("**Duration:** %s\n\n", result.Finished.Sub(result.Started).Round(time.Second)))
+	}
+
+	applied, failed, pending := result.Counts()
+	status := "✅ Succeeded"
+	if !result.Succeeded() {
+		status = "❌ Failed"
+	}
+	b.WriteString(fmt.Sprintf("**Status:** %s\n\n", status))
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Status | Count |\n")
+	b.WriteString("|--------|-------|\n")
+	b.WriteString(fmt.Sprintf("| ✅ Applied | %d |\n", applied))
+	b.WriteString(fmt.Sprintf("| ❌ Failed | %d |\n", failed))
+	b.WriteString(fmt.Sprintf("| ⏸ Not applied | %d |\n", pending))
+	b.WriteString("\n")
+
+	for _, line := range result.Summary {
+		b.WriteString(fmt.Sprintf("> %s\n", line))
+	}
+	if len(result.Summary) > 0 {
+		b.WriteString("\n")
+	}
+	if result.Err != nil {
+		b.WriteString(fmt.Sprintf("**Error:**\n```\n%v\n```\n\n", result.Err))
+	}
+
+	if failed > 0 {
+		b.WriteString("## Failed\n\n")
+		for _, state := range result.Resources {
+			if state.Status != apply.StatusFailed {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("### %s\n\n", applyAddress(state)))
+			b.WriteString(fmt.Sprintf("- **Action:** %s\n", state.Action))
+			b.WriteString(fmt.Sprintf("- **Elapsed:** %s\n\n", state.Elapsed(result.Finished).Round(time.Second)))
+			for _, diag := range state.Diagnostics {
+				writeDiagnostic(&b, diag)
+			}
+		}
+	}
+
+	if len(result.Diagnostics) > 0 {
+		b.WriteString("## Diagnostics\n\n")
+		for _, diag := range result.Diagnostics {
+			writeDiagnostic(&b, diag)
+		}
+	}
+
+	if applied > 0 {
+		b.WriteString("## Applied\n\n")
+		b.WriteString("| Resource | Action | Elapsed |\n")
+		b.WriteString("|----------|--------|---------|\n")
+		for _, state := range result.Resources {
+			if state.Status == apply.StatusApplied {
+				b.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", escapeTableCell(applyAddress(state)), state.Action,
+					state.Elapsed(result.Finished).Round(time.Second)))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if pending > 0 {
+		b.WriteString("## Not Applied\n\n")
+		for _, state := range result.Resources {
+			if state.Status == apply.StatusPending || state.Status == apply.StatusApplying {
+				b.WriteString(fmt.Sprintf("- `%s` (%s)\n", applyAddress(state), state.Action))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// WriteApplyReport writes the apply report to a file
+func WriteApplyReport(filename string, result *apply.Result) error {
+	return os.WriteFile(filename, []byte(GenerateApplyMarkdown(result)), 0644)
+}
+
+// applyAddress returns the resource address, prefixed with its root in multi-root applies
+func applyAddress(state *apply.ResourceState) string {
+	if state.Root != "" {
+		return state.Root + ": " + state.Address
+	}
+	return state.Address
+}
+
+// writeDiagnostic writes an apply diagnostic as a quoted block
+func writeDiagnostic(b *strings.Builder, diag apply.Diagnostic) {
+	severity := "Error"
+	if diag.Severity == "warning" {
+		severity = "Warning"
+	}
+	b.WriteString(fmt.Sprintf("**%s:** %s\n", severity, diag.Summary))
+	if diag.Detail != "" {
+		b.WriteString(fmt.Sprintf("```\n%s\n```\n", diag.Detail))
+	}
+	b.WriteString("\n")
+}
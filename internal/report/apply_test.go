@@ -0,0 +1,122 @@
+package report
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/tplan/internal/apply"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// resource returns a change of the given action; updates change "size"
+func resource(root, module, address string, action models.ChangeAction) models.ResourceChange {
+	res := models.ResourceChange{
+		Root:    root,
+		Module:  module,
+		Address: address,
+		Type:    strings.Split(strings.TrimPrefix(address, module+"."), ".")[0],
+		Action:  action,
+	}
+	switch action {
+	case models.ActionCreate:
+		res.Change.After = map[string]interface{}{"size": "small"}
+	case models.ActionDelete:
+		res.Change.Before = map[string]interface{}{"size": "small"}
+	default:
+		res.Change.Before = map[string]interface{}{"size": "small"}
+		res.Change.After = map[string]interface{}{"size": "large"}
+	}
+	return res
+}
+
+// planOf builds a plan and its summary from resource changes
+func planOf(resources ...models.ResourceChange) *models.PlanResult {
+	plan := &models.PlanResult{Resources: resources}
+	for _, res := range resources {
+		switch res.Action {
+		case models.ActionCreate:
+			plan.Summary.ToCreate++
+		case models.ActionUpdate:
+			plan.Summary.ToUpdate++
+		case models.ActionDelete:
+			plan.Summary.ToDelete++
+		case models.ActionReplace:
+			plan.Summary.ToReplace++
+		}
+		plan.Summary.Total++
+	}
+	return plan
+}
+
+func TestGenerateApplyMarkdown(t *testing.T) {
+	plan := planOf(
+		resource("app", "", "aws_instance.web", models.ActionCreate),
+		resource("app", "", "aws_s3_bucket.logs", models.ActionUpdate),
+		resource("app", "", "aws_iam_role.ci", models.ActionDelete),
+	)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		run     func(r *apply.Result)
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "succeeded",
+			run: func(r *apply.Result) {
+				for _, address := range []string{"aws_instance.web", "aws_s3_bucket.logs", "aws_iam_role.ci"} {
+					r.Apply(apply.Event{Type: apply.EventApplyStart, Root: "app", Address: address}, start)
+					r.Apply(apply.Event{Type: apply.EventApplyComplete, Root: "app", Address: address}, start.Add(5*time.Second))
+				}
+				r.Apply(apply.Event{Type: apply.EventChangeSummary, Message: "Apply complete! Resources: 1 added, 1 changed, 1 destroyed."}, start.Add(6*time.Second))
+				r.Finish(nil, start.Add(65*time.Second))
+			},
+			want:    []string{"**Duration:** 1m5s", "**Status:** ✅ Succeeded", "| ✅ Applied | 3 |", "> Apply complete!", "| `app: aws_instance.web` | create | 5s |"},
+			notWant: []string{"## Failed", "## Not Applied", "**Error:**"},
+		},
+		{
+			name: "failed",
+			run: func(r *apply.Result) {
+				r.Apply(apply.Event{Type: apply.EventApplyStart, Root: "app", Address: "aws_instance.web"}, start)
+				r.Apply(apply.Event{Type: apply.EventApplyComplete, Root: "app", Address: "aws_instance.web"}, start.Add(time.Second))
+				r.Apply(apply.Event{Type: apply.EventApplyStart, Root: "app", Address: "aws_s3_bucket.logs"}, start)
+				r.Apply(apply.Event{Type: apply.EventDiagnostic, Root: "app", Diagnostic: &apply.Diagnostic{
+					Severity: "error", Summary: "AccessDenied", Detail: "s3:PutBucketAcl", Address: "aws_s3_bucket.logs",
+				}}, start.Add(2*time.Second))
+				r.Apply(apply.Event{Type: apply.EventDiagnostic, Diagnostic: &apply.Diagnostic{Severity: "warning", Summary: "Deprecated"}}, start)
+				r.Finish(errors.New("exit status 1"), start.Add(3*time.Second))
+			},
+			want: []string{
+				"**Status:** ❌ Failed",
+				"| ❌ Failed | 1 |",
+				"| ⏸ Not applied | 1 |",
+				"**Error:**\n```\nexit status 1\n```",
+				// Failed by a diagnostic, the bucket ran until the apply finished
+				"### app: aws_s3_bucket.logs\n\n- **Action:** update\n- **Elapsed:** 3s",
+				"**Error:** AccessDenied\n```\ns3:PutBucketAcl\n```",
+				"## Diagnostics\n\n**Warning:** Deprecated",
+				"## Not Applied\n\n- `app: aws_iam_role.ci` (delete)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := apply.NewResult(plan)
+			tt.run(result)
+			content := GenerateApplyMarkdown(result)
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("report does not contain %q:\n%s", want, content)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(content, notWant) {
+					t.Errorf("report contains %q", notWant)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// Size limits of comment bodies. GitHub counts characters; tplan counts
+// bytes, which is never less.
+const (
+	GitHubCommentLimit = 65536
+	GitLabCommentLimit = 1000000
+
+	// DefaultCommentMaxLength fits both platforms
+	DefaultCommentMaxLength = GitHubCommentLimit
+
+	// MinCommentMaxLength is the smallest limit the summary table is sure to
+	// fit in; smaller limits are raised to it
+	MinCommentMaxLength = 1024
+)
+
+// commentMarkerPrefix starts the hidden marker of tplan's comments
+const commentMarkerPrefix = "<!-- tplan:pr-comment"
+
+// CommentOptions configure a pull request comment
+type CommentOptions struct {
+	// ID tells comments of several plans in one pull request apart, e.g. one
+	// per environment. Comments with the same ID replace each other. The ID
+	// is put into an HTML comment as is; see config.ValidCommentID.
+	ID string
+
+	// MaxLength is the maximum size of the comment in bytes, at least
+	// MinCommentMaxLength
+	MaxLength int
+}
+
+// CommentMarker returns the hidden HTML comment that identifies the PR
+// comment with the given ID, so a bot can find and update it in place
+func CommentMarker(id string) string {
+	if id == "" {
+		return commentMarkerPrefix + " -->"
+	}
+	return commentMarkerPrefix + ":" + id + " -->"
+}
+
+// commentModule is the changes of one module in one root
+type commentModule struct {
+	root      string
+	module    string
+	resources []models.ResourceChange
+}
+
+// destructive returns true if the module deletes or replaces resources
+func (m *commentModule) destructive() bool {
+	for _, res := range m.resources {
+		if isDestructive(res.Action) {
+			return true
+		}
+	}
+	return false
+}
+
+// label names the module in its <summary>
+func (m *commentModule) label() string {
+	module := "root module"
+	if m.module != "" {
+		module = m.module
+	}
+	if m.root != "" {
+		return fmt.Sprintf("<code>%s</code> · <code>%s</code>", m.root, module)
+	}
+	return fmt.Sprintf("<code>%s</code>", module)
+}
+
+// isDestructive returns true for actions that destroy existing resources
+func isDestructive(action models.ChangeAction) bool {
+	return action == models.ActionDelete || action == models.ActionReplace
+}
+
+// commentActionOrder lists the actions shown in a comment, destructive first
+var commentActionOrder = []models.ChangeAction{
+	models.ActionDelete,
+	models.ActionReplace,
+	models.ActionUpdate,
+	models.ActionCreate,
+}
+
+// actionEmoji marks actions like the summary table does
+var actionEmoji = map[models.ChangeAction]string{
+	models.ActionCreate:  "🟢",
+	models.ActionUpdate:  "🟡",
+	models.ActionDelete:  "🔴",
+	models.ActionReplace: "🔵",
+}
+
+// GeneratePRComment creates a compact Markdown summary for a pull request
+// comment: the summary table and a collapsible block per module, modules
+// with destructive changes first and expanded. Resources that don't fit in
+// opts.MaxLength are left out with a notice; errors that don't fit are only
+// counted.
+func (g *Generator) GeneratePRComment(opts CommentOptions) string {
+	maxLength := opts.MaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultCommentMaxLength
+	} else if maxLength < MinCommentMaxLength {
+		maxLength = MinCommentMaxLength
+	}
+
+	var b strings.Builder
+	summary := g.plan.Summary
+
+	b.WriteString(CommentMarker(opts.ID) + "\n")
+	title := "Terraform Plan"
+	if opts.ID != "" {
+		title += ": " + opts.ID
+	}
+	b.WriteString(fmt.Sprintf("### %s\n\n", title))
+
+	changes := summary.ToCreate + summary.ToUpdate + summary.ToDelete + summary.ToReplace
+	if changes == 0 && len(g.plan.Errors) == 0 {
+		b.WriteString("**No changes.** Infrastructure matches the configuration.\n")
+		b.WriteString(commentFooter)
+		return b.String()
+	}
+
+	if destroyed := summary.ToDelete + summary.ToReplace; destroyed > 0 {
+		b.WriteString(fmt.Sprintf("> [!WARNING]\n> **%d resource(s) will be destroyed or replaced.**\n\n", destroyed))
+	}
+	b.WriteString(g.generateSummary())
+	b.WriteString("\n")
+
+	modules := g.commentModules()
+	total := 0
+	for _, m := range modules {
+		total += len(m.resources)
+	}
+
+	tail := ""
+	if len(g.plan.Warnings) > 0 {
+		tail = fmt.Sprintf("⚠️ %d warning(s); see `tplan report` for details.\n", len(g.plan.Warnings))
+	}
+	tail += commentFooter
+
+	// Keep room for the tail and the truncation notice at its longest, with
+	// every resource left out
+	budget := maxLength - b.Len() - len(tail) - len(truncationNotice(total, total))
+
+	if len(g.plan.Errors) > 0 {
+		errors := g.commentErrors()
+		if len(errors) > budget {
+			errors = fmt.Sprintf("❌ %d error(s); see `tplan report` for details.\n\n", len(g.plan.Errors))
+		}
+		b.WriteString(errors)
+		budget -= len(errors)
+	}
+
+	// Add modules, and rows within them, while they fit
+	shown := 0
+	for _, m := range modules {
+		open := ""
+		if m.destructive() {
+			open = " open"
+		}
+		counts := make([]string, 0, len(commentActionOrder))
+		for _, action := range commentActionOrder {
+			if n := countAction(m.resources, action); n > 0 {
+				counts = append(counts, fmt.Sprintf("%s %d", actionEmoji[action], n))
+			}
+		}
+		header := fmt.Sprintf("<details%s><summary>%s — %s</summary>\n\n| Action | Resource | Changes |\n|--------|----------|---------|\n",
+			open, m.label(), strings.Join(counts, " "))
+		const closing = "\n</details>\n\n"
+		if len(header)+len(closing) > budget {
+			break
+		}
+
+		var block strings.Builder
+		block.WriteString(header)
+		rows := 0
+		for _, res := range m.resources {
+			row := g.commentRow(res)
+			if block.Len()+len(row)+len(closing) > budget {
+				break
+			}
+			block.WriteString(row)
+			rows++
+		}
+		if rows == 0 {
+			break
+		}
+		block.WriteString(closing)
+
+		b.WriteString(block.String())
+		budget -= block.Len()
+		shown += rows
+		if rows < len(m.resources) {
+			break
+		}
+	}
+
+	if shown < total {
+		b.WriteString(truncationNotice(total-shown, total))
+	}
+	b.WriteString(tail)
+	return b.String()
+}
+
+// truncationNotice tells how many resource changes a comment leaves out
+func truncationNotice(hidden, total int) string {
+	return fmt.Sprintf("> [!NOTE]\n> Truncated to fit the comment size limit: %d of %d resource changes are not shown. Run `tplan report` for the full plan.\n\n",
+		hidden, total)
+}
+
+// commentFooter ends every comment
+const commentFooter = "\n---\n*Generated by tplan*\n"
+
+// commentModules groups the changed resources by root and module. Resources
+// are collected by action, destructive first, so modules with destructive
+// changes come first too; otherwise the plan order is kept.
+func (g *Generator) commentModules() []*commentModule {
+	modules := make([]*commentModule, 0)
+	index := make(map[string]*commentModule)
+	for _, action := range commentActionOrder {
+		for _, res := range g.getResourcesByAction(action) {
+			key := res.Root + "\x00" + res.Module
+			m, ok := index[key]
+			if !ok {
+				m = &commentModule{root: res.Root, module: res.Module}
+				index[key] = m
+				modules = append(modules, m)
+			}
+			m.resources = append(m.resources, res)
+		}
+	}
+	return modules
+}
+
+// commentRow renders one resource as a table row listing its changed attributes
+func (g *Generator) commentRow(res models.ResourceChange) string {
+	details := ""
+	switch res.Action {
+	case models.ActionUpdate, models.ActionReplace:
+		paths := make([]string, 0)
+		hidden := 0
+		for _, change := range g.computeDiff(res).Changes() {
+			switch {
+			case change.Ignored && !g.showNoise:
+				continue
+			case len(paths) < 5:
+				paths = append(paths, fmt.Sprintf("`%s`", change.Path))
+			default:
+				hidden++
+			}
+		}
+		details = strings.Join(paths, ", ")
+		if hidden > 0 {
+			details += fmt.Sprintf(" +%d more", hidden)
+		}
+		if res.ActionReason != "" && details != "" {
+			details = res.ActionReason + "; " + details
+		} else if res.ActionReason != "" {
+			details = res.ActionReason
+		}
+	}
+	return fmt.Sprintf("| %s %s | `%s` | %s |\n", actionEmoji[res.Action], res.Action, escapeTableCell(res.Address), escapeTableCell(details))
+}
+
+// commentErrors renders the plan errors in an expanded block
+func (g *Generator) commentErrors() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<details open><summary>❌ %d error(s)</summary>\n\n", len(g.plan.Errors)))
+	for i, err := range g.plan.Errors {
+		if i == 10 {
+			b.WriteString(fmt.Sprintf("*%d more error(s) not shown*\n\n", len(g.plan.Errors)-i))
+			break
+		}
+		if err.Resource != "" {
+			b.WriteString(fmt.Sprintf("**`%s`**\n", err.Resource))
+		}
+		b.WriteString(fmt.Sprintf("```\n%s\n```\n\n", truncate(err.Message, 1000)))
+	}
+	b.WriteString("</details>\n\n")
+	return b.String()
+}
+
+// countAction counts the resources with an action
+func countAction(resources []models.ResourceChange, action models.ChangeAction) int {
+	n := 0
+	for _, res := range resources {
+		if res.Action == action {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,159 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestCommentMarker(t *testing.T) {
+	if got := CommentMarker(""); got != "<!-- tplan:pr-comment -->" {
+		t.Errorf("got %s", got)
+	}
+	if got := CommentMarker("prod"); got != "<!-- tplan:pr-comment:prod -->" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestGeneratePRComment(t *testing.T) {
+	mixed := planOf(
+		resource("", "module.app", "module.app.aws_instance.web", models.ActionCreate),
+		resource("", "", "aws_s3_bucket.logs", models.ActionUpdate),
+		resource("", "module.db", "module.db.aws_db_instance.main", models.ActionDelete),
+	)
+
+	tests := []struct {
+		name    string
+		plan    *models.PlanResult
+		opts    CommentOptions
+		want    []string
+		notWant []string
+
+		// order lists texts that must appear in this order
+		order []string
+	}{
+		{
+			name: "no changes",
+			plan: planOf(),
+			want: []string{"<!-- tplan:pr-comment -->", "**No changes.**", "*Generated by tplan*"},
+		},
+		{
+			name:    "destructive module first and expanded",
+			plan:    mixed,
+			opts:    CommentOptions{ID: "prod"},
+			want:    []string{"### Terraform Plan: prod", "**1 resource(s) will be destroyed or replaced.**", "<details open><summary><code>module.db</code> — 🔴 1</summary>", "<details><summary><code>module.app</code>", "| 🟡 update | `aws_s3_bucket.logs` | `size` |"},
+			notWant: []string{"Truncated"},
+			order:   []string{"module.db", "root module", "module.app"},
+		},
+		{
+			name: "errors",
+			plan: func() *models.PlanResult {
+				plan := planOf()
+				plan.Errors = []models.PlanError{{Resource: "aws_instance.web", Message: "Invalid AMI"}}
+				return plan
+			}(),
+			want:    []string{"❌ 1 error(s)", "**`aws_instance.web`**", "Invalid AMI"},
+			notWant: []string{"No changes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := NewGenerator(tt.plan, false).GeneratePRComment(tt.opts)
+			for _, want := range tt.want {
+				if !strings.Contains(comment, want) {
+					t.Errorf("comment does not contain %q:\n%s", want, comment)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(comment, notWant) {
+					t.Errorf("comment contains %q", notWant)
+				}
+			}
+			last := -1
+			for _, text := range tt.order {
+				i := strings.Index(comment, text)
+				if i < last {
+					t.Errorf("%q is out of order", text)
+				}
+				last = i
+			}
+		})
+	}
+}
+
+func TestGeneratePRCommentTruncates(t *testing.T) {
+	resources := make([]models.ResourceChange, 0, 200)
+	for i := 0; i < 200; i++ {
+		resources = append(resources, resource("", "", fmt.Sprintf("aws_instance.web[%d]", i), models.ActionUpdate))
+	}
+	plan := planOf(resources...)
+
+	failed := planOf(resources...)
+	for i := 0; i < 20; i++ {
+		failed.Errors = append(failed.Errors, models.PlanError{Resource: fmt.Sprintf("aws_instance.web[%d]", i), Message: strings.Repeat("x", 2000)})
+	}
+	failed.Warnings = []models.PlanWarning{{Message: "deprecated"}}
+
+	tests := []struct {
+		plan      *models.PlanResult
+		maxLength int
+		limit     int
+	}{
+		{plan, 100, MinCommentMaxLength},
+		{plan, 3000, 3000},
+		{plan, 6000, 6000},
+		{plan, GitHubCommentLimit, GitHubCommentLimit},
+		{failed, 1500, 1500},
+		{failed, 20000, 20000},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d errors, %d bytes", len(tt.plan.Errors), tt.maxLength), func(t *testing.T) {
+			comment := NewGenerator(tt.plan, false).GeneratePRComment(CommentOptions{ID: "production", MaxLength: tt.maxLength})
+			if len(comment) > tt.limit {
+				t.Errorf("comment has %d bytes, more than %d", len(comment), tt.limit)
+			}
+			shown := strings.Count(comment, "| 🟡 update |")
+			truncated := strings.Contains(comment, fmt.Sprintf("%d of 200 resource changes are not shown", 200-shown))
+			if truncated != (shown < 200) {
+				t.Errorf("%d rows shown, truncation notice %t", shown, truncated)
+			}
+			if len(tt.plan.Errors) > 0 && !strings.Contains(comment, "20 error(s)") {
+				t.Error("the errors are not mentioned")
+			}
+			if !strings.HasSuffix(comment, commentFooter) {
+				t.Error("the footer is missing")
+			}
+		})
+	}
+}
+
+func TestCommentRow(t *testing.T) {
+	many := resource("", "", "aws_instance.web", models.ActionReplace)
+	many.ActionReason = "requires replacement"
+	for _, attr := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		many.Change.Before[attr] = "1"
+		many.Change.After[attr] = "2"
+	}
+	piped := resource("", "", "aws_instance.pipe", models.ActionUpdate)
+	piped.ActionReason = "a|b"
+
+	tests := []struct {
+		name string
+		res  models.ResourceChange
+		want string
+	}{
+		{"create", resource("", "", "aws_instance.new", models.ActionCreate), "| 🟢 create | `aws_instance.new` |  |\n"},
+		{"reason and more paths", many, "| 🔵 replace | `aws_instance.web` | requires replacement; `a`, `b`, `c`, `d`, `e` +3 more |\n"},
+		{"escaped cell", piped, "| 🟡 update | `aws_instance.pipe` | a\\|b; `size` |\n"},
+		{"escaped address", resource("", "", `aws_route.r["a|b"]`, models.ActionCreate), "| 🟢 create | `aws_route.r[\"a\\|b\"]` |  |\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewGenerator(planOf(), false).commentRow(tt.res); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
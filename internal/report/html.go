@@ -0,0 +1,553 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/tplan/internal/diff"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// htmlValueLimit caps the size of a single rendered value
+const htmlValueLimit = 20000
+
+// actionSymbols are the short forms of actions in group counts
+var actionSymbols = map[models.ChangeAction]string{
+	models.ActionCreate:  "+",
+	models.ActionUpdate:  "~",
+	models.ActionDelete:  "-",
+	models.ActionReplace: "±",
+}
+
+// htmlResource is a changing resource with its anchor in the page
+type htmlResource struct {
+	id   string
+	file string
+	res  models.ResourceChange
+}
+
+// htmlGroup is a group of the sidebar tree
+type htmlGroup struct {
+	label     string
+	resources []*htmlResource
+}
+
+// GenerateHTML creates a self-contained HTML report with a sidebar tree by
+// module or file, search, action filters and side-by-side attribute diffs.
+// Styles and scripts are inlined, so the file can be shared as is.
+func (g *Generator) GenerateHTML() string {
+	resources := g.htmlResources()
+	summary := g.plan.Summary
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString("<title>Terraform Plan Report</title>\n")
+	b.WriteString("<style>\n" + htmlStyle + "</style>\n</head>\n<body>\n")
+
+	// Sidebar with both groupings; the script switches between them
+	b.WriteString("<nav id=\"sidebar\">\n<div class=\"grouping\">Group by ")
+	b.WriteString("<label><input type=\"radio\" name=\"grouping\" value=\"module\" checked> module</label> ")
+	b.WriteString("<label><input type=\"radio\" name=\"grouping\" value=\"file\"> file</label></div>\n")
+	b.WriteString("<div id=\"tree-module\">\n")
+	g.writeHTMLTree(&b, resources, func(r *htmlResource) string {
+		if r.res.Module == "" {
+			return "root module"
+		}
+		return r.res.Module
+	})
+	b.WriteString("</div>\n<div id=\"tree-file\" hidden>\n")
+	g.writeHTMLTree(&b, resources, func(r *htmlResource) string {
+		return r.file
+	})
+	b.WriteString("</div>\n</nav>\n<main>\n")
+
+	// Header with the summary; the action counts double as filters
+	b.WriteString("<header>\n<h1>Terraform Plan Report</h1>\n<p class=\"meta\">")
+	b.WriteString(fmt.Sprintf("Generated %s", html.EscapeString(time.Now().Format("2006-01-02 15:04:05 MST"))))
+	if g.plan.TerraformVersion != "" {
+		b.WriteString(fmt.Sprintf(" · Terraform %s", html.EscapeString(g.plan.TerraformVersion)))
+	}
+	if len(g.plan.Roots) > 1 {
+		b.WriteString(fmt.Sprintf(" · %d root modules", len(g.plan.Roots)))
+	}
+	if g.includeDrift {
+		b.WriteString(" · includes git drift analysis")
+	}
+	b.WriteString("</p>\n")
+
+	if destroyed := summary.ToDelete + summary.ToReplace; destroyed > 0 {
+		b.WriteString(fmt.Sprintf("<p class=\"warning\">%d resource(s) will be destroyed or replaced.</p>\n", destroyed))
+	}
+
+	b.WriteString("<div class=\"toolbar\">\n")
+	counts := map[models.ChangeAction]int{
+		models.ActionCreate:  summary.ToCreate,
+		models.ActionUpdate:  summary.ToUpdate,
+		models.ActionDelete:  summary.ToDelete,
+		models.ActionReplace: summary.ToReplace,
+	}
+	for _, action := range commentActionOrder {
+		if counts[action] == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("<label class=\"filter %s\"><input type=\"checkbox\" value=\"%s\" checked> %s <b>%d</b></label>\n",
+			action, action, action, counts[action]))
+	}
+	b.WriteString("<input id=\"search\" type=\"search\" placeholder=\"Search resources ( / )\" autocomplete=\"off\">\n")
+	b.WriteString("<button type=\"button\" id=\"expand\">Expand all</button> <button type=\"button\" id=\"collapse\">Collapse all</button>\n")
+	b.WriteString(fmt.Sprintf("<span class=\"shown\"><span id=\"shown\">%d</span> of %d resources</span>\n", len(resources), len(resources)))
+	b.WriteString("</div>\n</header>\n")
+
+	if len(g.plan.Errors) > 0 {
+		b.WriteString(fmt.Sprintf("<section class=\"diagnostics\"><h2>Errors (%d)</h2>\n", len(g.plan.Errors)))
+		for _, err := range g.plan.Errors {
+			writeHTMLDiagnostic(&b, "error", err.Resource, err.Message)
+		}
+		b.WriteString("</section>\n")
+	}
+	if len(g.plan.Warnings) > 0 {
+		b.WriteString(fmt.Sprintf("<section class=\"diagnostics\"><h2>Warnings (%d)</h2>\n", len(g.plan.Warnings)))
+		for _, warn := range g.plan.Warnings {
+			writeHTMLDiagnostic(&b, "warning", warn.Resource, warn.Message)
+		}
+		b.WriteString("</section>\n")
+	}
+
+	if len(resources) == 0 {
+		b.WriteString("<p class=\"empty\">No changes. Infrastructure matches the configuration.</p>\n")
+	}
+	b.WriteString("<section id=\"resources\">\n")
+	for _, r := range resources {
+		g.writeHTMLResource(&b, r)
+	}
+	b.WriteString("</section>\n<p id=\"empty\" class=\"empty\" hidden>No resources match the search and filters.</p>\n")
+
+	b.WriteString("</main>\n<script>\n" + htmlScript + "</script>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// htmlResources returns the changing resources, destructive actions first
+func (g *Generator) htmlResources() []*htmlResource {
+	resources := make([]*htmlResource, 0, len(g.plan.Resources))
+	for _, action := range commentActionOrder {
+		for _, res := range g.getResourcesByAction(action) {
+			resources = append(resources, &htmlResource{
+				id:   fmt.Sprintf("r%d", len(resources)+1),
+				file: resourceFile(res),
+				res:  res,
+			})
+		}
+	}
+	return resources
+}
+
+// resourceFile returns the file declaring a resource, relative to the
+// working directory when it is below it
+func resourceFile(res models.ResourceChange) string {
+	if res.DriftInfo == nil || res.DriftInfo.FilePath == "" {
+		return "unknown file"
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, res.DriftInfo.FilePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return res.DriftInfo.FilePath
+}
+
+// writeHTMLTree writes the sidebar tree of resources grouped by label,
+// below one group per root module for multi-root plans
+func (g *Generator) writeHTMLTree(b *strings.Builder, resources []*htmlResource, label func(*htmlResource) string) {
+	roots := make([]string, 0)
+	byRoot := make(map[string][]*htmlResource)
+	for _, r := range resources {
+		if _, ok := byRoot[r.res.Root]; !ok {
+			roots = append(roots, r.res.Root)
+		}
+		byRoot[r.res.Root] = append(byRoot[r.res.Root], r)
+	}
+	sort.Strings(roots)
+
+	for _, root := range roots {
+		if root != "" {
+			b.WriteString(fmt.Sprintf("<details class=\"group root\" open><summary>%s %s</summary>\n",
+				html.EscapeString(root), htmlCounts(byRoot[root])))
+		}
+		for _, group := range htmlGroups(byRoot[root], label) {
+			b.WriteString(fmt.Sprintf("<details class=\"group\" open><summary>%s %s</summary>\n<ul>\n",
+				html.EscapeString(group.label), htmlCounts(group.resources)))
+			for _, r := range group.resources {
+				name := r.res.Address
+				if r.res.Module != "" && label(r) == r.res.Module {
+					name = strings.TrimPrefix(name, r.res.Module+".")
+				}
+				b.WriteString(fmt.Sprintf("<li data-res=\"%s\"><a href=\"#%s\" class=\"%s\" title=\"%s\">%s</a></li>\n",
+					r.id, r.id, r.res.Action, html.EscapeString(r.res.Address), html.EscapeString(name)))
+			}
+			b.WriteString("</ul>\n</details>\n")
+		}
+		if root != "" {
+			b.WriteString("</details>\n")
+		}
+	}
+}
+
+// htmlGroups groups resources by label, sorted by label with the root
+// module first and resources sorted by address
+func htmlGroups(resources []*htmlResource, label func(*htmlResource) string) []*htmlGroup {
+	groups := make([]*htmlGroup, 0)
+	index := make(map[string]*htmlGroup)
+	for _, r := range resources {
+		key := label(r)
+		group, ok := index[key]
+		if !ok {
+			group = &htmlGroup{label: key}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.resources = append(group.resources, r)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].label == "root module") != (groups[j].label == "root module") {
+			return groups[i].label == "root module"
+		}
+		return groups[i].label < groups[j].label
+	})
+	for _, group := range groups {
+		sort.SliceStable(group.resources, func(i, j int) bool {
+			return group.resources[i].res.Address < group.resources[j].res.Address
+		})
+	}
+	return groups
+}
+
+// htmlCounts renders the action counts of resources like "+2 ~1"
+func htmlCounts(resources []*htmlResource) string {
+	parts := make([]string, 0, len(commentActionOrder))
+	for _, action := range []models.ChangeAction{models.ActionCreate, models.ActionUpdate, models.ActionDelete, models.ActionReplace} {
+		n := 0
+		for _, r := range resources {
+			if r.res.Action == action {
+				n++
+			}
+		}
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("<span class=\"%s\">%s%d</span>", action, actionSymbols[action], n))
+		}
+	}
+	return "<span class=\"counts\">" + strings.Join(parts, " ") + "</span>"
+}
+
+// writeHTMLResource writes one collapsible resource with its details and
+// attribute diff. Destructive changes start expanded.
+func (g *Generator) writeHTMLResource(b *strings.Builder, r *htmlResource) {
+	res := r.res
+	changeDiff := g.computeDiff(res)
+
+	search := strings.ToLower(strings.Join([]string{res.Address, res.Type, res.Module, res.Root, r.file}, " "))
+	open := ""
+	if isDestructive(res.Action) {
+		open = " open"
+	}
+	b.WriteString(fmt.Sprintf("<details class=\"resource\" id=\"%s\" data-action=\"%s\" data-search=\"%s\"%s>\n",
+		r.id, res.Action, html.EscapeString(search), open))
+
+	b.WriteString(fmt.Sprintf("<summary><span class=\"badge %s\">%s</span> <code>%s</code>", res.Action, res.Action, html.EscapeString(res.Address)))
+	if res.Root != "" {
+		b.WriteString(fmt.Sprintf(" <span class=\"tag\">%s</span>", html.EscapeString(res.Root)))
+	}
+	if res.ActionReason != "" {
+		b.WriteString(fmt.Sprintf(" <span class=\"reason\">%s</span>", html.EscapeString(res.ActionReason)))
+	}
+	if changeDiff.NoiseOnly() {
+		b.WriteString(" <span class=\"tag\" title=\"every change matches an ignore rule\">noise</span>")
+	}
+	b.WriteString("</summary>\n<div class=\"body\">\n<dl>\n")
+
+	writeHTMLField(b, "Type", "<code>"+html.EscapeString(res.Type)+"</code>")
+	writeHTMLField(b, "Provider", "<code>"+html.EscapeString(res.ProviderName)+"</code>")
+	if res.Module != "" {
+		writeHTMLField(b, "Module", "<code>"+html.EscapeString(res.Module)+"</code>")
+	}
+	if res.Root != "" {
+		writeHTMLField(b, "Root", "<code>"+html.EscapeString(res.Root)+"</code>")
+	}
+	writeHTMLField(b, "File", "<code>"+html.EscapeString(r.file)+"</code>")
+
+	// Git information (if drift mode is enabled and available)
+	if g.includeDrift && res.DriftInfo != nil && res.DriftInfo.IsValid() {
+		drift := res.DriftInfo
+		writeHTMLField(b, "Commit", fmt.Sprintf("<code title=\"%s\">%s</code> on <code>%s</code>",
+			html.EscapeString(drift.CommitID), html.EscapeString(drift.ShortCommitID()), html.EscapeString(drift.BranchName)))
+		writeHTMLField(b, "Author", html.EscapeString(fmt.Sprintf("%s <%s>", drift.AuthorName, drift.AuthorEmail)))
+		writeHTMLField(b, "Date", html.EscapeString(drift.CommitDate.Format("2006-01-02 15:04:05")))
+		if drift.CommitMessage != "" {
+			writeHTMLField(b, "Message", html.EscapeString(drift.CommitMessage))
+		}
+		if drift.HasUncommittedChanges {
+			writeHTMLField(b, "Status", "<span class=\"reason\">has uncommitted changes</span>")
+		}
+	}
+	b.WriteString("</dl>\n")
+
+	g.writeHTMLDiff(b, res, changeDiff)
+	b.WriteString("</div>\n</details>\n")
+}
+
+// writeHTMLField writes a term and its already escaped description
+func writeHTMLField(b *strings.Builder, term, description string) {
+	b.WriteString(fmt.Sprintf("<dt>%s</dt><dd>%s</dd>\n", term, description))
+}
+
+// writeHTMLDiff writes the attribute diff of a resource as a table with the
+// before and after values side by side
+func (g *Generator) writeHTMLDiff(b *strings.Builder, res models.ResourceChange, changeDiff *diff.Node) {
+	rows := make([]*diff.Node, 0)
+	switch res.Action {
+	case models.ActionCreate, models.ActionDelete:
+		for _, node := range changeDiff.Children {
+			if !node.Ignored || g.showNoise {
+				rows = append(rows, node)
+			}
+		}
+	default:
+		for _, change := range changeDiff.Changes() {
+			if !change.Ignored || g.showNoise {
+				rows = append(rows, change)
+			}
+		}
+	}
+
+	hidden := changeDiff.IgnoredChanges()
+	if len(rows) == 0 {
+		if hidden > 0 {
+			b.WriteString(fmt.Sprintf("<p class=\"note\">%d ignored attribute change(s) hidden</p>\n", hidden))
+		} else {
+			b.WriteString("<p class=\"note\">No attribute changes detected (may be internal resource changes)</p>\n")
+		}
+		return
+	}
+
+	b.WriteString("<table class=\"diff\">\n<thead><tr><th>Attribute</th><th>Before</th><th>After</th></tr></thead>\n<tbody>\n")
+	for _, node := range rows {
+		if node.Type == diff.TypeDocument && node.Kind == diff.Modified {
+			// Embedded policy documents and manifests get a row per semantic change
+			b.WriteString(fmt.Sprintf("<tr class=\"document\"><td><code>%s</code></td><td colspan=\"2\">%s document, semantic diff</td></tr>\n",
+				html.EscapeString(node.Path), strings.ToUpper(node.Format)))
+			for _, change := range node.Changes() {
+				writeHTMLRow(b, change, "in-document")
+			}
+			continue
+		}
+		writeHTMLRow(b, node, "")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	if hidden > 0 && !g.showNoise {
+		b.WriteString(fmt.Sprintf("<p class=\"note\">%d ignored attribute change(s) hidden</p>\n", hidden))
+	}
+}
+
+// writeHTMLRow writes one attribute of a diff table
+func writeHTMLRow(b *strings.Builder, node *diff.Node, class string) {
+	classes := []string{"kind-" + strings.ToLower(kindName(node.Kind))}
+	if class != "" {
+		classes = append(classes, class)
+	}
+
+	attribute := "<code>" + html.EscapeString(node.Path) + "</code>"
+	if node.Ignored {
+		attribute += " <span class=\"tag\">ignored</span>"
+	}
+
+	before := "<pre>" + html.EscapeString(renderValue(node, false, "")) + "</pre>"
+	after := "<pre>" + html.EscapeString(renderValue(node, true, "")) + "</pre>"
+	switch node.Kind {
+	case diff.Added:
+		before = "<span class=\"absent\">not set</span>"
+	case diff.Removed:
+		after = "<span class=\"absent\">removed</span>"
+	}
+
+	b.WriteString(fmt.Sprintf("<tr class=\"%s\"><td>%s</td><td class=\"before\">%s</td><td class=\"after\">%s</td></tr>\n",
+		strings.Join(classes, " "), attribute, before, after))
+}
+
+// kindName names a diff kind for CSS classes
+func kindName(kind diff.Kind) string {
+	switch kind {
+	case diff.Added:
+		return "added"
+	case diff.Removed:
+		return "removed"
+	case diff.Modified:
+		return "modified"
+	default:
+		return "unchanged"
+	}
+}
+
+// renderValue formats the before or after value of a node. Objects and
+// lists are built from their children, so sensitive and unknown values
+// nested in them stay masked.
+func renderValue(node *diff.Node, after bool, indent string) string {
+	if node.Sensitive || (after && node.Unknown) || !node.HasChildren() || node.Type == diff.TypeDocument {
+		value := node.BeforeString()
+		if after {
+			value = node.AfterString()
+		}
+		return truncate(value, htmlValueLimit)
+	}
+
+	open, close := "{", "}"
+	if node.Type == diff.TypeList {
+		open, close = "[", "]"
+	}
+	var b strings.Builder
+	b.WriteString(open + "\n")
+	for _, child := range node.Children {
+		if (after && child.Kind == diff.Removed) || (!after && child.Kind == diff.Added) {
+			continue
+		}
+		b.WriteString(indent + "  ")
+		if node.Type == diff.TypeObject {
+			b.WriteString(child.Key + " = ")
+		}
+		b.WriteString(renderValue(child, after, indent+"  ") + "\n")
+	}
+	b.WriteString(indent + close)
+	return b.String()
+}
+
+// writeHTMLDiagnostic writes a plan error or warning
+func writeHTMLDiagnostic(b *strings.Builder, class, resource, message string) {
+	b.WriteString(fmt.Sprintf("<div class=\"diagnostic %s\">", class))
+	if resource != "" {
+		b.WriteString(fmt.Sprintf("<code>%s</code>", html.EscapeString(resource)))
+	}
+	b.WriteString(fmt.Sprintf("<pre>%s</pre></div>\n", html.EscapeString(message)))
+}
+
+// htmlStyle is the stylesheet of the HTML report, with a dark variant
+const htmlStyle = `:root {
+  --bg: #ffffff; --fg: #1f2328; --muted: #656d76; --border: #d0d7de; --panel: #f6f8fa;
+  --create: #1a7f37; --update: #9a6700; --delete: #cf222e; --replace: #0969da;
+  --added-bg: #dafbe1; --removed-bg: #ffebe9;
+}
+@media (prefers-color-scheme: dark) {
+  :root {
+    --bg: #0d1117; --fg: #e6edf3; --muted: #8d96a0; --border: #30363d; --panel: #161b22;
+    --create: #3fb950; --update: #d29922; --delete: #f85149; --replace: #58a6ff;
+    --added-bg: #12261e; --removed-bg: #25171c;
+  }
+}
+* { box-sizing: border-box; }
+body { margin: 0; font: 14px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: var(--fg); background: var(--bg); }
+code, pre { font: 12px/1.45 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+pre { margin: 0; white-space: pre-wrap; word-break: break-all; }
+#sidebar { position: fixed; top: 0; bottom: 0; left: 0; width: 320px; overflow: auto; padding: 12px; border-right: 1px solid var(--border); background: var(--panel); }
+#sidebar ul { list-style: none; margin: 0; padding: 0 0 0 16px; }
+#sidebar li { white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+#sidebar a { text-decoration: none; font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 12px; }
+#sidebar details.root > details { margin-left: 12px; }
+#sidebar summary { cursor: pointer; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+.grouping { margin-bottom: 8px; color: var(--muted); }
+main { margin-left: 320px; padding: 16px 24px; }
+header { position: sticky; top: 0; padding-bottom: 8px; background: var(--bg); border-bottom: 1px solid var(--border); z-index: 1; }
+h1 { margin: 0 0 4px; font-size: 22px; }
+h2 { font-size: 16px; }
+.meta, .note, .shown, .absent { color: var(--muted); }
+.warning { color: var(--delete); font-weight: 600; }
+.toolbar { display: flex; flex-wrap: wrap; gap: 8px; align-items: center; }
+.filter { padding: 2px 8px; border: 1px solid var(--border); border-radius: 12px; cursor: pointer; }
+#search { flex: 1; min-width: 200px; padding: 4px 8px; border: 1px solid var(--border); border-radius: 6px; background: var(--bg); color: var(--fg); }
+button { padding: 4px 10px; border: 1px solid var(--border); border-radius: 6px; background: var(--panel); color: var(--fg); cursor: pointer; }
+.create { color: var(--create); } .update { color: var(--update); } .delete { color: var(--delete); } .replace { color: var(--replace); }
+.counts { font-size: 12px; }
+.resource { margin: 8px 0; border: 1px solid var(--border); border-radius: 6px; }
+.resource > summary { padding: 6px 10px; cursor: pointer; background: var(--panel); border-radius: 6px; }
+.resource[open] > summary { border-bottom: 1px solid var(--border); border-radius: 6px 6px 0 0; }
+.body { padding: 8px 12px; }
+.badge { display: inline-block; min-width: 60px; padding: 0 6px; border: 1px solid currentColor; border-radius: 10px; font-size: 12px; text-align: center; }
+.tag { padding: 0 6px; border-radius: 10px; background: var(--border); font-size: 12px; }
+.reason { color: var(--update); font-size: 12px; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: 2px 12px; margin: 0 0 8px; }
+dt { color: var(--muted); }
+dd { margin: 0; }
+table.diff { width: 100%; table-layout: fixed; border-collapse: collapse; }
+table.diff th, table.diff td { padding: 4px 8px; border: 1px solid var(--border); text-align: left; vertical-align: top; }
+table.diff th:first-child { width: 25%; }
+table.diff td:first-child { word-break: break-all; }
+.kind-modified .before, .kind-removed .before { background: var(--removed-bg); }
+.kind-modified .after, .kind-added .after { background: var(--added-bg); }
+tr.document td { background: var(--panel); color: var(--muted); }
+tr.in-document td:first-child { padding-left: 24px; }
+.diagnostic { margin: 6px 0; padding: 6px 10px; border-left: 3px solid var(--update); background: var(--panel); }
+.diagnostic.error { border-left-color: var(--delete); }
+.empty { padding: 24px; text-align: center; color: var(--muted); }
+@media print { #sidebar, .toolbar { display: none; } main { margin-left: 0; } header { position: static; } }
+`
+
+// htmlScript implements search, filters, grouping and navigation
+const htmlScript = `(function () {
+  var resources = Array.prototype.slice.call(document.querySelectorAll('.resource'));
+  var links = Array.prototype.slice.call(document.querySelectorAll('#sidebar li[data-res]'));
+  var groups = Array.prototype.slice.call(document.querySelectorAll('#sidebar details.group'));
+  var filters = Array.prototype.slice.call(document.querySelectorAll('.filter input'));
+  var search = document.getElementById('search');
+
+  function update() {
+    var query = search.value.trim().toLowerCase();
+    var actions = {};
+    filters.forEach(function (f) { actions[f.value] = f.checked; });
+    var visible = {};
+    var count = 0;
+    resources.forEach(function (r) {
+      var show = actions[r.dataset.action] !== false && (query === '' || r.dataset.search.indexOf(query) >= 0);
+      r.hidden = !show;
+      if (show) { visible[r.id] = true; count++; }
+    });
+    links.forEach(function (l) { l.hidden = !visible[l.dataset.res]; });
+    groups.forEach(function (g) { g.hidden = !g.querySelector('li[data-res]:not([hidden])'); });
+    document.getElementById('shown').textContent = count;
+    document.getElementById('empty').hidden = count > 0 || resources.length === 0;
+  }
+
+  search.addEventListener('input', update);
+  filters.forEach(function (f) { f.addEventListener('change', update); });
+
+  Array.prototype.slice.call(document.querySelectorAll('input[name=grouping]')).forEach(function (radio) {
+    radio.addEventListener('change', function () {
+      document.getElementById('tree-module').hidden = radio.value !== 'module';
+      document.getElementById('tree-file').hidden = radio.value !== 'file';
+    });
+  });
+
+  function reveal(id) {
+    var r = document.getElementById(id);
+    if (r && r.classList.contains('resource')) { r.open = true; }
+  }
+  document.getElementById('sidebar').addEventListener('click', function (e) {
+    var link = e.target.closest('a[href^="#"]');
+    if (link) { reveal(link.getAttribute('href').slice(1)); }
+  });
+  if (location.hash) { reveal(location.hash.slice(1)); }
+
+  function setOpen(open) {
+    resources.forEach(function (r) { if (!r.hidden) { r.open = open; } });
+  }
+  document.getElementById('expand').addEventListener('click', function () { setOpen(true); });
+  document.getElementById('collapse').addEventListener('click', function () { setOpen(false); });
+
+  document.addEventListener('keydown', function (e) {
+    if (e.key === '/' && document.activeElement !== search) { e.preventDefault(); search.focus(); }
+    if (e.key === 'Escape' && document.activeElement === search) { search.value = ''; update(); search.blur(); }
+  });
+})();
+`
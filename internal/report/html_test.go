@@ -0,0 +1,133 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/diff"
+	"github.com/yourusername/tplan/internal/ignore"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestGenerateHTML(t *testing.T) {
+	injected := resource("", "", "aws_instance.web", models.ActionUpdate)
+	injected.Change.After["user_data"] = "<script>alert(1)</script>"
+	tagged := resource("envs/prod", "module.db", "module.db.aws_db_instance.main", models.ActionUpdate)
+	tagged.Change.Before["tags_all"] = map[string]interface{}{"a": "1"}
+	tagged.Change.After["tags_all"] = map[string]interface{}{"a": "2"}
+	tagged.Change.Before["size"] = tagged.Change.After["size"]
+
+	tests := []struct {
+		name      string
+		plan      *models.PlanResult
+		rules     ignore.Rules
+		showNoise bool
+		want      []string
+		notWant   []string
+	}{
+		{
+			name: "values are escaped",
+			plan: planOf(injected),
+			want: []string{"&lt;script&gt;alert(1)&lt;/script&gt;"},
+			// The report's own script is the only one
+			notWant: []string{"<script>alert"},
+		},
+		{
+			name:    "destructive resources expanded with a warning",
+			plan:    planOf(resource("", "", "aws_s3_bucket.logs", models.ActionDelete), resource("", "", "aws_instance.web", models.ActionCreate)),
+			want:    []string{"1 resource(s) will be destroyed or replaced.", `data-action="delete" data-search="aws_s3_bucket.logs aws_s3_bucket   unknown file" open>`, `data-action="create" data-search="aws_instance.web aws_instance   unknown file">`},
+			notWant: []string{"<link", "src=\"http"},
+		},
+		{
+			name:  "ignored changes hidden",
+			plan:  planOf(tagged),
+			rules: ignore.Rules{{ResourceType: "*", Attribute: "tags_all"}},
+			want:  []string{"1 ignored attribute change(s) hidden", `<span class="tag" title="every change matches an ignore rule">noise</span>`, `<details class="group root" open><summary>envs/prod`},
+		},
+		{
+			name:      "ignored changes shown",
+			plan:      planOf(tagged),
+			rules:     ignore.Rules{{ResourceType: "*", Attribute: "tags_all"}},
+			showNoise: true,
+			want:      []string{`<code>tags_all.a</code> <span class="tag">ignored</span>`},
+			notWant:   []string{"hidden</p>"},
+		},
+		{
+			name: "no changes",
+			plan: planOf(),
+			want: []string{"No changes. Infrastructure matches the configuration."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewGenerator(tt.plan, false).WithIgnoreRules(tt.rules, tt.showNoise).GenerateHTML()
+			for _, want := range tt.want {
+				if !strings.Contains(page, want) {
+					t.Errorf("page does not contain %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(page, notWant) {
+					t.Errorf("page contains %q", notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLGroups(t *testing.T) {
+	resources := make([]*htmlResource, 0)
+	for _, res := range []models.ResourceChange{
+		resource("", "module.b", "module.b.aws_instance.z", models.ActionCreate),
+		resource("", "module.a", "module.a.aws_instance.y", models.ActionCreate),
+		resource("", "", "aws_instance.x", models.ActionCreate),
+		resource("", "module.b", "module.b.aws_instance.a", models.ActionCreate),
+	} {
+		resources = append(resources, &htmlResource{res: res})
+	}
+
+	groups := htmlGroups(resources, func(r *htmlResource) string {
+		if r.res.Module == "" {
+			return "root module"
+		}
+		return r.res.Module
+	})
+	got := make([]string, 0)
+	for _, group := range groups {
+		addresses := make([]string, 0)
+		for _, r := range group.resources {
+			addresses = append(addresses, r.res.Address)
+		}
+		got = append(got, group.label+": "+strings.Join(addresses, ", "))
+	}
+	want := []string{"root module: aws_instance.x", "module.a: module.a.aws_instance.y", "module.b: module.b.aws_instance.a, module.b.aws_instance.z"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("groups:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRenderValue(t *testing.T) {
+	root := diff.Compute(models.Change{
+		Before:         map[string]interface{}{"config": map[string]interface{}{"token": "a", "region": "eu"}},
+		After:          map[string]interface{}{"config": map[string]interface{}{"token": "b", "region": "eu", "zone": "1a"}},
+		AfterSensitive: map[string]interface{}{"config": map[string]interface{}{"token": true}},
+	})
+	config := root.Children[0]
+
+	tests := []struct {
+		name  string
+		after bool
+		want  string
+	}{
+		// A value sensitive on either side is masked on both
+		{"before", false, "{\n  region = \"eu\"\n  token = (sensitive value)\n}"},
+		{"after", true, "{\n  region = \"eu\"\n  token = (sensitive value)\n  zone = \"1a\"\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderValue(config, tt.after, ""); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
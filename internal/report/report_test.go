@@ -0,0 +1,51 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// sensitivePolicyPlan updates a sensitive attribute that holds a JSON policy
+func sensitivePolicyPlan() *models.PlanResult {
+	sensitive := map[string]interface{}{"policy": true}
+	return &models.PlanResult{
+		Resources: []models.ResourceChange{{
+			Address: "aws_iam_policy.secret",
+			Type:    "aws_iam_policy",
+			Name:    "secret",
+			Action:  models.ActionUpdate,
+			Change: models.Change{
+				Before:          map[string]interface{}{"policy": `{"Statement": [{"Sid": "A", "Effect": "Allow"}]}`},
+				After:           map[string]interface{}{"policy": `{"Statement": [{"Sid": "A", "Effect": "Deny"}]}`},
+				BeforeSensitive: sensitive,
+				AfterSensitive:  sensitive,
+			},
+		}},
+		Summary: models.PlanSummary{ToUpdate: 1, Total: 1},
+	}
+}
+
+func TestSensitiveDocumentReports(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func(*Generator) string
+	}{
+		{"markdown", (*Generator).GenerateMarkdown},
+		{"html", (*Generator).GenerateHTML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := tt.render(NewGenerator(sensitivePolicyPlan(), false))
+			for _, leak := range []string{"Allow", "Deny", "Statement", "( document)", "```diff"} {
+				if strings.Contains(content, leak) {
+					t.Errorf("report contains %q", leak)
+				}
+			}
+			if !strings.Contains(content, "(sensitive value)") {
+				t.Error("report does not show the masked value")
+			}
+		})
+	}
+}
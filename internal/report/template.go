@@ -0,0 +1,263 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yourusername/tplan/internal/diff"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// defaultTemplate is the source of the built-in Markdown report
+//
+//go:embed templates/markdown.tmpl
+var defaultTemplate string
+
+// markdownTemplate is the parsed built-in Markdown report
+var markdownTemplate = template.Must(ParseTemplate("markdown.tmpl", defaultTemplate))
+
+// DefaultTemplate returns the source of the built-in Markdown report, as a
+// starting point for custom templates
+func DefaultTemplate() string {
+	return defaultTemplate
+}
+
+// TemplateData is the data report templates are executed with
+type TemplateData struct {
+	// Generated is when the report was rendered
+	Generated time.Time
+
+	TerraformVersion string
+	Summary          models.PlanSummary
+
+	// Roots has the counts per root module of a multi-root plan; it is empty
+	// for single-root plans
+	Roots []RootSummary
+
+	// Resources are the changing resources in plan order
+	Resources []*TemplateResource
+
+	Outputs  []models.OutputChange
+	Errors   []models.PlanError
+	Warnings []models.PlanWarning
+
+	// IncludeDrift is set when the report should show git information
+	IncludeDrift bool
+
+	// ShowNoise is set when changes matching ignore rules are shown
+	ShowNoise bool
+}
+
+// RootSummary is the action counts of one root module
+type RootSummary struct {
+	Root string
+	models.PlanSummary
+}
+
+// TemplateResource is a changing resource with its attribute diff. The
+// fields of models.ResourceChange (Address, Type, Action, DriftInfo, ...)
+// are available directly.
+type TemplateResource struct {
+	models.ResourceChange
+
+	// Diff is the full attribute diff, with ignore rules applied
+	Diff *diff.Node
+
+	// Changes are the changed attributes at the finest useful granularity.
+	// Changes matching ignore rules are left out unless ShowNoise is set.
+	Changes []*diff.Node
+
+	// Attributes are the top-level attributes, e.g. of created or deleted
+	// resources, with ignored ones left out unless ShowNoise is set
+	Attributes []*diff.Node
+
+	// HiddenChanges is the number of ignored changes left out
+	HiddenChanges int
+
+	// NoiseOnly is set when every change matches an ignore rule
+	NoiseOnly bool
+}
+
+// templateActions are the actions reports list, in report order
+var templateActions = []models.ChangeAction{
+	models.ActionCreate,
+	models.ActionUpdate,
+	models.ActionDelete,
+	models.ActionReplace,
+}
+
+// ParseTemplate parses a report template with the report helper functions
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs(false)).Parse(text)
+}
+
+// LoadTemplate reads and parses a report template file
+func LoadTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	tmpl, err := ParseTemplate(filepath.Base(path), string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// templateFuncs returns the helper functions available in templates
+func templateFuncs(showNoise bool) template.FuncMap {
+	return template.FuncMap{
+		// actions lists create, update, delete and replace
+		"actions": func() []models.ChangeAction { return templateActions },
+
+		// byAction returns the resources with an action
+		"byAction": func(action models.ChangeAction, resources []*TemplateResource) []*TemplateResource {
+			filtered := make([]*TemplateResource, 0)
+			for _, res := range resources {
+				if res.Action == action {
+					filtered = append(filtered, res)
+				}
+			}
+			return filtered
+		},
+
+		// icon returns the emoji of an action, title its capitalized name
+		"icon":  func(action models.ChangeAction) string { return actionEmoji[action] },
+		"title": actionTitle,
+
+		// before and after return the display form of a value, with
+		// sensitive values masked and unknown values marked
+		"before": (*diff.Node).BeforeString,
+		"after":  (*diff.Node).AfterString,
+
+		// isAdded, isRemoved and isDocument tell how an attribute changed;
+		// isDocument is true for modified JSON and YAML documents
+		"isAdded":   func(node *diff.Node) bool { return node.Kind == diff.Added },
+		"isRemoved": func(node *diff.Node) bool { return node.Kind == diff.Removed },
+		"isDocument": func(node *diff.Node) bool {
+			return node.Type == diff.TypeDocument && node.Kind == diff.Modified
+		},
+
+		// documentDiff renders the semantic changes of a document in
+		// unified-diff style, one line per value
+		"documentDiff": func(node *diff.Node) string {
+			var b strings.Builder
+			for _, child := range node.Children {
+				writeDocumentDiff(child, &b, "", showNoise)
+			}
+			return b.String()
+		},
+
+		// truncate shortens a string to n bytes, cell escapes it for a Markdown table
+		"truncate": func(n int, s string) string { return truncate(s, n) },
+		"cell":     escapeTableCell,
+
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"join":  func(sep string, elems []string) string { return strings.Join(elems, sep) },
+		"add":   func(a, b int) int { return a + b },
+	}
+}
+
+// actionTitle returns the capitalized name of an action, e.g. "Create"
+func actionTitle(action models.ChangeAction) string {
+	name := string(action)
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// GenerateTemplate renders the report with a template parsed by
+// ParseTemplate or LoadTemplate
+func (g *Generator) GenerateTemplate(tmpl *template.Template) (string, error) {
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl.Funcs(templateFuncs(g.showNoise))
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, g.templateData()); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// templateData collects the data templates are executed with
+func (g *Generator) templateData() *TemplateData {
+	data := &TemplateData{
+		Generated:        time.Now(),
+		TerraformVersion: g.plan.TerraformVersion,
+		Summary:          g.plan.Summary,
+		Outputs:          g.plan.OutputChanges,
+		Errors:           g.plan.Errors,
+		Warnings:         g.plan.Warnings,
+		IncludeDrift:     g.includeDrift,
+		ShowNoise:        g.showNoise,
+	}
+	if len(g.plan.Roots) > 1 {
+		data.Roots = g.rootSummaries()
+	}
+
+	for _, res := range g.plan.Resources {
+		if res.Action == models.ActionNoOp {
+			continue
+		}
+		changeDiff := g.computeDiff(res)
+		resource := &TemplateResource{
+			ResourceChange: res,
+			Diff:           changeDiff,
+			Changes:        make([]*diff.Node, 0),
+			Attributes:     make([]*diff.Node, 0),
+			NoiseOnly:      changeDiff.NoiseOnly(),
+		}
+		for _, change := range changeDiff.Changes() {
+			if !change.Ignored || g.showNoise {
+				resource.Changes = append(resource.Changes, change)
+			}
+		}
+		for _, node := range changeDiff.Children {
+			if !node.Ignored || g.showNoise {
+				resource.Attributes = append(resource.Attributes, node)
+			}
+		}
+		if !g.showNoise {
+			resource.HiddenChanges = changeDiff.IgnoredChanges()
+		}
+		data.Resources = append(data.Resources, resource)
+	}
+	return data
+}
+
+// rootSummaries counts the actions per root module
+func (g *Generator) rootSummaries() []RootSummary {
+	summaries := make([]RootSummary, len(g.plan.Roots))
+	index := make(map[string]*RootSummary)
+	for i, root := range g.plan.Roots {
+		summaries[i].Root = root
+		index[root] = &summaries[i]
+	}
+	for _, res := range g.plan.Resources {
+		summary, ok := index[res.Root]
+		if !ok {
+			continue
+		}
+		switch res.Action {
+		case models.ActionCreate:
+			summary.ToCreate++
+		case models.ActionUpdate:
+			summary.ToUpdate++
+		case models.ActionDelete:
+			summary.ToDelete++
+		case models.ActionReplace:
+			summary.ToReplace++
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,160 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/ignore"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestGenerateTemplate(t *testing.T) {
+	policy := resource("", "", "aws_iam_policy.deploy", models.ActionUpdate)
+	policy.Change.Before["policy"] = `{"Statement": [{"Sid": "A", "Effect": "Allow"}]}`
+	policy.Change.After["policy"] = `{"Statement": [{"Sid": "A", "Effect": "Deny"}]}`
+	noisy := resource("", "", "aws_s3_bucket.logs", models.ActionUpdate)
+	noisy.Change.Before = map[string]interface{}{"tags_all": "a"}
+	noisy.Change.After = map[string]interface{}{"tags_all": "b"}
+	multiRoot := planOf(
+		resource("network", "", "aws_vpc.main", models.ActionCreate),
+		resource("app", "", "aws_instance.web", models.ActionDelete),
+		resource("app", "", "aws_instance.api", models.ActionDelete),
+	)
+	multiRoot.Roots = []string{"network", "app"}
+	rules := ignore.Rules{{ResourceType: "*", Attribute: "tags_all"}}
+
+	tests := []struct {
+		name      string
+		template  string
+		plan      *models.PlanResult
+		showNoise bool
+		want      string
+	}{
+		{
+			name:     "resources by action",
+			template: `{{range actions}}{{$action := .}}{{range byAction . $.Resources}}{{icon $action}} {{title $action}} {{.Address}}{{"\n"}}{{end}}{{end}}`,
+			plan:     planOf(resource("", "", "aws_instance.web", models.ActionDelete), resource("", "", "aws_instance.api", models.ActionCreate)),
+			want:     "🟢 Create aws_instance.api\n🔴 Delete aws_instance.web\n",
+		},
+		{
+			name:     "changes with a document diff",
+			template: `{{range .Resources}}{{range .Changes}}{{.Path}} {{if isDocument .}}{{upper .Format}}{{"\n"}}{{documentDiff .}}{{else}}{{before .}} -> {{after .}}{{"\n"}}{{end}}{{end}}{{end}}`,
+			plan:     planOf(policy),
+			want:     "policy JSON\n~ Statement [\n~   [Sid=A] {\n-     Effect = \"Allow\"\n+     Effect = \"Deny\"\n~   }\n~ ]\nsize \"small\" -> \"large\"\n",
+		},
+		{
+			name:     "noise hidden",
+			template: `{{range .Resources}}{{.Address}} {{len .Changes}} {{.HiddenChanges}} {{.NoiseOnly}}{{end}}`,
+			plan:     planOf(noisy),
+			want:     "aws_s3_bucket.logs 0 1 true",
+		},
+		{
+			name:      "noise shown",
+			template:  `{{range .Resources}}{{.Address}} {{len .Changes}} {{.HiddenChanges}} {{.NoiseOnly}}{{end}}`,
+			plan:      planOf(noisy),
+			showNoise: true,
+			want:      "aws_s3_bucket.logs 1 0 true",
+		},
+		{
+			name:     "root summaries",
+			template: `{{range .Roots}}{{.Root}} +{{.ToCreate}} -{{.ToDelete}}{{"\n"}}{{end}}`,
+			plan:     multiRoot,
+			want:     "network +1 -0\napp +0 -2\n",
+		},
+		{
+			name:     "helpers",
+			template: `{{truncate 8 "abcdefghijkl"}} {{cell "a|b"}} {{lower "ABC"}} {{join "," (index .Resources 0).Dependencies}} {{add 1 2}}`,
+			plan: func() *models.PlanResult {
+				res := resource("", "", "aws_instance.web", models.ActionCreate)
+				res.Dependencies = []string{"a", "b"}
+				return planOf(res)
+			}(),
+			want: `abcde... a\|b abc a,b 3`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.name, tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewGenerator(tt.plan, false).WithIgnoreRules(rules, tt.showNoise).GenerateTemplate(tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	plan := planOf(
+		resource("", "", "aws_instance.web", models.ActionCreate),
+		resource("", "module.db", "module.db.aws_db_instance.main", models.ActionReplace),
+	)
+	plan.TerraformVersion = "1.6.0"
+	plan.Warnings = []models.PlanWarning{{Message: "Deprecated attribute"}}
+
+	report := NewGenerator(plan, false).GenerateMarkdown()
+	for _, want := range []string{
+		"**Terraform Version:** 1.6.0",
+		"| 🟢 Create | 1 |",
+		"| ⚠️ Warnings | 1 |",
+		"- [Resources to Create](#resources-to-create)",
+		"- [Resources to Replace](#resources-to-replace)",
+		"### 1. aws_instance.web",
+		"- **Module:** `module.db`",
+		"| `size` | `\"small\"` | `\"large\"` |",
+		"size = \"small\"",
+		"Deprecated attribute",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+	if strings.Contains(report, "failed to render report") || strings.Contains(report, "<no value>") {
+		t.Errorf("the built-in template failed:\n%s", report)
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"valid", write("ok.tmpl", "{{len .Resources}} changes"), ""},
+		{"unknown function", write("bad.tmpl", "{{shout .Summary}}"), "invalid template"},
+		{"missing file", filepath.Join(dir, "missing.tmpl"), "failed to read template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := LoadTemplate(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tmpl.Name() != "ok.tmpl" {
+				t.Errorf("template name %q, want the file name", tmpl.Name())
+			}
+		})
+	}
+}
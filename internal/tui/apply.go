@@ -0,0 +1,318 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/tplan/internal/apply"
+	"github.com/yourusername/tplan/internal/models"
+	"github.com/yourusername/tplan/internal/report"
+)
+
+// ApplyFunc runs the apply, calling send for every event terraform emits
+type ApplyFunc func(send func(apply.Event)) error
+
+// Messages delivering apply progress to the model
+type (
+	applyEventMsg apply.Event
+	applyDoneMsg  struct{ err error }
+	applyTickMsg  time.Time
+)
+
+// startApply runs the apply in the background and switches the tree to
+// showing the progress of every resource
+func (m Model) startApply() (tea.Model, tea.Cmd) {
+	events := make(chan tea.Msg, 64)
+	run := m.applyFn
+	go func() {
+		err := run(func(event apply.Event) {
+			events <- applyEventMsg(event)
+		})
+		events <- applyDoneMsg{err: err}
+		close(events)
+	}()
+
+	m.confirming = false
+	m.applying = true
+	m.applyEvents = events
+	m.result = apply.NewResult(m.plan)
+	m.result.Started = time.Now()
+	m = m.layout()
+	return m, tea.Batch(waitForApply(events), applyTick())
+}
+
+// waitForApply waits for the next apply event
+func waitForApply(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// applyTick refreshes the elapsed times while the apply runs
+func applyTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return applyTickMsg(t)
+	})
+}
+
+// updateApply handles apply progress messages
+func (m Model) updateApply(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case applyEventMsg:
+		m.result.Apply(apply.Event(msg), time.Now())
+		return m, waitForApply(m.applyEvents)
+
+	case applyDoneMsg:
+		m.result.Finish(msg.err, time.Now())
+		m.applying = false
+		m.showApplySummary = true
+		return m, nil
+
+	case applyTickMsg:
+		if m.applying {
+			return m, applyTick()
+		}
+	}
+	return m, nil
+}
+
+// updateApplySummary handles keys on the apply summary screen
+func (m Model) updateApplySummary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	k := m.keys
+	switch {
+	case key.Matches(msg, k.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, k.Back, k.ApplySummary):
+		m.showApplySummary = false
+	case key.Matches(msg, k.NextFailure):
+		m.showApplySummary = false
+		m = m.jumpToFailure()
+	case key.Matches(msg, k.ExportReport):
+		path := m.applyReportPath
+		if path == "" {
+			path = report.DefaultApplyReportPath
+		}
+		if err := report.WriteApplyReport(path, m.result); err != nil {
+			m.notice = fmt.Sprintf("Failed to write apply report: %v", err)
+		} else {
+			m.notice = "Apply report written to " + path
+		}
+	}
+	return m, nil
+}
+
+// jumpToFailure moves the cursor to the next failed resource after the
+// cursor, so its diagnostics are shown in the detail pane
+func (m Model) jumpToFailure() Model {
+	if m.result == nil {
+		return m
+	}
+
+	var failed []*TreeNode
+	for _, node := range m.nodes {
+		node.walk(func(n *TreeNode) {
+			if n.IsGroup() {
+				return
+			}
+			if state := m.result.State(n.Resource.Root, n.Resource.Address); state != nil && state.Status == apply.StatusFailed {
+				failed = append(failed, n)
+			}
+		})
+	}
+	if len(failed) == 0 {
+		m.notice = "No failed resources"
+		return m
+	}
+
+	// Continue after the current failure, wrapping around
+	target := failed[0]
+	visible := m.getVisibleNodes()
+	if m.cursor < len(visible) {
+		for i, node := range failed {
+			if node == visible[m.cursor] && i+1 < len(failed) {
+				target = failed[i+1]
+			}
+		}
+	}
+
+	for parent := target.Parent; parent != nil; parent = parent.Parent {
+		parent.Expanded = true
+	}
+	m.viewMode = ViewChanges
+	m.cursor = m.indexOfVisible(target)
+	return m.adjustViewport()
+}
+
+// renderApplyStatus renders the status of a resource in the apply
+func (m Model) renderApplyStatus(res models.ResourceChange) string {
+	if m.result == nil {
+		return ""
+	}
+	state := m.result.State(res.Root, res.Address)
+	if state == nil {
+		return ""
+	}
+
+	switch state.Status {
+	case apply.StatusApplying:
+		return applyingStyle.Render(fmt.Sprintf("  ⟳ applying %s", formatElapsed(state.Elapsed(time.Now()))))
+	case apply.StatusApplied:
+		return appliedStyle.Render(fmt.Sprintf("  ✓ done %s", formatElapsed(state.Elapsed(time.Now()))))
+	case apply.StatusFailed:
+		return failedStyle.Render("  ✖ failed")
+	default:
+		if m.applying {
+			return pendingStyle.Render("  ◌ pending")
+		}
+		return pendingStyle.Render("  ◌ not applied")
+	}
+}
+
+// renderApplyDiagnostics renders the diagnostics terraform reported for a resource
+func (m Model) renderApplyDiagnostics(res models.ResourceChange, indent string) string {
+	if m.result == nil {
+		return ""
+	}
+	state := m.result.State(res.Root, res.Address)
+	if state == nil || len(state.Diagnostics) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s%s\n", indent, failedStyle.Render("Apply Errors:")))
+	for _, diag := range state.Diagnostics {
+		writeDiagnostic(&b, indent+"  ", diag)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderApplyProgress renders the progress bar shown above the tree during and after an apply
+func (m Model) renderApplyProgress() string {
+	applied, failed, pending := m.result.Counts()
+	total := applied + failed + pending
+
+	elapsed := time.Since(m.result.Started)
+	if m.result.Done() {
+		elapsed = m.result.Finished.Sub(m.result.Started)
+	}
+
+	status := applyingStyle.Render("Applying…")
+	switch {
+	case m.applying:
+	case m.result.Succeeded():
+		status = appliedStyle.Render("✓ Apply complete")
+	default:
+		status = failedStyle.Render("✖ Apply failed")
+	}
+
+	line := fmt.Sprintf("%s  %d/%d done", status, applied, total)
+	if failed > 0 {
+		line += "  " + failedStyle.Render(fmt.Sprintf("%d failed", failed))
+	}
+	return line + "  " + pendingStyle.Render(formatElapsed(elapsed))
+}
+
+// renderApplySummary renders the summary shown when the apply has finished
+func (m Model) renderApplySummary() string {
+	var b strings.Builder
+	applied, failed, pending := m.result.Counts()
+
+	if m.result.Succeeded() {
+		b.WriteString(appliedStyle.Copy().Bold(true).Render("✓ Apply complete"))
+	} else {
+		b.WriteString(failedStyle.Render("✖ Apply failed"))
+	}
+	b.WriteString(pendingStyle.Render(fmt.Sprintf("  (%s)", formatElapsed(m.result.Finished.Sub(m.result.Started)))))
+	b.WriteString("\n\n")
+
+	b.WriteString(fmt.Sprintf("%s %d  %s %d  %s %d\n",
+		appliedStyle.Render("✓ Applied:"), applied,
+		failedStyle.Render("✖ Failed:"), failed,
+		pendingStyle.Render("◌ Not applied:"), pending))
+	for _, line := range m.result.Summary {
+		b.WriteString(line + "\n")
+	}
+
+	if m.result.Err != nil {
+		b.WriteString("\n")
+		b.WriteString(failedStyle.Render(m.result.Err.Error()))
+		b.WriteString("\n")
+	}
+
+	if failed > 0 {
+		b.WriteString("\n")
+		b.WriteString(failedStyle.Render("Failed:"))
+		b.WriteString("\n")
+		for _, state := range m.result.Resources {
+			if state.Status != apply.StatusFailed {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("  ✖ %s\n", applyAddress(state)))
+			for _, diag := range state.Diagnostics {
+				b.WriteString(fmt.Sprintf("      %s\n", diag.Summary))
+			}
+		}
+	}
+
+	for _, diag := range m.result.Diagnostics {
+		b.WriteString("\n")
+		writeDiagnostic(&b, "", diag)
+	}
+
+	if applied > 0 {
+		b.WriteString("\n")
+		b.WriteString(appliedStyle.Render("Applied:"))
+		b.WriteString("\n")
+		for _, state := range m.result.Resources {
+			if state.Status == apply.StatusApplied {
+				b.WriteString(fmt.Sprintf("  ✓ %s %s\n", applyAddress(state),
+					pendingStyle.Render(formatElapsed(state.Elapsed(m.result.Finished)))))
+			}
+		}
+	}
+
+	b.WriteString("\n")
+	k := m.keys
+	help := helpLine(entry("Export Report", k.ExportReport), entry("Back to Tree", k.Back), entry("Help", k.Help), entry("Quit", k.Quit))
+	if failed > 0 {
+		help = helpLine(entry("Show Failures", k.NextFailure)) + "  " + help
+	}
+	b.WriteString(helpStyle.Render(help))
+
+	return applyPanelStyle.Render(b.String())
+}
+
+// writeDiagnostic writes a diagnostic summary and its detail lines
+func writeDiagnostic(b *strings.Builder, indent string, diag apply.Diagnostic) {
+	style := failedStyle
+	if diag.Severity == "warning" {
+		style = applyingStyle
+	}
+	b.WriteString(fmt.Sprintf("%s%s\n", indent, style.Render(diag.Summary)))
+	for _, line := range strings.Split(strings.TrimSpace(diag.Detail), "\n") {
+		if line != "" {
+			b.WriteString(fmt.Sprintf("%s  %s\n", indent, attributeStyle.Render(line)))
+		}
+	}
+}
+
+// applyAddress returns the resource address, prefixed with its root in multi-root applies
+func applyAddress(state *apply.ResourceState) string {
+	if state.Root != "" {
+		return state.Root + ": " + state.Address
+	}
+	return state.Address
+}
+
+// formatElapsed formats a duration in whole seconds
+func formatElapsed(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbletea"
+)
+
+// updateConfirm handles keys while the apply confirmation is shown. Plans
+// without destructive changes are confirmed with y or Enter; destructive
+// plans require typing the confirmation word after every deleted or
+// replaced resource has been shown.
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+
+	// The list as rendered before this key counts as shown
+	if m.confirmTop+m.confirmRows() >= len(m.destructive) {
+		m.confirmSeen = true
+	}
+
+	// While the confirmation word is typed, keys that type a character are
+	// part of the word rather than commands
+	typing := len(m.destructive) > 0
+	confirm, cancel := m.keys.Confirm, m.keys.Cancel
+	if typing {
+		confirm, cancel = commandKeys(confirm), commandKeys(cancel)
+	}
+
+	switch {
+	case key.Matches(msg, cancel):
+		m.confirming = false
+		m.confirmInput = ""
+		m.confirmError = ""
+
+	case key.Matches(msg, confirm):
+		switch {
+		case !typing:
+			return m.confirmed()
+		case !m.confirmSeen:
+			m.confirmError = "scroll to the end of the list first"
+		case m.confirmInput != m.confirmWord:
+			m.confirmError = "does not match"
+		default:
+			return m.confirmed()
+		}
+
+	case !typing:
+		// Other keys only edit the confirmation word
+
+	case key.Matches(msg, commandKeys(m.keys.ScrollUp)):
+		m = m.scrollConfirm(-1)
+	case key.Matches(msg, commandKeys(m.keys.ScrollDown)):
+		m = m.scrollConfirm(1)
+	case key.Matches(msg, commandKeys(m.keys.PageUp)):
+		m = m.scrollConfirm(-m.confirmRows())
+	case key.Matches(msg, commandKeys(m.keys.PageDown)):
+		m = m.scrollConfirm(m.confirmRows())
+
+	case msg.Type == tea.KeyBackspace:
+		m.confirmError = ""
+		if len(m.confirmInput) > 0 {
+			runes := []rune(m.confirmInput)
+			m.confirmInput = string(runes[:len(runes)-1])
+		}
+
+	case msg.Type == tea.KeyRunes, msg.Type == tea.KeySpace:
+		m.confirmError = ""
+		m.confirmInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// confirmRows returns how many destructive resources fit the confirmation,
+// leaving room for the counts, the position, the prompt and the border
+func (m Model) confirmRows() int {
+	rows := m.contentHeight - 9
+	if rows < 3 {
+		rows = 3
+	}
+	return rows
+}
+
+// scrollConfirm moves the list of destructive resources by delta lines
+func (m Model) scrollConfirm(delta int) Model {
+	m.confirmTop += delta
+	if maxTop := len(m.destructive) - m.confirmRows(); m.confirmTop > maxTop {
+		m.confirmTop = maxTop
+	}
+	if m.confirmTop < 0 {
+		m.confirmTop = 0
+	}
+	return m
+}
+
+// confirmed starts the apply inside the TUI, or quits so the caller applies
+func (m Model) confirmed() (tea.Model, tea.Cmd) {
+	if m.applyFn != nil {
+		return m.startApply()
+	}
+	m.shouldApply = true
+	return m, tea.Quit
+}
+
+// renderConfirm renders the apply confirmation with the change counts and
+// a scrollable list of every resource that will be deleted or replaced
+func (m Model) renderConfirm() string {
+	var b strings.Builder
+
+	b.WriteString(confirmTitleStyle.Render("Apply this plan?"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("%s %d  %s %d  %s %d  %s %d\n",
+		createStyle.Render("✚ Create:"), m.plan.Summary.ToCreate,
+		updateStyle.Render("~ Update:"), m.plan.Summary.ToUpdate,
+		deleteStyle.Render("✖ Delete:"), m.plan.Summary.ToDelete,
+		replaceStyle.Render("⟳ Replace:"), m.plan.Summary.ToReplace))
+
+	if len(m.destructive) > 0 {
+		b.WriteString("\n")
+		b.WriteString(deleteStyle.Render("The following resources will be destroyed:"))
+		b.WriteString("\n")
+
+		rows := m.confirmRows()
+		end := m.confirmTop + rows
+		if end > len(m.destructive) {
+			end = len(m.destructive)
+		}
+		for _, res := range m.destructive[m.confirmTop:end] {
+			address := res.Address
+			if res.Root != "" {
+				address = res.Root + ": " + address
+			}
+			icon, style := getActionIconAndStyle(string(res.Action))
+			b.WriteString("  " + style.Render(icon+" "+address) + "\n")
+		}
+		if len(m.destructive) > rows {
+			position := fmt.Sprintf("  %d–%d of %d  ", m.confirmTop+1, end, len(m.destructive))
+			scroll := helpLine(entry("Scroll", commandKeys(m.keys.ScrollUp), commandKeys(m.keys.ScrollDown), commandKeys(m.keys.PageUp), commandKeys(m.keys.PageDown)))
+			b.WriteString(helpStyle.Render(position+scroll) + "\n")
+		}
+
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Type %s to confirm: ", confirmTitleStyle.Render(m.confirmWord)))
+		b.WriteString(confirmInputStyle.Render(m.confirmInput + "█"))
+		if m.confirmError != "" {
+			b.WriteString("  " + deleteStyle.Render(m.confirmError))
+		}
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(confirmHelp(commandKeys(m.keys.Confirm), commandKeys(m.keys.Cancel))))
+	} else {
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(confirmHelp(m.keys.Confirm, m.keys.Cancel)))
+	}
+
+	return confirmStyle.Render(b.String())
+}
+
+// confirmHelp renders the help line of the confirmation with all keys of
+// its bindings, e.g. "y/Enter: Apply  n/q/Esc: Cancel"
+func confirmHelp(confirm, cancel key.Binding) string {
+	items := make([]string, 0, 2)
+	if confirm.Enabled() {
+		items = append(items, confirm.Help().Key+": Apply")
+	}
+	if cancel.Enabled() {
+		items = append(items, cancel.Help().Key+": Cancel")
+	}
+	return strings.Join(items, "  ")
+}
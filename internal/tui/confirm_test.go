@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// press sends key messages to the model in order
+func press(m Model, keys ...tea.KeyMsg) Model {
+	for _, k := range keys {
+		next, _ := m.Update(k)
+		m = next.(Model)
+	}
+	return m
+}
+
+// typed returns the key messages typing text
+func typed(text string) []tea.KeyMsg {
+	keys := make([]tea.KeyMsg, 0, len(text))
+	for _, r := range text {
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return keys
+}
+
+func TestConfirmShowsEveryDestructiveResource(t *testing.T) {
+	plan := &models.PlanResult{}
+	for i := 0; i < 30; i++ {
+		plan.Resources = append(plan.Resources, models.ResourceChange{
+			Address: fmt.Sprintf("aws_instance.web[%d]", i),
+			Type:    "aws_instance",
+			Action:  models.ActionDelete,
+		})
+	}
+	plan.Summary = models.PlanSummary{ToDelete: 30, Total: 30}
+
+	m := NewModel(plan, Options{PlanFile: "plan.tfplan"})
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = press(next.(Model), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	if !m.confirming {
+		t.Fatal("apply key did not open the confirmation")
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "aws_instance.web[0]") || strings.Contains(view, "aws_instance.web[29]") {
+		t.Fatalf("first page does not start the list:\n%s", view)
+	}
+	if !strings.Contains(view, fmt.Sprintf("1–%d of 30", m.confirmRows())) {
+		t.Errorf("position is not shown:\n%s", view)
+	}
+
+	m = press(m, append(typed("yes"), tea.KeyMsg{Type: tea.KeyEnter})...)
+	if m.shouldApply || !strings.Contains(m.View(), "scroll to the end of the list first") {
+		t.Fatal("confirmed before every resource was shown")
+	}
+
+	for i := 0; i < 30 && !strings.Contains(m.View(), "aws_instance.web[29]"); i++ {
+		m = press(m, tea.KeyMsg{Type: tea.KeyPgDown})
+	}
+	if !strings.Contains(m.View(), "aws_instance.web[29]") {
+		t.Fatal("the end of the list is never shown")
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.shouldApply {
+		t.Errorf("not confirmed after the whole list was shown: %q", m.confirmError)
+	}
+}
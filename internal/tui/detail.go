@@ -0,0 +1,347 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sideBySideWidth is the window width from which the detail pane is shown
+// next to the tree rather than below it
+const sideBySideWidth = 120
+
+// minPaneWidth is the narrowest a pane can be dragged side by side
+const minPaneWidth = 20
+
+// ansiPattern matches the escape sequences styles add to rendered text
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// layout sizes the tree and the detail pane to the window: side by side on
+// wide windows, the details below the tree otherwise
+func (m Model) layout() Model {
+	// Leave room for the header, summary, tabs and help
+	m.contentHeight = m.height - 10 - m.headerExtraLines()
+	if m.contentHeight < 6 {
+		m.contentHeight = 6
+	}
+
+	m.sideBySide = m.width >= sideBySideWidth
+	treeHeight := m.contentHeight
+	if m.sideBySide {
+		m.treeWidth = m.width * 2 / 5
+		if m.splitWidth > 0 {
+			m.treeWidth = clamp(m.splitWidth, minPaneWidth, m.width-minPaneWidth-3)
+		}
+		m.detailWidth = m.width - m.treeWidth - 3 // " │ " between the panes
+		m.detailHeight = m.contentHeight
+	} else {
+		treeHeight = m.contentHeight * 2 / 5
+		if m.splitHeight > 0 {
+			treeHeight = clamp(m.splitHeight, 3, m.contentHeight-3)
+		}
+		if treeHeight < 3 {
+			treeHeight = 3
+		}
+		m.treeWidth = m.width
+		m.detailWidth = m.width
+		m.detailHeight = m.contentHeight - treeHeight
+	}
+
+	// Both panes start with a title line
+	m.viewportSize = treeHeight - 1
+	m = m.adjustViewport()
+	return m.scrollDetail(0)
+}
+
+// selectedNode returns the node under the cursor, or nil if there is none
+func (m Model) selectedNode() *TreeNode {
+	visibleNodes := m.getVisibleNodes()
+	if m.cursor < 0 || m.cursor >= len(visibleNodes) {
+		return nil
+	}
+	return visibleNodes[m.cursor]
+}
+
+// syncDetail shows the top of the details when the selection changed
+func (m Model) syncDetail() Model {
+	if node := m.selectedNode(); node != m.detailNode {
+		m.detailNode = node
+		m.detailTop = 0
+		m.matchLine = -1
+	}
+	return m
+}
+
+// detailLines renders the details of the selected node, one entry per line
+func (m Model) detailLines() []string {
+	node := m.selectedNode()
+	if node == nil {
+		return nil
+	}
+
+	var content string
+	if node.IsGroup() {
+		content = m.renderGroupDetails(node)
+	} else {
+		content = m.renderDetails(node.Resource, "")
+	}
+	return strings.Split(strings.TrimRight(content, "\n"), "\n")
+}
+
+// renderGroupDetails renders the action counts and resources of a group
+func (m Model) renderGroupDetails(node *TreeNode) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d resources%s\n\n", node.ResourceCount(), m.renderGroupCounts(node.Counts(), lipgloss.NewStyle())))
+	node.walk(func(n *TreeNode) {
+		if n.IsGroup() {
+			return
+		}
+		icon, style := getActionIconAndStyle(string(n.Resource.Action))
+		b.WriteString(style.Render(icon + " " + n.Resource.Address))
+		b.WriteString("\n")
+	})
+	return b.String()
+}
+
+// detailRows returns the number of detail lines shown below the pane title
+func (m Model) detailRows() int {
+	if m.detailHeight < 2 {
+		return 1
+	}
+	return m.detailHeight - 1
+}
+
+// scrollDetail scrolls the detail pane by delta lines, staying within the details
+func (m Model) scrollDetail(delta int) Model {
+	m.detailTop += delta
+	if maxTop := len(m.detailLines()) - m.detailRows(); m.detailTop > maxTop {
+		m.detailTop = maxTop
+	}
+	if m.detailTop < 0 {
+		m.detailTop = 0
+	}
+	return m
+}
+
+// updateDetail handles keys while the detail pane has the focus. It returns
+// false for keys the pane leaves to the tree.
+func (m Model) updateDetail(msg tea.KeyMsg) (Model, bool) {
+	k := m.keys
+	switch {
+	case key.Matches(msg, k.ScrollUp):
+		m = m.scrollDetail(-1)
+	case key.Matches(msg, k.ScrollDown):
+		m = m.scrollDetail(1)
+	case key.Matches(msg, k.PageUp):
+		m = m.scrollDetail(-m.detailRows())
+	case key.Matches(msg, k.PageDown):
+		m = m.scrollDetail(m.detailRows())
+	case key.Matches(msg, k.HalfPageUp):
+		m = m.scrollDetail(-m.detailRows() / 2)
+	case key.Matches(msg, k.HalfPageDown):
+		m = m.scrollDetail(m.detailRows() / 2)
+	case key.Matches(msg, k.DetailTop):
+		m.detailTop = 0
+	case key.Matches(msg, k.DetailBottom):
+		m = m.scrollDetail(len(m.detailLines()))
+
+	case key.Matches(msg, k.NextMatch, k.PrevMatch):
+		// Without a search, the keys do what they do in the tree, e.g. n
+		// toggles the noise
+		if m.search == "" {
+			return m, false
+		}
+		m = m.findMatch(key.Matches(msg, k.NextMatch))
+
+	case key.Matches(msg, k.Back):
+		// Clear the search first, then return to the tree
+		if m.search != "" {
+			m.search = ""
+			m.matchLine = -1
+			break
+		}
+		m.focusDetail = false
+
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// updateSearch handles keys while the search query is typed
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.SearchCancel):
+		m.searching = false
+
+	case key.Matches(msg, m.keys.SearchFind):
+		m.searching = false
+		m.search = m.searchInput
+		m.matchLine = m.detailTop - 1
+		if m.search != "" {
+			m = m.findMatch(true)
+		}
+
+	case msg.Type == tea.KeyBackspace:
+		if len(m.searchInput) > 0 {
+			runes := []rune(m.searchInput)
+			m.searchInput = string(runes[:len(runes)-1])
+		}
+
+	case msg.Type == tea.KeyRunes, msg.Type == tea.KeySpace:
+		m.searchInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// searchMatches returns the indexes of the lines containing query, ignoring case
+func searchMatches(lines []string, query string) []int {
+	matches := make([]int, 0)
+	if query == "" {
+		return matches
+	}
+	query = strings.ToLower(query)
+	for i, line := range lines {
+		if strings.Contains(strings.ToLower(stripANSI(line)), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// findMatch moves to the next or previous line matching the search, wrapping
+// around, and scrolls it into view
+func (m Model) findMatch(forward bool) Model {
+	matches := searchMatches(m.detailLines(), m.search)
+	if len(matches) == 0 {
+		m.notice = fmt.Sprintf("No matches for %q", m.search)
+		m.matchLine = -1
+		return m
+	}
+
+	next := matches[0]
+	if forward {
+		for _, line := range matches {
+			if line > m.matchLine {
+				next = line
+				break
+			}
+		}
+	} else {
+		next = matches[len(matches)-1]
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i] < m.matchLine {
+				next = matches[i]
+				break
+			}
+		}
+	}
+	m.matchLine = next
+
+	if next < m.detailTop || next >= m.detailTop+m.detailRows() {
+		// Show the match with some context above it
+		m.detailTop = next - m.detailRows()/3
+	}
+	return m.scrollDetail(0)
+}
+
+// stripANSI removes the styling from rendered text
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// renderPaneTitle renders the title line of a pane with position information
+// on the right
+func renderPaneTitle(title, position string, width int, active bool) string {
+	style := paneTitleStyle
+	if active {
+		style = paneTitleActiveStyle
+	}
+	title = " " + title + " "
+	position = " " + position + " "
+	gap := width - lipgloss.Width(title) - lipgloss.Width(position)
+	if gap < 1 {
+		return style.Copy().MaxWidth(width).Render(title)
+	}
+	return style.Render(title + strings.Repeat(" ", gap) + position)
+}
+
+// renderPane fits lines into a pane of the given size, cutting off what
+// does not fit and padding the rest
+func renderPane(lines []string, width, height int) string {
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	content := lipgloss.NewStyle().MaxWidth(width).Render(strings.Join(lines[:height], "\n"))
+	return lipgloss.NewStyle().Width(width).Render(content)
+}
+
+// renderTreePane renders the visible part of the tree with its title
+func (m Model) renderTreePane(visibleNodes []*TreeNode) string {
+	end := min(m.viewportTop+m.viewportSize, len(visibleNodes))
+	position := ""
+	if len(visibleNodes) > m.viewportSize {
+		position = fmt.Sprintf("%d-%d of %d", m.viewportTop+1, end, len(visibleNodes))
+	}
+
+	lines := []string{renderPaneTitle("Resources", position, m.treeWidth, !m.focusDetail)}
+	for i := m.viewportTop; i < end; i++ {
+		lines = append(lines, m.renderTreeNode(visibleNodes[i], i == m.cursor))
+	}
+	return renderPane(lines, m.treeWidth, m.viewportSize+1)
+}
+
+// renderDetailPane renders the details of the selected node with their own
+// scroll position, and the search query while it is typed
+func (m Model) renderDetailPane() string {
+	node := m.selectedNode()
+	if node == nil {
+		return ""
+	}
+
+	details := m.detailLines()
+	rows := m.detailRows()
+	end := min(m.detailTop+rows, len(details))
+
+	title := node.Label
+	if !node.IsGroup() {
+		title = node.Resource.Address
+	}
+	position := ""
+	if len(details) > rows {
+		position = fmt.Sprintf("lines %d-%d of %d", m.detailTop+1, end, len(details))
+	}
+	matches := searchMatches(details, m.search)
+	matched := make(map[int]bool, len(matches))
+	current := 0
+	for i, line := range matches {
+		matched[line] = true
+		if line == m.matchLine {
+			current = i + 1
+		}
+	}
+	switch {
+	case m.searching:
+		title = "/" + m.searchInput + "█"
+	case m.search != "":
+		position = fmt.Sprintf("/%s %d/%d  %s", m.search, current, len(matches), position)
+	}
+
+	lines := []string{renderPaneTitle(title, position, m.detailWidth, m.focusDetail || m.searching)}
+	for i := m.detailTop; i < end; i++ {
+		line := details[i]
+		if matched[i] {
+			style := searchMatchStyle
+			if i == m.matchLine {
+				style = searchCurrentStyle
+			}
+			line = style.Render(stripANSI(line))
+		}
+		lines = append(lines, line)
+	}
+	return renderPane(lines, m.detailWidth, m.detailHeight)
+}
@@ -0,0 +1,487 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yourusername/tplan/internal/config"
+)
+
+// KeyMap holds the key bindings of the TUI. Bindings are named in the
+// ui.keybindings config by the snake_case names listed in contexts.
+type KeyMap struct {
+	// Everywhere except while typing
+	Quit     key.Binding
+	Help     key.Binding
+	NextView key.Binding
+	PrevView key.Binding
+
+	// Tree
+	Up            key.Binding
+	Down          key.Binding
+	Toggle        key.Binding
+	Expand        key.Binding
+	Collapse      key.Binding
+	ExpandAll     key.Binding
+	CollapseAll   key.Binding
+	ExpandGroup   key.Binding
+	CollapseGroup key.Binding
+	Top           key.Binding
+	Bottom        key.Binding
+	Noise         key.Binding
+	Search        key.Binding
+	Apply         key.Binding
+	NextFailure   key.Binding
+	ApplySummary  key.Binding
+	Yank          key.Binding
+
+	// Detail pane; the page keys also scroll it from the tree
+	ScrollUp     key.Binding
+	ScrollDown   key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	HalfPageUp   key.Binding
+	HalfPageDown key.Binding
+	DetailTop    key.Binding
+	DetailBottom key.Binding
+	NextMatch    key.Binding
+	PrevMatch    key.Binding
+	Back         key.Binding
+
+	// Copying the selected node after Yank
+	YankAddress   key.Binding
+	YankTarget    key.Binding
+	YankStateShow key.Binding
+	YankBefore    key.Binding
+	YankAfter     key.Binding
+	YankDiff      key.Binding
+
+	// Search query input
+	SearchFind   key.Binding
+	SearchCancel key.Binding
+
+	// Apply confirmation; letters only count when no word has to be typed
+	Confirm key.Binding
+	Cancel  key.Binding
+
+	// Apply summary
+	ExportReport key.Binding
+}
+
+// DefaultKeyMap returns the built-in key bindings
+func DefaultKeyMap() *KeyMap {
+	return &KeyMap{
+		Quit:     newBinding("Quit", "q", "ctrl+c"),
+		Help:     newBinding("Show/hide this help", "?"),
+		NextView: newBinding("Next tab", "tab"),
+		PrevView: newBinding("Previous tab", "shift+tab"),
+
+		Up:            newBinding("Move up", "up", "k"),
+		Down:          newBinding("Move down", "down", "j"),
+		Toggle:        newBinding("Expand/collapse group, open resource details", "enter", " "),
+		Expand:        newBinding("Expand group, open resource details", "right", "l"),
+		Collapse:      newBinding("Collapse group, or go to the enclosing group", "left", "h"),
+		ExpandAll:     newBinding("Expand all groups", "e"),
+		CollapseAll:   newBinding("Collapse all groups", "c"),
+		ExpandGroup:   newBinding("Expand everything in the selected group", "E"),
+		CollapseGroup: newBinding("Collapse everything in the selected group", "C"),
+		Top:           newBinding("Jump to top", "g"),
+		Bottom:        newBinding("Jump to bottom", "G"),
+		Noise:         newBinding("Show/hide changes matching ignore rules", "n"),
+		Search:        newBinding("Search the details", "/"),
+		Apply:         newBinding("Apply the plan", "a"),
+		NextFailure:   newBinding("Next resource that failed to apply", "f"),
+		ApplySummary:  newBinding("Show the apply summary", "s"),
+		Yank:          newBinding("Copy to the clipboard, followed by what to copy", "y"),
+
+		ScrollUp:     newBinding("Scroll up", "up", "k"),
+		ScrollDown:   newBinding("Scroll down", "down", "j"),
+		PageUp:       newBinding("Scroll the details a page up", "pgup"),
+		PageDown:     newBinding("Scroll the details a page down", "pgdown"),
+		HalfPageUp:   newBinding("Scroll the details half a page up", "ctrl+u"),
+		HalfPageDown: newBinding("Scroll the details half a page down", "ctrl+d"),
+		DetailTop:    newBinding("Scroll to the top", "g", "home"),
+		DetailBottom: newBinding("Scroll to the bottom", "G", "end"),
+		NextMatch:    newBinding("Next match", "n"),
+		PrevMatch:    newBinding("Previous match", "N"),
+		Back:         newBinding("Back to the tree, clearing the search first", "esc", "left", "h"),
+
+		YankAddress:   newBinding("The address", "y"),
+		YankTarget:    newBinding("A -target= argument", "t"),
+		YankStateShow: newBinding("A terraform state show command", "s"),
+		YankBefore:    newBinding("The values before the change as JSON", "b"),
+		YankAfter:     newBinding("The values after the change as JSON", "a"),
+		YankDiff:      newBinding("The changed attributes as JSON", "d"),
+
+		SearchFind:   newBinding("Find", "enter"),
+		SearchCancel: newBinding("Cancel", "esc", "ctrl+c"),
+
+		Confirm: newBinding("Confirm", "y", "enter"),
+		Cancel:  newBinding("Cancel", "n", "q", "esc"),
+
+		ExportReport: newBinding("Export the apply report", "r"),
+	}
+}
+
+// presets are the key bindings that replace the defaults in the ui.keymap
+// presets, by action name
+var presets = map[string]map[string][]string{
+	config.KeymapDefault: {},
+	config.KeymapVim: {
+		"page_up":   {"pgup", "ctrl+b"},
+		"page_down": {"pgdown", "ctrl+f"},
+	},
+	config.KeymapEmacs: {
+		"up":            {"up", "ctrl+p"},
+		"down":          {"down", "ctrl+n"},
+		"expand":        {"right", "ctrl+f"},
+		"collapse":      {"left", "ctrl+b"},
+		"top":           {"home", "alt+<"},
+		"bottom":        {"end", "alt+>"},
+		"search":        {"/", "ctrl+s"},
+		"scroll_up":     {"up", "ctrl+p"},
+		"scroll_down":   {"down", "ctrl+n"},
+		"page_up":       {"pgup", "alt+v"},
+		"page_down":     {"pgdown", "ctrl+v"},
+		"detail_top":    {"home", "alt+<"},
+		"detail_bottom": {"end", "alt+>"},
+		"next_match":    {"ctrl+s", "n"},
+		"prev_match":    {"ctrl+r", "N"},
+		"back":          {"esc", "left", "ctrl+g"},
+		"search_cancel": {"esc", "ctrl+g", "ctrl+c"},
+		"yank":          {"alt+w"},
+		"cancel":        {"n", "q", "esc", "ctrl+g"},
+	},
+}
+
+// NewKeyMap returns the key bindings of a ui.keymap preset with overrides
+// from the ui.keybindings config, which map action names to keys. An empty
+// key list unbinds an action. It fails for unknown presets and actions, and
+// for keys bound to two actions that apply at the same time.
+func NewKeyMap(preset string, overrides map[string][]string) (*KeyMap, error) {
+	k := DefaultKeyMap()
+	if preset == "" {
+		preset = config.KeymapDefault
+	}
+	presetKeys, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown keymap %q", preset)
+	}
+
+	actions := make(map[string]*key.Binding)
+	for _, context := range k.contexts() {
+		for _, action := range context.actions {
+			actions[action.name] = action.binding
+		}
+	}
+
+	for _, bindings := range []map[string][]string{presetKeys, overrides} {
+		names := make([]string, 0, len(bindings))
+		for name := range bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			binding, ok := actions[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown action %q", name)
+			}
+			keys := make([]string, len(bindings[name]))
+			for i, keyName := range bindings[name] {
+				keys[i] = normalizeKey(keyName)
+			}
+			*binding = newBinding(binding.Help().Desc, keys...)
+			binding.SetEnabled(len(keys) > 0)
+		}
+	}
+
+	if conflicts := k.conflicts(); len(conflicts) > 0 {
+		return nil, fmt.Errorf("conflicting keybindings: %s", strings.Join(conflicts, "; "))
+	}
+	return k, nil
+}
+
+// keyAction is a binding with its config name
+type keyAction struct {
+	name    string
+	binding *key.Binding
+}
+
+// keyContext is a group of actions that apply at the same time
+type keyContext struct {
+	title   string
+	actions []keyAction
+
+	// global is set if the general actions apply in the context too
+	global bool
+}
+
+// contexts returns the actions of each context in help overlay order. The
+// general actions come first and apply in contexts marked global.
+func (k *KeyMap) contexts() []keyContext {
+	pages := []keyAction{
+		{"page_up", &k.PageUp},
+		{"page_down", &k.PageDown},
+		{"half_page_up", &k.HalfPageUp},
+		{"half_page_down", &k.HalfPageDown},
+	}
+	return []keyContext{
+		{title: "General", actions: []keyAction{
+			{"help", &k.Help},
+			{"next_view", &k.NextView},
+			{"prev_view", &k.PrevView},
+			{"quit", &k.Quit},
+		}},
+		{title: "Tree", global: true, actions: append([]keyAction{
+			{"up", &k.Up},
+			{"down", &k.Down},
+			{"toggle", &k.Toggle},
+			{"expand", &k.Expand},
+			{"collapse", &k.Collapse},
+			{"expand_all", &k.ExpandAll},
+			{"collapse_all", &k.CollapseAll},
+			{"expand_group", &k.ExpandGroup},
+			{"collapse_group", &k.CollapseGroup},
+			{"top", &k.Top},
+			{"bottom", &k.Bottom},
+			{"noise", &k.Noise},
+			{"search", &k.Search},
+			{"apply", &k.Apply},
+			{"next_failure", &k.NextFailure},
+			{"apply_summary", &k.ApplySummary},
+			{"yank", &k.Yank},
+		}, pages...)},
+		{title: "Detail pane", global: true, actions: append([]keyAction{
+			{"scroll_up", &k.ScrollUp},
+			{"scroll_down", &k.ScrollDown},
+			{"detail_top", &k.DetailTop},
+			{"detail_bottom", &k.DetailBottom},
+			{"next_match", &k.NextMatch},
+			{"prev_match", &k.PrevMatch},
+			{"back", &k.Back},
+			{"yank", &k.Yank},
+		}, pages...)},
+		{title: "Copy", actions: []keyAction{
+			{"yank_address", &k.YankAddress},
+			{"yank_target", &k.YankTarget},
+			{"yank_state_show", &k.YankStateShow},
+			{"yank_before", &k.YankBefore},
+			{"yank_after", &k.YankAfter},
+			{"yank_diff", &k.YankDiff},
+		}},
+		{title: "Search", actions: []keyAction{
+			{"search_find", &k.SearchFind},
+			{"search_cancel", &k.SearchCancel},
+		}},
+		{title: "Confirmation", actions: []keyAction{
+			{"confirm", &k.Confirm},
+			{"cancel", &k.Cancel},
+		}},
+		{title: "Apply summary", global: true, actions: []keyAction{
+			{"export_report", &k.ExportReport},
+			{"next_failure", &k.NextFailure},
+			{"apply_summary", &k.ApplySummary},
+			{"back", &k.Back},
+		}},
+	}
+}
+
+// conflicts returns the keys bound to more than one action of a context
+func (k *KeyMap) conflicts() []string {
+	contexts := k.contexts()
+	general := contexts[0].actions
+
+	conflicts := make([]string, 0)
+	for _, context := range contexts {
+		actions := context.actions
+		if context.global {
+			actions = append(append([]keyAction{}, general...), actions...)
+		}
+		bound := make(map[string]string)
+		for _, action := range actions {
+			for _, keyName := range action.binding.Keys() {
+				if other, ok := bound[keyName]; ok && other != action.name {
+					conflicts = append(conflicts, fmt.Sprintf("%s: %q is bound to %s and %s", strings.ToLower(context.title), keyName, other, action.name))
+					continue
+				}
+				bound[keyName] = action.name
+			}
+		}
+	}
+	return conflicts
+}
+
+// newBinding creates a binding whose help shows its keys
+func newBinding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keyLabel(keys), desc))
+}
+
+// normalizeKey turns the names used in config files into key names, e.g.
+// "space" into " "
+func normalizeKey(name string) string {
+	if strings.EqualFold(name, "space") {
+		return " "
+	}
+	return name
+}
+
+// keyLabels are the display names of keys that differ from their key name
+var keyLabels = map[string]string{
+	"up":        "↑",
+	"down":      "↓",
+	"left":      "←",
+	"right":     "→",
+	" ":         "Space",
+	"enter":     "Enter",
+	"esc":       "Esc",
+	"tab":       "Tab",
+	"shift+tab": "Shift+Tab",
+	"pgup":      "PgUp",
+	"pgdown":    "PgDn",
+	"home":      "Home",
+	"end":       "End",
+}
+
+// keyLabel returns the display form of a list of keys, e.g. "↑/k"
+func keyLabel(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, keyName := range keys {
+		switch label, ok := keyLabels[keyName]; {
+		case ok:
+			labels[i] = label
+		case strings.HasPrefix(keyName, "ctrl+"):
+			labels[i] = "Ctrl+" + strings.ToUpper(strings.TrimPrefix(keyName, "ctrl+"))
+		default:
+			labels[i] = keyName
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+// commandKeys returns a copy of a binding without the keys that type a
+// character
+func commandKeys(binding key.Binding) key.Binding {
+	keys := make([]string, 0)
+	for _, keyName := range binding.Keys() {
+		if utf8.RuneCountInString(keyName) != 1 {
+			keys = append(keys, keyName)
+		}
+	}
+	command := newBinding(binding.Help().Desc, keys...)
+	command.SetEnabled(binding.Enabled() && len(keys) > 0)
+	return command
+}
+
+// helpEntry is one "keys: description" item of the help line
+type helpEntry struct {
+	desc     string
+	bindings []key.Binding
+}
+
+// entry creates a help line item showing the first key of each binding,
+// e.g. "↑/↓: Navigate"
+func entry(desc string, bindings ...key.Binding) helpEntry {
+	return helpEntry{desc: desc, bindings: bindings}
+}
+
+// helpLine renders help line items, leaving out those without any bound key
+func helpLine(entries ...helpEntry) string {
+	items := make([]string, 0, len(entries))
+	for _, e := range entries {
+		labels := make([]string, 0, len(e.bindings))
+		for _, binding := range e.bindings {
+			if keys := binding.Keys(); binding.Enabled() && len(keys) > 0 {
+				labels = append(labels, keyLabel(keys[:1]))
+			}
+		}
+		if len(labels) > 0 {
+			items = append(items, strings.Join(labels, "/")+": "+e.desc)
+		}
+	}
+	return strings.Join(items, "  ")
+}
+
+// keyHelpLines renders the help overlay listing every binding by context,
+// in two columns of about the same height when the window is wide enough
+func (m Model) keyHelpLines() []string {
+	sections := make([]string, 0)
+	for _, context := range m.keys.contexts() {
+		var b strings.Builder
+		b.WriteString(helpSectionStyle.Render(context.title))
+		for _, action := range context.actions {
+			if !action.binding.Enabled() {
+				continue
+			}
+			help := action.binding.Help()
+			b.WriteString(fmt.Sprintf("\n  %s %s", helpKeyStyle.Render(fmt.Sprintf("%-16s", help.Key)), help.Desc))
+		}
+		sections = append(sections, b.String())
+	}
+
+	content := strings.Join(sections, "\n\n")
+	if m.width >= 2*helpColumnWidth {
+		// Split where the taller column is shortest
+		split, height := 1, lipgloss.Height(content)
+		for i := 1; i < len(sections); i++ {
+			left := lipgloss.Height(strings.Join(sections[:i], "\n\n"))
+			right := lipgloss.Height(strings.Join(sections[i:], "\n\n"))
+			if max(left, right) < height {
+				split, height = i, max(left, right)
+			}
+		}
+		left := lipgloss.NewStyle().Width(helpColumnWidth).Render(strings.Join(sections[:split], "\n\n"))
+		content = lipgloss.JoinHorizontal(lipgloss.Top, left, strings.Join(sections[split:], "\n\n"))
+	}
+	return append([]string{helpTitleStyle.Render("Keyboard shortcuts"), ""}, strings.Split(content, "\n")...)
+}
+
+// helpRows returns the number of overlay lines shown above its footer
+func (m Model) helpRows() int {
+	return max(m.height-2, 1)
+}
+
+// scrollHelp scrolls the help overlay by delta lines
+func (m Model) scrollHelp(delta int) Model {
+	m.helpTop = clamp(m.helpTop+delta, 0, len(m.keyHelpLines())-m.helpRows())
+	return m
+}
+
+// updateHelp handles keys while the help overlay is shown: the scroll keys
+// scroll it, any other key closes it
+func (m Model) updateHelp(msg tea.KeyMsg) Model {
+	k := m.keys
+	switch {
+	case key.Matches(msg, k.ScrollUp):
+		return m.scrollHelp(-1)
+	case key.Matches(msg, k.ScrollDown):
+		return m.scrollHelp(1)
+	case key.Matches(msg, k.PageUp):
+		return m.scrollHelp(-m.helpRows())
+	case key.Matches(msg, k.PageDown):
+		return m.scrollHelp(m.helpRows())
+	}
+	m.showHelp = false
+	m.helpTop = 0
+	return m
+}
+
+// renderKeyHelp renders the visible part of the help overlay
+func (m Model) renderKeyHelp() string {
+	lines := m.keyHelpLines()
+	footer := "Press any key to close this help. Keys are configured with ui.keymap and ui.keybindings."
+	if rows := m.helpRows(); len(lines) > rows {
+		end := min(m.helpTop+rows, len(lines))
+		footer = fmt.Sprintf("Lines %d-%d of %d  %s  Any other key closes this help.",
+			m.helpTop+1, end, len(lines), helpLine(entry("Scroll", m.keys.ScrollUp, m.keys.ScrollDown)))
+		lines = lines[m.helpTop:end]
+	}
+	return strings.Join(lines, "\n") + "\n\n" + helpStyle.Render(footer)
+}
+
+// helpColumnWidth is the width of a column of the help overlay
+const helpColumnWidth = 66
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		overrides map[string][]string
+		check     func(t *testing.T, k *KeyMap)
+		wantErr   string
+	}{
+		{
+			name:   "default",
+			preset: "",
+			check: func(t *testing.T, k *KeyMap) {
+				if !reflect.DeepEqual(k.Apply.Keys(), []string{"a"}) {
+					t.Errorf("apply keys %v", k.Apply.Keys())
+				}
+			},
+		},
+		{
+			name:   "vim paging",
+			preset: "vim",
+			check: func(t *testing.T, k *KeyMap) {
+				if !reflect.DeepEqual(k.PageDown.Keys(), []string{"pgdown", "ctrl+f"}) {
+					t.Errorf("page down keys %v", k.PageDown.Keys())
+				}
+			},
+		},
+		{
+			name:      "override on top of a preset",
+			preset:    "emacs",
+			overrides: map[string][]string{"apply": {"A"}, "noise": {"ctrl+t"}},
+			check: func(t *testing.T, k *KeyMap) {
+				if !reflect.DeepEqual(k.Apply.Keys(), []string{"A"}) || !reflect.DeepEqual(k.Up.Keys(), []string{"up", "ctrl+p"}) {
+					t.Errorf("apply keys %v, up keys %v", k.Apply.Keys(), k.Up.Keys())
+				}
+			},
+		},
+		{
+			name:      "unbound action",
+			overrides: map[string][]string{"next_failure": {}},
+			check: func(t *testing.T, k *KeyMap) {
+				if k.NextFailure.Enabled() {
+					t.Error("next_failure is still enabled")
+				}
+			},
+		},
+		{
+			name:    "unknown preset",
+			preset:  "nano",
+			wantErr: `unknown keymap "nano"`,
+		},
+		{
+			name:      "unknown action",
+			overrides: map[string][]string{"launch": {"l"}},
+			wantErr:   "launch",
+		},
+		{
+			name:      "conflict in the tree",
+			overrides: map[string][]string{"apply": {"e"}},
+			wantErr:   "conflicting keybindings",
+		},
+		{
+			name:      "conflict with a general action",
+			overrides: map[string][]string{"search": {"q"}},
+			wantErr:   "conflicting keybindings",
+		},
+		{
+			// The tree and the search prompt never apply at the same time
+			name:      "same key in separate contexts",
+			overrides: map[string][]string{"search_cancel": {"esc", "e"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := NewKeyMap(tt.preset, tt.overrides)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.check != nil {
+				tt.check(t, k)
+			}
+		})
+	}
+}
+
+func TestCommandKeys(t *testing.T) {
+	tests := []struct {
+		keys        []string
+		want        []string
+		wantEnabled bool
+	}{
+		{[]string{"y", "enter"}, []string{"enter"}, true},
+		{[]string{"n", "q", "esc"}, []string{"esc"}, true},
+		{[]string{"y"}, []string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.keys, ","), func(t *testing.T) {
+			command := commandKeys(key.NewBinding(key.WithKeys(tt.keys...)))
+			if !reflect.DeepEqual(command.Keys(), tt.want) || command.Enabled() != tt.wantEnabled {
+				t.Errorf("got %v (enabled %t), want %v (enabled %t)", command.Keys(), command.Enabled(), tt.want, tt.wantEnabled)
+			}
+		})
+	}
+}
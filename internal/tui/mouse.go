@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// wheelLines is how far one step of the mouse wheel scrolls
+const wheelLines = 3
+
+// updateMouse handles clicks on tabs, tree nodes and panes, the wheel, and
+// dragging the divider between the tree and the detail pane
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.confirming || m.showApplySummary || m.searching || m.showHelp {
+		return m, nil
+	}
+
+	contentTop := strings.Count(m.renderHeader(), "\n")
+	if m.dragging {
+		switch msg.Action {
+		case tea.MouseActionMotion:
+			m = m.dragDivider(msg, contentTop)
+		case tea.MouseActionRelease:
+			m.dragging = false
+		}
+		return m, nil
+	}
+
+	if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft && msg.Y == m.tabsRow() {
+		if mode, ok := m.tabAt(msg.X); ok {
+			m = m.switchView(mode)
+		}
+		return m.syncDetail(), nil
+	}
+
+	row := msg.Y - contentTop
+	if row < 0 || row >= m.contentHeight {
+		return m, nil
+	}
+	if m.viewMode == ViewChanges {
+		m = m.mouseChanges(msg, row)
+	} else {
+		m = m.mouseList(msg, row)
+	}
+	return m.syncDetail(), nil
+}
+
+// tabsRow returns the screen row of the tab bar
+func (m Model) tabsRow() int {
+	if len(m.production) > 0 {
+		return lipgloss.Height(m.renderProductionBanner()) + 1
+	}
+	return 0
+}
+
+// tabAt returns the view of the tab at screen column x
+func (m Model) tabAt(x int) (ViewMode, bool) {
+	left := 0
+	for i, tab := range m.tabs() {
+		right := left + lipgloss.Width(tab)
+		if x >= left && x < right {
+			return ViewMode(i), true
+		}
+		left = right
+	}
+	return ViewChanges, false
+}
+
+// mouseChanges handles the mouse in the changes view. row is relative to
+// the top of the tree pane.
+func (m Model) mouseChanges(msg tea.MouseMsg, row int) Model {
+	// The divider is the column between the panes side by side, and the
+	// title of the detail pane otherwise
+	inTree := row <= m.viewportSize
+	onDivider := row == m.viewportSize+1
+	if m.sideBySide {
+		inTree = msg.X < m.treeWidth
+		onDivider = msg.X >= m.treeWidth && msg.X < m.treeWidth+3
+	}
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown:
+		lines := wheelLines
+		if msg.Button == tea.MouseButtonWheelUp {
+			lines = -wheelLines
+		}
+		if inTree {
+			return m.scrollTree(lines)
+		}
+		return m.scrollDetail(lines)
+
+	case msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft:
+		return m
+
+	case onDivider:
+		m.dragging = true
+		return m
+
+	case !inTree:
+		m.focusDetail = true
+		return m
+	}
+
+	m.focusDetail = false
+	visibleNodes := m.getVisibleNodes()
+	index := m.viewportTop + row - 1 // below the pane title
+	if row == 0 || index >= len(visibleNodes) {
+		return m
+	}
+
+	// Clicking the selected node or the expand icon of a group toggles it;
+	// clicking the selected resource moves to its details
+	node := visibleNodes[index]
+	onIcon := msg.X >= 2+2*node.Level && msg.X < 4+2*node.Level
+	switch {
+	case node.IsGroup() && (index == m.cursor || onIcon):
+		node.Expanded = !node.Expanded
+	case !node.IsGroup() && index == m.cursor:
+		m.focusDetail = true
+	}
+	m.cursor = index
+	return m.adjustViewport()
+}
+
+// mouseList handles the mouse in the errors and warnings views, which show
+// one entry per row
+func (m Model) mouseList(msg tea.MouseMsg, row int) Model {
+	count := len(m.plan.Errors)
+	if m.viewMode == ViewWarnings {
+		count = len(m.plan.Warnings)
+	}
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.cursor = clamp(m.cursor-1, 0, count-1)
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.cursor = clamp(m.cursor+1, 0, count-1)
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft && row < count:
+		m.cursor = row
+	}
+	return m
+}
+
+// scrollTree scrolls the tree by delta lines without moving the cursor
+func (m Model) scrollTree(delta int) Model {
+	maxTop := len(m.getVisibleNodes()) - m.viewportSize
+	m.viewportTop = clamp(m.viewportTop+delta, 0, maxTop)
+	return m
+}
+
+// dragDivider resizes the panes to the mouse position
+func (m Model) dragDivider(msg tea.MouseMsg, contentTop int) Model {
+	if m.sideBySide {
+		m.splitWidth = msg.X - 1
+	} else {
+		m.splitHeight = msg.Y - contentTop
+	}
+	return m.layout()
+}
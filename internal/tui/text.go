@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// RenderText renders the plan as static text for output that is not
+// interactive, such as CI logs or a pager: the summary, the fully expanded
+// tree with the attribute diffs of every resource, and the errors and
+// warnings. Colors follow stdout, so they are left out when stdout is not a
+// terminal or NO_COLOR is set.
+func RenderText(plan *models.PlanResult, opts Options) string {
+	m := NewModel(plan, opts)
+	m.cursor = -1 // nothing is selected
+	m.inlineDetails = true
+
+	var b strings.Builder
+	if len(m.production) > 0 {
+		b.WriteString(m.renderProductionBanner())
+		b.WriteString("\n\n")
+	}
+	b.WriteString(m.renderSummary())
+	b.WriteString("\n")
+
+	for _, node := range m.nodes {
+		node.SetExpandedRecursive(true)
+	}
+	nodes := flattenVisible(m.nodes, nil)
+	if len(nodes) == 0 {
+		b.WriteString(helpStyle.Render("No changes."))
+		b.WriteString("\n")
+	}
+	for _, node := range nodes {
+		b.WriteString(m.renderTreeNode(node, false))
+		b.WriteString("\n")
+		if showsDetails(node) {
+			b.WriteString(m.renderResourceDetails(node))
+		}
+	}
+
+	if len(plan.Errors) > 0 {
+		b.WriteString("\n")
+		b.WriteString(deleteStyle.Render("Errors:"))
+		b.WriteString("\n")
+		b.WriteString(m.renderErrorsView())
+	}
+	if len(plan.Warnings) > 0 {
+		b.WriteString("\n")
+		b.WriteString(updateStyle.Render("Warnings:"))
+		b.WriteString("\n")
+		b.WriteString(m.renderWarningsView())
+	}
+	return b.String()
+}
+
+// DisableColor makes the styles render plain text, e.g. for text written to
+// a file rather than the terminal
+func DisableColor() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
@@ -0,0 +1,246 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yourusername/tplan/internal/config"
+)
+
+// Theme holds the colors the TUI and the text output are drawn with
+type Theme struct {
+	// Actions, also used for added and removed values
+	Create  lipgloss.TerminalColor
+	Update  lipgloss.TerminalColor
+	Delete  lipgloss.TerminalColor
+	Replace lipgloss.TerminalColor
+
+	Text      lipgloss.TerminalColor // resource metadata, file groups, unchanged resources
+	Module    lipgloss.TerminalColor // module group names
+	Root      lipgloss.TerminalColor // root module group names
+	Warning   lipgloss.TerminalColor // warnings and uncommitted changes
+	Muted     lipgloss.TerminalColor // help text and resources waiting to be applied
+	TreeLine  lipgloss.TerminalColor // tree indentation and resource counts
+	Attribute lipgloss.TerminalColor // attribute names
+	Context   lipgloss.TerminalColor // workspace context in the header
+
+	Selected     lipgloss.TerminalColor // background of the selected line
+	SelectedText lipgloss.TerminalColor // text of the selected line; nil keeps the action colors
+
+	Accent      lipgloss.TerminalColor // active tab and pane, borders
+	AccentText  lipgloss.TerminalColor
+	Surface     lipgloss.TerminalColor // inactive tabs, pane titles, input fields
+	SurfaceText lipgloss.TerminalColor
+	Banner      lipgloss.TerminalColor // production warning
+	BannerText  lipgloss.TerminalColor
+
+	Match            lipgloss.TerminalColor // search matches
+	MatchText        lipgloss.TerminalColor
+	CurrentMatch     lipgloss.TerminalColor
+	CurrentMatchText lipgloss.TerminalColor
+
+	// Reverse marks the selection, active tabs and panes and search matches
+	// with reverse video, for themes without colors
+	Reverse bool
+}
+
+// themes are the named themes of the ui.theme config
+var themes = map[string]Theme{
+	config.ThemeDark: {
+		Create: lipgloss.Color("10"), Update: lipgloss.Color("11"), Delete: lipgloss.Color("9"), Replace: lipgloss.Color("12"),
+		Text: lipgloss.Color("15"), Module: lipgloss.Color("14"), Root: lipgloss.Color("13"), Warning: lipgloss.Color("11"),
+		Muted: lipgloss.Color("241"), TreeLine: lipgloss.Color("240"), Attribute: lipgloss.Color("245"), Context: lipgloss.Color("250"),
+		Selected: lipgloss.Color("62"),
+		Accent:   lipgloss.Color("62"), AccentText: lipgloss.Color("15"),
+		Surface: lipgloss.Color("236"), SurfaceText: lipgloss.Color("250"),
+		Banner: lipgloss.Color("196"), BannerText: lipgloss.Color("15"),
+		Match: lipgloss.Color("58"), MatchText: lipgloss.Color("15"),
+		CurrentMatch: lipgloss.Color("214"), CurrentMatchText: lipgloss.Color("0"),
+	},
+	config.ThemeLight: {
+		Create: lipgloss.Color("28"), Update: lipgloss.Color("130"), Delete: lipgloss.Color("160"), Replace: lipgloss.Color("25"),
+		Text: lipgloss.Color("235"), Module: lipgloss.Color("30"), Root: lipgloss.Color("90"), Warning: lipgloss.Color("130"),
+		Muted: lipgloss.Color("244"), TreeLine: lipgloss.Color("248"), Attribute: lipgloss.Color("242"), Context: lipgloss.Color("238"),
+		Selected: lipgloss.Color("189"),
+		Accent:   lipgloss.Color("62"), AccentText: lipgloss.Color("15"),
+		Surface: lipgloss.Color("254"), SurfaceText: lipgloss.Color("238"),
+		Banner: lipgloss.Color("160"), BannerText: lipgloss.Color("15"),
+		Match: lipgloss.Color("229"), MatchText: lipgloss.Color("0"),
+		CurrentMatch: lipgloss.Color("214"), CurrentMatchText: lipgloss.Color("0"),
+	},
+	config.ThemeHighContrast: {
+		Create: lipgloss.Color("46"), Update: lipgloss.Color("226"), Delete: lipgloss.Color("196"), Replace: lipgloss.Color("51"),
+		Text: lipgloss.Color("15"), Module: lipgloss.Color("15"), Root: lipgloss.Color("15"), Warning: lipgloss.Color("226"),
+		Muted: lipgloss.Color("252"), TreeLine: lipgloss.Color("250"), Attribute: lipgloss.Color("255"), Context: lipgloss.Color("255"),
+		Selected: lipgloss.Color("226"), SelectedText: lipgloss.Color("0"),
+		Accent: lipgloss.Color("15"), AccentText: lipgloss.Color("0"),
+		Surface: lipgloss.Color("238"), SurfaceText: lipgloss.Color("15"),
+		Banner: lipgloss.Color("196"), BannerText: lipgloss.Color("15"),
+		Match: lipgloss.Color("21"), MatchText: lipgloss.Color("15"),
+		CurrentMatch: lipgloss.Color("226"), CurrentMatchText: lipgloss.Color("0"),
+	},
+	// Okabe-Ito colors, which stay apart with every kind of color blindness
+	config.ThemeColorblind: {
+		Create:  lipgloss.AdaptiveColor{Light: "#0072B2", Dark: "#56B4E9"}, // blue
+		Update:  lipgloss.AdaptiveColor{Light: "#9A6700", Dark: "#F0E442"}, // yellow
+		Delete:  lipgloss.Color("#D55E00"),                                 // vermillion
+		Replace: lipgloss.AdaptiveColor{Light: "#AA4499", Dark: "#CC79A7"}, // reddish purple
+		Text:    lipgloss.AdaptiveColor{Light: "235", Dark: "15"},
+		Module:  lipgloss.AdaptiveColor{Light: "#007A5A", Dark: "#009E73"}, // bluish green
+		Root:    lipgloss.AdaptiveColor{Light: "235", Dark: "15"},
+		Warning: lipgloss.AdaptiveColor{Light: "#9A6700", Dark: "#F0E442"},
+		Muted:   lipgloss.AdaptiveColor{Light: "244", Dark: "241"}, TreeLine: lipgloss.AdaptiveColor{Light: "248", Dark: "240"},
+		Attribute: lipgloss.AdaptiveColor{Light: "242", Dark: "245"}, Context: lipgloss.AdaptiveColor{Light: "238", Dark: "250"},
+		Selected: lipgloss.AdaptiveColor{Light: "189", Dark: "62"},
+		Accent:   lipgloss.Color("62"), AccentText: lipgloss.Color("15"),
+		Surface: lipgloss.AdaptiveColor{Light: "254", Dark: "236"}, SurfaceText: lipgloss.AdaptiveColor{Light: "238", Dark: "250"},
+		Banner: lipgloss.Color("#D55E00"), BannerText: lipgloss.Color("15"),
+		Match: lipgloss.AdaptiveColor{Light: "153", Dark: "24"}, MatchText: lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+		CurrentMatch: lipgloss.Color("#E69F00"), CurrentMatchText: lipgloss.Color("0"),
+	},
+	config.ThemeNone: {
+		Create: lipgloss.NoColor{}, Update: lipgloss.NoColor{}, Delete: lipgloss.NoColor{}, Replace: lipgloss.NoColor{},
+		Text: lipgloss.NoColor{}, Module: lipgloss.NoColor{}, Root: lipgloss.NoColor{}, Warning: lipgloss.NoColor{},
+		Muted: lipgloss.NoColor{}, TreeLine: lipgloss.NoColor{}, Attribute: lipgloss.NoColor{}, Context: lipgloss.NoColor{},
+		Selected: lipgloss.NoColor{},
+		Accent:   lipgloss.NoColor{}, AccentText: lipgloss.NoColor{},
+		Surface: lipgloss.NoColor{}, SurfaceText: lipgloss.NoColor{},
+		Banner: lipgloss.NoColor{}, BannerText: lipgloss.NoColor{},
+		Match: lipgloss.NoColor{}, MatchText: lipgloss.NoColor{},
+		CurrentMatch: lipgloss.NoColor{}, CurrentMatchText: lipgloss.NoColor{},
+		Reverse: true,
+	},
+}
+
+// actionLabels is set when actions are spelled out next to their icons
+var actionLabels bool
+
+// Styles for the TUI, set by applyTheme
+var (
+	// Actions
+	createStyle  lipgloss.Style
+	updateStyle  lipgloss.Style
+	deleteStyle  lipgloss.Style
+	replaceStyle lipgloss.Style
+	noopStyle    lipgloss.Style
+
+	// Tree and details
+	selectedBgStyle lipgloss.Style // background only, the text keeps its action color
+	textStyle       lipgloss.Style
+	moduleStyle     lipgloss.Style
+	rootStyle       lipgloss.Style
+	warningStyle    lipgloss.Style
+	treeLineStyle   lipgloss.Style
+	attributeStyle  lipgloss.Style
+	valueAddStyle   lipgloss.Style
+	valueRemStyle   lipgloss.Style
+
+	// Header, tabs and help
+	summaryStyle   lipgloss.Style
+	tabActiveStyle lipgloss.Style
+	tabStyle       lipgloss.Style
+	helpStyle      lipgloss.Style
+	contextStyle   lipgloss.Style
+	bannerStyle    lipgloss.Style
+
+	// Panes and search
+	paneTitleStyle       lipgloss.Style
+	paneTitleActiveStyle lipgloss.Style
+	searchMatchStyle     lipgloss.Style
+	searchCurrentStyle   lipgloss.Style
+
+	// Help overlay
+	helpTitleStyle   lipgloss.Style
+	helpSectionStyle lipgloss.Style
+	helpKeyStyle     lipgloss.Style
+
+	// Apply confirmation
+	confirmStyle      lipgloss.Style
+	confirmTitleStyle lipgloss.Style
+	confirmInputStyle lipgloss.Style
+
+	// Apply progress
+	pendingStyle    lipgloss.Style
+	applyingStyle   lipgloss.Style
+	appliedStyle    lipgloss.Style
+	failedStyle     lipgloss.Style
+	applyPanelStyle lipgloss.Style
+)
+
+func init() {
+	applyTheme(themes[config.ThemeDark])
+}
+
+// UseTheme switches to a theme of the ui.theme config. ThemeAuto picks the
+// dark or light theme from the terminal background. With actionLabels, the
+// action of every resource is spelled out rather than told by color and icon.
+func UseTheme(name string, labels bool) error {
+	if name == config.ThemeAuto || name == "" {
+		name = config.ThemeLight
+		if lipgloss.HasDarkBackground() {
+			name = config.ThemeDark
+		}
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q", name)
+	}
+	applyTheme(theme)
+	actionLabels = labels
+	return nil
+}
+
+// applyTheme sets the styles to the colors of a theme
+func applyTheme(t Theme) {
+	fg := func(color lipgloss.TerminalColor) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(color)
+	}
+	colors := func(bg, text lipgloss.TerminalColor) lipgloss.Style {
+		return lipgloss.NewStyle().Background(bg).Foreground(text).Reverse(t.Reverse)
+	}
+
+	createStyle = fg(t.Create).Bold(true)
+	updateStyle = fg(t.Update).Bold(true)
+	deleteStyle = fg(t.Delete).Bold(true)
+	replaceStyle = fg(t.Replace).Bold(true)
+	noopStyle = fg(t.Text)
+
+	selectedBgStyle = lipgloss.NewStyle().Background(t.Selected).Reverse(t.Reverse)
+	if t.SelectedText != nil {
+		selectedBgStyle = selectedBgStyle.Foreground(t.SelectedText)
+	}
+	textStyle = fg(t.Text)
+	moduleStyle = fg(t.Module).Bold(true)
+	rootStyle = fg(t.Root).Bold(true)
+	warningStyle = fg(t.Warning)
+	treeLineStyle = fg(t.TreeLine)
+	attributeStyle = fg(t.Attribute)
+	valueAddStyle = fg(t.Create)
+	valueRemStyle = fg(t.Delete)
+
+	summaryStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(t.Accent).Padding(0, 1).MarginBottom(1)
+	tabActiveStyle = colors(t.Accent, t.AccentText).Padding(0, 2).Bold(true)
+	tabStyle = lipgloss.NewStyle().Background(t.Surface).Foreground(t.SurfaceText).Padding(0, 2)
+	helpStyle = fg(t.Muted).Italic(true)
+	contextStyle = fg(t.Context)
+	bannerStyle = colors(t.Banner, t.BannerText).Bold(true).Padding(0, 1)
+
+	paneTitleStyle = lipgloss.NewStyle().Background(t.Surface).Foreground(t.SurfaceText).Underline(t.Reverse)
+	paneTitleActiveStyle = colors(t.Accent, t.AccentText).Bold(true)
+	searchMatchStyle = lipgloss.NewStyle().Background(t.Match).Foreground(t.MatchText).Underline(t.Reverse)
+	searchCurrentStyle = colors(t.CurrentMatch, t.CurrentMatchText)
+
+	helpTitleStyle = fg(t.Accent).Bold(true)
+	helpSectionStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+	helpKeyStyle = fg(t.Warning)
+
+	confirmStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(t.Delete).Padding(0, 1)
+	confirmTitleStyle = lipgloss.NewStyle().Bold(true)
+	confirmInputStyle = colors(t.Surface, t.Text).Padding(0, 1)
+
+	pendingStyle = fg(t.Muted)
+	applyingStyle = fg(t.Update)
+	appliedStyle = fg(t.Create)
+	failedStyle = fg(t.Delete).Bold(true)
+	applyPanelStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(t.Accent).Padding(0, 1)
+}
@@ -0,0 +1,417 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/config"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// NodeKind distinguishes resource nodes from grouping nodes in the tree
+type NodeKind int
+
+const (
+	NodeResource NodeKind = iota
+	NodeModule
+	NodeFile
+	NodeRoot
+)
+
+// TreeNode represents a node in the hierarchical tree view
+type TreeNode struct {
+	Kind     NodeKind
+	Label    string // Display label for group nodes (module address, file name or root directory)
+	Resource models.ResourceChange
+	Expanded bool
+	Children []*TreeNode
+	Parent   *TreeNode
+	Level    int
+
+	// noiseOnly is set on resources whose changes all match ignore rules,
+	// see Model.markNoise
+	noiseOnly bool
+}
+
+// ActionCounts holds the number of resources per action below a group node
+type ActionCounts struct {
+	Create  int
+	Update  int
+	Delete  int
+	Replace int
+}
+
+// Total returns the number of changing resources counted
+func (c ActionCounts) Total() int {
+	return c.Create + c.Update + c.Delete + c.Replace
+}
+
+// IsGroup returns true if the node is a root, module or file grouping node
+func (n *TreeNode) IsGroup() bool {
+	return n.Kind != NodeResource
+}
+
+// Counts aggregates the action counts of all resources in the node's subtree
+func (n *TreeNode) Counts() ActionCounts {
+	var c ActionCounts
+	n.walk(func(node *TreeNode) {
+		if node.IsGroup() {
+			return
+		}
+		switch node.Resource.Action {
+		case models.ActionCreate:
+			c.Create++
+		case models.ActionUpdate:
+			c.Update++
+		case models.ActionDelete:
+			c.Delete++
+		case models.ActionReplace:
+			c.Replace++
+		}
+	})
+	return c
+}
+
+// ResourceCount returns the number of resource nodes in the node's subtree
+func (n *TreeNode) ResourceCount() int {
+	count := 0
+	n.walk(func(node *TreeNode) {
+		if !node.IsGroup() {
+			count++
+		}
+	})
+	return count
+}
+
+// SetExpandedRecursive expands or collapses the node and every node below it
+func (n *TreeNode) SetExpandedRecursive(expanded bool) {
+	n.walk(func(node *TreeNode) {
+		node.Expanded = expanded
+	})
+}
+
+// walk calls fn for the node and all of its descendants, depth first
+func (n *TreeNode) walk(fn func(*TreeNode)) {
+	fn(n)
+	for _, child := range n.Children {
+		child.walk(fn)
+	}
+}
+
+// addChild attaches a child node and fixes up its parent and level
+func (n *TreeNode) addChild(child *TreeNode) {
+	child.Parent = n
+	child.setLevel(n.Level + 1)
+	n.Children = append(n.Children, child)
+}
+
+// setLevel sets the level of the node and shifts its descendants accordingly
+func (n *TreeNode) setLevel(level int) {
+	n.Level = level
+	for _, child := range n.Children {
+		child.setLevel(level + 1)
+	}
+}
+
+// newResourceNode creates a leaf node for a resource
+func newResourceNode(res models.ResourceChange) *TreeNode {
+	return &TreeNode{
+		Kind:     NodeResource,
+		Resource: res,
+		Children: []*TreeNode{},
+	}
+}
+
+// newGroupNode creates an empty grouping node
+func newGroupNode(kind NodeKind, label string) *TreeNode {
+	return &TreeNode{
+		Kind:     kind,
+		Label:    label,
+		Children: make([]*TreeNode, 0),
+	}
+}
+
+// buildTreeNodes converts resources into a hierarchical tree structure with grouping.
+// Resources of a multi-root plan are grouped by root module directory first.
+func buildTreeNodes(resources []models.ResourceChange, grouping string) []*TreeNode {
+	// Filter out resources with no changes (no-op)
+	// Only show resources that are actually changing
+	changingResources := make([]models.ResourceChange, 0)
+	rootResources := make(map[string][]models.ResourceChange)
+	for _, res := range resources {
+		if res.Action != models.ActionNoOp {
+			changingResources = append(changingResources, res)
+			rootResources[res.Root] = append(rootResources[res.Root], res)
+		}
+	}
+
+	if _, single := rootResources[""]; single || len(rootResources) == 0 {
+		return buildGroupedNodes(changingResources, grouping)
+	}
+
+	roots := make([]string, 0, len(rootResources))
+	for root := range rootResources {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+
+	nodes := make([]*TreeNode, 0, len(roots))
+	for _, root := range roots {
+		rootNode := newGroupNode(NodeRoot, root)
+		for _, child := range buildGroupedNodes(rootResources[root], grouping) {
+			rootNode.addChild(child)
+		}
+		nodes = append(nodes, rootNode)
+	}
+	return nodes
+}
+
+// buildGroupedNodes groups the changing resources of one root module
+func buildGroupedNodes(changingResources []models.ResourceChange, grouping string) []*TreeNode {
+	switch grouping {
+	case config.GroupNone:
+		sortByAddress(changingResources)
+		nodes := make([]*TreeNode, 0, len(changingResources))
+		for _, res := range changingResources {
+			nodes = append(nodes, newResourceNode(res))
+		}
+		return nodes
+
+	case config.GroupByFile:
+		sortByAddress(changingResources)
+		return buildFileNodes(changingResources, getResourceFilePath)
+	}
+
+	// Split root module resources from module resources
+	rootResources := make([]models.ResourceChange, 0)
+	moduleResources := make([]models.ResourceChange, 0)
+	for _, res := range changingResources {
+		if res.Module == "" {
+			rootResources = append(rootResources, res)
+		} else {
+			moduleResources = append(moduleResources, res)
+		}
+	}
+
+	sortByAddress(rootResources)
+	sortByAddress(moduleResources)
+
+	nodes := buildModuleNodes(moduleResources)
+	nodes = append(nodes, buildFileNodes(rootResources, getResourceFileName)...)
+
+	return nodes
+}
+
+// sortByAddress sorts resources by address for consistent ordering
+func sortByAddress(resources []models.ResourceChange) {
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Address < resources[j].Address
+	})
+}
+
+// buildModuleNodes builds nested module group nodes, so that
+// "module.app.module.db" becomes a child group of "module.app"
+func buildModuleNodes(resources []models.ResourceChange) []*TreeNode {
+	groups := make(map[string]*TreeNode)
+	roots := make([]*TreeNode, 0)
+
+	var groupFor func(address string) *TreeNode
+	groupFor = func(address string) *TreeNode {
+		if group, ok := groups[address]; ok {
+			return group
+		}
+
+		group := newGroupNode(NodeModule, address)
+		groups[address] = group
+
+		if parent := parentModuleAddress(address); parent != "" {
+			groupFor(parent).addChild(group)
+		} else {
+			roots = append(roots, group)
+		}
+		return group
+	}
+
+	for _, res := range resources {
+		groupFor(res.Module).addChild(newResourceNode(res))
+	}
+
+	sortGroups(roots)
+	return roots
+}
+
+// parentModuleAddress returns the address of the calling module, or "" for a top-level module
+// e.g., "module.app.module.db" -> "module.app"
+func parentModuleAddress(address string) string {
+	idx := strings.LastIndex(address, ".module.")
+	if idx == -1 {
+		return ""
+	}
+	return address[:idx]
+}
+
+// sortGroups orders children so nested groups come first, each sorted by label,
+// followed by resources in their original (address) order
+func sortGroups(nodes []*TreeNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].IsGroup() != nodes[j].IsGroup() {
+			return nodes[i].IsGroup()
+		}
+		if nodes[i].IsGroup() {
+			return nodes[i].Label < nodes[j].Label
+		}
+		return false
+	})
+	for _, node := range nodes {
+		sortGroups(node.Children)
+	}
+}
+
+// buildFileNodes groups resources by the file that declares them, using fileName to label each file
+func buildFileNodes(resources []models.ResourceChange, fileName func(models.ResourceChange) string) []*TreeNode {
+	nodes := make([]*TreeNode, 0)
+
+	// Group root resources by file
+	fileGroups := make(map[string][]models.ResourceChange)
+	ungroupedResources := make([]models.ResourceChange, 0)
+
+	// First pass: group resources by file
+	for _, res := range resources {
+		name := fileName(res)
+		if name == "unknown.tf" {
+			// Don't group resources we can't find files for yet
+			ungroupedResources = append(ungroupedResources, res)
+		} else {
+			fileGroups[name] = append(fileGroups[name], res)
+		}
+	}
+
+	// Second pass: try to group ungrouped deleted resources with their replacements
+	remainingUngrouped := make([]models.ResourceChange, 0)
+	for _, res := range ungroupedResources {
+		// Only try to relocate deleted resources
+		if res.Action == models.ActionDelete {
+			// Look for a create operation with the same type and index
+			targetFile := findReplacementFile(res, resources, fileName)
+			if targetFile != "" {
+				// Group this deleted resource with its replacement
+				fileGroups[targetFile] = append(fileGroups[targetFile], res)
+			} else {
+				remainingUngrouped = append(remainingUngrouped, res)
+			}
+		} else {
+			remainingUngrouped = append(remainingUngrouped, res)
+		}
+	}
+	ungroupedResources = remainingUngrouped
+
+	// Sort file names
+	fileNames := make([]string, 0, len(fileGroups))
+	for fileName := range fileGroups {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		fileResources := fileGroups[fileName]
+
+		// If only one file in root and no ungrouped resources, don't create a grouping node
+		if len(fileGroups) == 1 && len(ungroupedResources) == 0 {
+			for _, res := range fileResources {
+				nodes = append(nodes, newResourceNode(res))
+			}
+			continue
+		}
+
+		fileNode := newGroupNode(NodeFile, fileName)
+		for _, res := range fileResources {
+			fileNode.addChild(newResourceNode(res))
+		}
+		nodes = append(nodes, fileNode)
+	}
+
+	// Add ungrouped resources at the end (no file grouping)
+	for _, res := range ungroupedResources {
+		nodes = append(nodes, newResourceNode(res))
+	}
+
+	return nodes
+}
+
+// getResourceFileName extracts the file name from a resource
+func getResourceFileName(res models.ResourceChange) string {
+	// If drift info is available, use the file path
+	if res.DriftInfo != nil && res.DriftInfo.FilePath != "" {
+		// Extract just the filename from the path
+		parts := strings.Split(res.DriftInfo.FilePath, "/")
+		return parts[len(parts)-1]
+	}
+
+	// Fallback: return "unknown.tf" if no file info available
+	return "unknown.tf"
+}
+
+// getResourceFilePath returns the file declaring a resource relative to the
+// working directory, so files with the same name in different modules stay apart
+func getResourceFilePath(res models.ResourceChange) string {
+	if res.DriftInfo == nil || res.DriftInfo.FilePath == "" {
+		return "unknown.tf"
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, res.DriftInfo.FilePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return res.DriftInfo.FilePath
+}
+
+// findReplacementFile finds the file for a deleted resource by looking for a create operation
+// with the same resource type and index (likely a renamed resource)
+func findReplacementFile(deletedRes models.ResourceChange, allResources []models.ResourceChange, fileName func(models.ResourceChange) string) string {
+	// Extract the index from the deleted resource
+	deletedIndex := deletedRes.Index
+
+	// Look for a create operation with the same type and index
+	for _, res := range allResources {
+		if res.Action == models.ActionCreate && res.Type == deletedRes.Type {
+			// Check if the index matches
+			if indexMatches(res.Index, deletedIndex) {
+				// Found a potential replacement - get its file
+				if name := fileName(res); name != "unknown.tf" {
+					return name
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// indexMatches checks if two resource indices match
+func indexMatches(idx1, idx2 interface{}) bool {
+	// Handle nil cases
+	if idx1 == nil && idx2 == nil {
+		return true
+	}
+	if idx1 == nil || idx2 == nil {
+		return false
+	}
+
+	// Compare as strings to handle both int and string indices
+	return fmt.Sprintf("%v", idx1) == fmt.Sprintf("%v", idx2)
+}
+
+// flattenVisible appends the node and, if expanded, its visible descendants
+func flattenVisible(nodes []*TreeNode, visible []*TreeNode) []*TreeNode {
+	for _, node := range nodes {
+		visible = append(visible, node)
+		if node.IsGroup() && node.Expanded {
+			visible = flattenVisible(node.Children, visible)
+		}
+	}
+	return visible
+}
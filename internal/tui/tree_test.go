@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/config"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// change returns a resource change in a root and module, declared in file
+func change(root, module, address string, action models.ChangeAction, file string) models.ResourceChange {
+	res := models.ResourceChange{Root: root, Module: module, Address: address, Action: action}
+	res.Type = strings.Split(strings.TrimPrefix(address, module+"."), ".")[0]
+	if file != "" {
+		res.DriftInfo = &models.DriftInfo{FilePath: file}
+	}
+	return res
+}
+
+// dump renders a tree as one indented line per node
+func dump(nodes []*TreeNode) string {
+	var b strings.Builder
+	for _, root := range nodes {
+		root.walk(func(node *TreeNode) {
+			label := node.Label
+			if !node.IsGroup() {
+				label = node.Resource.Address
+			}
+			b.WriteString(fmt.Sprintf("%s%s\n", strings.Repeat("  ", node.Level), label))
+		})
+	}
+	return b.String()
+}
+
+func TestBuildTreeNodes(t *testing.T) {
+	resources := []models.ResourceChange{
+		change("", "module.app.module.db", "module.app.module.db.aws_db_instance.main", models.ActionUpdate, "/src/modules/db/main.tf"),
+		change("", "", "aws_s3_bucket.logs", models.ActionCreate, "/src/storage.tf"),
+		change("", "module.app", "module.app.aws_instance.web", models.ActionCreate, "/src/modules/app/main.tf"),
+		change("", "", "aws_vpc.main", models.ActionNoOp, "/src/network.tf"),
+		change("", "", "aws_iam_role.ci", models.ActionDelete, "/src/iam.tf"),
+		change("", "module.cdn", "module.cdn.aws_cloudfront_distribution.site", models.ActionReplace, ""),
+	}
+
+	tests := []struct {
+		name     string
+		grouping string
+		want     string
+	}{
+		{
+			name:     "by module",
+			grouping: config.GroupByModule,
+			want: `module.app
+  module.app.module.db
+    module.app.module.db.aws_db_instance.main
+  module.app.aws_instance.web
+module.cdn
+  module.cdn.aws_cloudfront_distribution.site
+iam.tf
+  aws_iam_role.ci
+storage.tf
+  aws_s3_bucket.logs
+`,
+		},
+		{
+			name:     "none",
+			grouping: config.GroupNone,
+			want: `aws_iam_role.ci
+aws_s3_bucket.logs
+module.app.aws_instance.web
+module.app.module.db.aws_db_instance.main
+module.cdn.aws_cloudfront_distribution.site
+`,
+		},
+		{
+			name:     "by file",
+			grouping: config.GroupByFile,
+			want: `/src/iam.tf
+  aws_iam_role.ci
+/src/modules/app/main.tf
+  module.app.aws_instance.web
+/src/modules/db/main.tf
+  module.app.module.db.aws_db_instance.main
+/src/storage.tf
+  aws_s3_bucket.logs
+module.cdn.aws_cloudfront_distribution.site
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dump(buildTreeNodes(resources, tt.grouping)); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	app := buildTreeNodes(resources, config.GroupByModule)[0]
+	if counts := app.Counts(); counts != (ActionCounts{Create: 1, Update: 1}) || counts.Total() != 2 || app.ResourceCount() != 2 {
+		t.Errorf("module.app counts %+v, %d resources", counts, app.ResourceCount())
+	}
+	if app.Children[0].Parent != app {
+		t.Error("nested module is not linked to its parent")
+	}
+}
+
+func TestBuildTreeNodesMultiRoot(t *testing.T) {
+	nodes := buildTreeNodes([]models.ResourceChange{
+		change("network", "", "aws_vpc.main", models.ActionUpdate, ""),
+		change("app", "module.web", "module.web.aws_instance.a", models.ActionCreate, ""),
+		change("app", "module.web", "module.web.aws_instance.b", models.ActionDelete, ""),
+		change("app", "", "aws_s3_bucket.assets", models.ActionReplace, ""),
+	}, config.GroupByModule)
+
+	want := `app
+  module.web
+    module.web.aws_instance.a
+    module.web.aws_instance.b
+  aws_s3_bucket.assets
+network
+  aws_vpc.main
+`
+	if got := dump(nodes); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	counts := nodes[0].Counts()
+	if counts != (ActionCounts{Create: 1, Delete: 1, Replace: 1}) || counts.Total() != 3 || nodes[0].ResourceCount() != 3 {
+		t.Errorf("app counts %+v, %d resources", counts, nodes[0].ResourceCount())
+	}
+	if nodes[0].Children[0].Parent != nodes[0] {
+		t.Error("module group is not linked to its root")
+	}
+}
+
+func TestDeletedResourceJoinsItsReplacementFile(t *testing.T) {
+	created := change("", "", "aws_instance.new", models.ActionCreate, "/src/compute.tf")
+	created.Index = "a"
+	deleted := change("", "", "aws_instance.old", models.ActionDelete, "")
+	deleted.Index = "a"
+	other := change("", "", "aws_s3_bucket.logs", models.ActionCreate, "/src/storage.tf")
+
+	want := `compute.tf
+  aws_instance.new
+  aws_instance.old
+storage.tf
+  aws_s3_bucket.logs
+`
+	if got := dump(buildTreeNodes([]models.ResourceChange{created, deleted, other}, config.GroupByModule)); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -1,14 +1,18 @@
 package tui
 
 import (
-	"encoding/json"
 	"fmt"
-	"sort"
+	"regexp"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/yourusername/tplan/internal/apply"
+	"github.com/yourusername/tplan/internal/diff"
+	"github.com/yourusername/tplan/internal/ignore"
 	"github.com/yourusername/tplan/internal/models"
+	"github.com/yourusername/tplan/internal/workspace"
 )
 
 // ViewMode represents different view tabs
@@ -20,15 +24,6 @@ const (
 	ViewWarnings
 )
 
-// TreeNode represents a node in the hierarchical tree view
-type TreeNode struct {
-	Resource     models.ResourceChange
-	Expanded     bool
-	Children     []*TreeNode
-	Level        int
-	RenderedLines int // Number of lines this node takes when rendered (including expanded details)
-}
-
 // Model is the Bubble Tea model for the TUI
 type Model struct {
 	plan         *models.PlanResult
@@ -42,281 +37,132 @@ type Model struct {
 	tfCmd        string // terraform or tofu command
 	planFile     string // path to the plan file
 	shouldApply  bool   // whether user pressed 'a' to apply
+	ignoreRules  ignore.Rules
+	showNoise    bool // whether changes matching ignore rules are shown
+	workspaces   []workspace.Info
+	production   []string // production workspaces the plan changes
+	keys         *KeyMap
+	showHelp     bool // whether the key binding overlay is shown
+	helpTop      int  // first line of the help overlay shown
+	yanking      bool // whether the next key selects what to copy
+	disableMouse bool // whether the mouse is left to the terminal
+
+	// Apply confirmation
+	confirming   bool                    // whether the confirmation is shown
+	confirmWord  string                  // what to type to confirm a destructive plan
+	confirmInput string                  // what has been typed so far
+	confirmError string                  // why the last attempt was refused
+	confirmTop   int                     // first destructive resource shown
+	confirmSeen  bool                    // whether every destructive resource has been shown
+	destructive  []models.ResourceChange // resources that will be deleted or replaced
+
+	// Apply progress
+	applyFn          ApplyFunc     // runs the apply inside the TUI; nil quits and applies afterwards
+	applyReportPath  string        // where "r" exports the apply report
+	applying         bool          // whether the apply is running
+	applyEvents      chan tea.Msg  // apply events and the final applyDoneMsg
+	result           *apply.Result // progress of the apply, nil before it starts
+	showApplySummary bool          // whether the apply summary is shown
+	notice           string        // one-off message shown above the help line
+
+	// Layout of the tree and the detail pane, see layout
+	contentHeight int  // lines below the summary
+	sideBySide    bool // whether the details are right of the tree rather than below it
+	treeWidth     int
+	detailWidth   int
+	detailHeight  int // lines of the detail pane, including its title
+
+	// Detail pane
+	detailTop     int       // first detail line shown
+	detailNode    *TreeNode // node the details were scrolled for
+	focusDetail   bool      // whether keys scroll the details rather than move in the tree
+	searching     bool      // whether the search query is being typed
+	searchInput   string    // the query typed so far
+	search        string    // the active search query
+	matchLine     int       // detail line of the current match, -1 if none
+	splitWidth    int       // tree width chosen by dragging the divider, 0 for the default
+	splitHeight   int       // tree height chosen by dragging the divider, 0 for the default
+	dragging      bool      // whether the divider is being dragged
+	inlineDetails bool      // whether resource details are rendered inside the tree (text output)
 }
 
-// Styles for the TUI
-var (
-	// Action colors - text colors based on terraform action
-	createStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true) // Green for creates
-	updateStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true) // Yellow for updates
-	deleteStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)  // Red for deletes
-	replaceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true) // Blue for replaces
-	noopStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("15"))            // White for no changes
-
-	// UI element styles
-	selectedBgStyle = lipgloss.NewStyle().Background(lipgloss.Color("62")) // Just background, no foreground override
-	summaryStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("62")).Padding(0, 1).MarginBottom(1)
-	tabActiveStyle  = lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("15")).Padding(0, 2).Bold(true)
-	tabStyle        = lipgloss.NewStyle().Background(lipgloss.Color("236")).Foreground(lipgloss.Color("250")).Padding(0, 2)
-	helpStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
-	treeLineStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	attributeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-	valueAddStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
-	valueRemStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
-)
+// Options configures the TUI
+type Options struct {
+	TfCmd    string // terraform or tofu command
+	PlanFile string // path to the binary plan file; empty disables apply
 
-// NewModel creates a new TUI model
-func NewModel(plan *models.PlanResult, tfCmd, planFile string) Model {
-	nodes := buildTreeNodes(plan.Resources)
-	return Model{
-		plan:         plan,
-		nodes:        nodes,
-		cursor:       0,
-		viewMode:     ViewChanges,
-		viewportTop:  0,
-		viewportSize: 20, // Will be updated on window size
-		width:        80,
-		height:       24,
-		tfCmd:        tfCmd,
-		planFile:     planFile,
-	}
-}
+	// IgnoreRules hide attribute changes that are considered noise
+	IgnoreRules ignore.Rules
 
-// buildTreeNodes converts resources into a hierarchical tree structure with grouping
-func buildTreeNodes(resources []models.ResourceChange) []*TreeNode {
-	// Filter out resources with no changes (no-op)
-	// Only show resources that are actually changing
-	changingResources := make([]models.ResourceChange, 0)
-	for _, res := range resources {
-		if res.Action != models.ActionNoOp {
-			changingResources = append(changingResources, res)
-		}
-	}
+	// Grouping selects how resources are grouped in the tree (see config.GroupByModule)
+	Grouping string
 
-	// Group resources by module
-	moduleGroups := make(map[string][]models.ResourceChange)
+	// Workspaces describe the terraform context of each planned root
+	Workspaces []workspace.Info
 
-	for _, res := range changingResources {
-		module := res.Module
-		if module == "" {
-			module = "root" // Root module resources
-		}
-		moduleGroups[module] = append(moduleGroups[module], res)
-	}
+	// ProductionPattern matches workspace names that get a warning banner
+	ProductionPattern *regexp.Regexp
 
-	// Sort module names for consistent ordering
-	moduleNames := make([]string, 0, len(moduleGroups))
-	for moduleName := range moduleGroups {
-		moduleNames = append(moduleNames, moduleName)
-	}
-	sort.Strings(moduleNames)
+	// Apply runs terraform apply and streams its progress into the tree. If
+	// nil, the TUI quits after confirmation and the caller applies the plan.
+	Apply ApplyFunc
 
-	// Build tree nodes
-	nodes := make([]*TreeNode, 0)
+	// ApplyReportPath is where the apply report is exported (default: apply-report.md)
+	ApplyReportPath string
 
-	for _, moduleName := range moduleNames {
-		moduleResources := moduleGroups[moduleName]
+	// ShowNoise shows changes matching ignore rules from the start
+	ShowNoise bool
 
-		// Sort resources within module by address
-		sort.Slice(moduleResources, func(i, j int) bool {
-			return moduleResources[i].Address < moduleResources[j].Address
-		})
+	// DisableMouse leaves the mouse to the terminal, e.g. for selecting text
+	DisableMouse bool
 
-		// Special handling for root module - group by file
-		if moduleName == "root" {
-			// Group root resources by file
-			fileGroups := make(map[string][]models.ResourceChange)
-			ungroupedResources := make([]models.ResourceChange, 0)
-
-			// First pass: group resources by file
-			for _, res := range moduleResources {
-				fileName := getResourceFileName(res)
-				if fileName == "unknown.tf" {
-					// Don't group resources we can't find files for yet
-					ungroupedResources = append(ungroupedResources, res)
-				} else {
-					fileGroups[fileName] = append(fileGroups[fileName], res)
-				}
-			}
-
-			// Second pass: try to group ungrouped deleted resources with their replacements
-			remainingUngrouped := make([]models.ResourceChange, 0)
-			for _, res := range ungroupedResources {
-				// Only try to relocate deleted resources
-				if res.Action == models.ActionDelete {
-					// Look for a create operation with the same type and index
-					targetFile := findReplacementFile(res, moduleResources)
-					if targetFile != "" {
-						// Group this deleted resource with its replacement
-						fileGroups[targetFile] = append(fileGroups[targetFile], res)
-					} else {
-						remainingUngrouped = append(remainingUngrouped, res)
-					}
-				} else {
-					remainingUngrouped = append(remainingUngrouped, res)
-				}
-			}
-			ungroupedResources = remainingUngrouped
-
-			// Sort file names
-			fileNames := make([]string, 0, len(fileGroups))
-			for fileName := range fileGroups {
-				fileNames = append(fileNames, fileName)
-			}
-			sort.Strings(fileNames)
-
-			// Create file group nodes
-			for _, fileName := range fileNames {
-				fileResources := fileGroups[fileName]
-
-				// If only one file in root and no ungrouped resources, don't create a grouping node
-				if len(fileGroups) == 1 && len(ungroupedResources) == 0 {
-					for _, res := range fileResources {
-						node := &TreeNode{
-							Resource: res,
-							Expanded: false,
-							Children: []*TreeNode{},
-							Level:    0,
-						}
-						nodes = append(nodes, node)
-					}
-				} else {
-					// Create a file group node
-					if len(fileResources) > 0 {
-						firstRes := fileResources[0]
-						fileNode := &TreeNode{
-							Resource: models.ResourceChange{
-								Address:      fileName,
-								Type:         "file",
-								Name:         fileName,
-								Module:       "root",
-								Mode:         "file",
-								ProviderName: firstRes.ProviderName,
-								Action:       models.ActionNoOp, // File nodes are just grouping, not actions
-								Change: models.Change{
-									Actions: []string{"no-op"},
-								},
-							},
-							Expanded: false,
-							Children: make([]*TreeNode, 0),
-							Level:    0,
-						}
-
-						// Add all resources in this file as children
-						for _, res := range fileResources {
-							childNode := &TreeNode{
-								Resource: res,
-								Expanded: false,
-								Children: []*TreeNode{},
-								Level:    1,
-							}
-							fileNode.Children = append(fileNode.Children, childNode)
-						}
-
-						nodes = append(nodes, fileNode)
-					}
-				}
-			}
-
-			// Add ungrouped resources at the end (no file grouping)
-			for _, res := range ungroupedResources {
-				node := &TreeNode{
-					Resource: res,
-					Expanded: false,
-					Children: []*TreeNode{},
-					Level:    0,
-				}
-				nodes = append(nodes, node)
-			}
-		} else {
-			// Create a module group node for non-root modules
-			if len(moduleResources) > 0 {
-				firstRes := moduleResources[0]
-				moduleNode := &TreeNode{
-					Resource: models.ResourceChange{
-						Address:      moduleName,
-						Type:         "module",
-						Name:         moduleName,
-						Module:       moduleName,
-						Mode:         "module",
-						ProviderName: firstRes.ProviderName,
-						Action:       models.ActionNoOp, // Module nodes are just grouping, not actions
-						Change: models.Change{
-							Actions: []string{"no-op"},
-						},
-					},
-					Expanded: false,
-					Children: make([]*TreeNode, 0),
-					Level:    0,
-				}
-
-				// Add all resources in this module as children
-				for _, res := range moduleResources {
-					childNode := &TreeNode{
-						Resource: res,
-						Expanded: false,
-						Children: []*TreeNode{},
-						Level:    1,
-					}
-					moduleNode.Children = append(moduleNode.Children, childNode)
-				}
-
-				nodes = append(nodes, moduleNode)
-			}
-		}
-	}
-
-	return nodes
+	// Keys are the key bindings; nil uses DefaultKeyMap
+	Keys *KeyMap
 }
 
-// getResourceFileName extracts the file name from a resource
-func getResourceFileName(res models.ResourceChange) string {
-	// If drift info is available, use the file path
-	if res.DriftInfo != nil && res.DriftInfo.FilePath != "" {
-		// Extract just the filename from the path
-		parts := strings.Split(res.DriftInfo.FilePath, "/")
-		return parts[len(parts)-1]
-	}
-
-	// Fallback: return "unknown.tf" if no file info available
-	return "unknown.tf"
-}
+// NewModel creates a new TUI model
+func NewModel(plan *models.PlanResult, opts Options) Model {
+	nodes := buildTreeNodes(plan.Resources, opts.Grouping)
 
-// findReplacementFile finds the file for a deleted resource by looking for a create operation
-// with the same resource type and index (likely a renamed resource)
-func findReplacementFile(deletedRes models.ResourceChange, allResources []models.ResourceChange) string {
-	// Extract the index from the deleted resource
-	deletedIndex := deletedRes.Index
-
-	// Look for a create operation with the same type and index
-	for _, res := range allResources {
-		if res.Action == models.ActionCreate && res.Type == deletedRes.Type {
-			// Check if the index matches
-			if indexMatches(res.Index, deletedIndex) {
-				// Found a potential replacement - get its file
-				fileName := getResourceFileName(res)
-				if fileName != "unknown.tf" {
-					return fileName
-				}
-			}
+	production := make([]string, 0)
+	for _, info := range opts.Workspaces {
+		if info.IsProduction(opts.ProductionPattern) && !contains(production, info.Workspace) {
+			production = append(production, info.Workspace)
 		}
 	}
 
-	return ""
-}
-
-// indexMatches checks if two resource indices match
-func indexMatches(idx1, idx2 interface{}) bool {
-	// Handle nil cases
-	if idx1 == nil && idx2 == nil {
-		return true
-	}
-	if idx1 == nil || idx2 == nil {
-		return false
+	keys := opts.Keys
+	if keys == nil {
+		keys = DefaultKeyMap()
 	}
 
-	// Compare as strings to handle both int and string indices
-	return fmt.Sprintf("%v", idx1) == fmt.Sprintf("%v", idx2)
+	m := Model{
+		plan:         plan,
+		nodes:        nodes,
+		cursor:       0,
+		viewMode:     ViewChanges,
+		viewportTop:  0,
+		viewportSize: 20, // Will be updated by layout
+		width:        80,
+		height:       24,
+		tfCmd:        opts.TfCmd,
+		planFile:     opts.PlanFile,
+		ignoreRules:  opts.IgnoreRules,
+		showNoise:    opts.ShowNoise,
+		workspaces:   opts.Workspaces,
+		production:   production,
+		keys:         keys,
+		disableMouse: opts.DisableMouse,
+		confirmWord:  workspace.ConfirmationWord(plan, opts.Workspaces),
+		destructive:  plan.DestructiveResources(),
+
+		applyFn:         opts.Apply,
+		applyReportPath: opts.ApplyReportPath,
+
+		matchLine: -1,
+	}
+	m.markNoise()
+	return m.layout()
 }
 
 // Init initializes the model
@@ -330,92 +176,216 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.viewportSize = msg.Height - 10 // Account for header, summary, tabs, and help
+		m = m.layout()
+
+	case applyEventMsg, applyDoneMsg, applyTickMsg:
+		return m.updateApply(msg)
+
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+
+	case terminalCopyMsg:
+		return m, copyThroughTerminal(msg)
+
+	case copiedMsg:
+		return m.copied(msg)
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		m.notice = ""
+		if m.showHelp {
+			return m.updateHelp(msg), nil
+		}
+		if m.confirming {
+			return m.updateConfirm(msg)
+		}
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		if m.yanking {
+			return m.updateYank(msg)
+		}
+		if key.Matches(msg, m.keys.Help) {
+			m.showHelp = true
+			return m, nil
+		}
+		if m.showApplySummary {
+			return m.updateApplySummary(msg)
+		}
+		if m.focusDetail && m.viewMode == ViewChanges {
+			if detail, handled := m.updateDetail(msg); handled {
+				return detail, nil
+			}
+		}
+
+		k := m.keys
+		switch {
+		case key.Matches(msg, k.Quit):
+			// Quitting would leave terraform running without its output
+			if m.applying {
+				m.notice = "Apply in progress; wait for it to finish"
+				break
+			}
 			return m, tea.Quit
 
-		case "up", "k":
+		case key.Matches(msg, k.Up):
 			if m.cursor > 0 {
 				m.cursor--
 				m = m.adjustViewport()
 			}
 
-		case "down", "j":
+		case key.Matches(msg, k.Down):
 			visibleNodes := m.getVisibleNodes()
 			if m.cursor < len(visibleNodes)-1 {
 				m.cursor++
 				m = m.adjustViewport()
 			}
 
-		case "enter", " ":
-			visibleNodes := m.getVisibleNodes()
-			if m.cursor < len(visibleNodes) {
-				visibleNodes[m.cursor].Expanded = !visibleNodes[m.cursor].Expanded
-				// Adjust viewport after expanding/collapsing to ensure content is visible
-				m = m.adjustViewport()
+		case key.Matches(msg, k.Toggle):
+			// Groups expand and collapse, resources open the detail pane
+			node := m.selectedNode()
+			if node == nil || m.viewMode != ViewChanges {
+				break
 			}
+			if !node.IsGroup() {
+				m.focusDetail = true
+				break
+			}
+			node.Expanded = !node.Expanded
+			m = m.adjustViewport()
 
-		case "tab":
-			m.viewMode = (m.viewMode + 1) % 3
-			m.cursor = 0
-			m.viewportTop = 0
+		case key.Matches(msg, k.NextView):
+			m = m.switchView((m.viewMode + 1) % 3)
 
-		case "shift+tab":
-			if m.viewMode == 0 {
-				m.viewMode = 2
-			} else {
-				m.viewMode--
-			}
-			m.cursor = 0
-			m.viewportTop = 0
+		case key.Matches(msg, k.PrevView):
+			m = m.switchView((m.viewMode + 2) % 3)
 
-		case "g":
-			// Go to top
+		case key.Matches(msg, k.Top):
 			m.cursor = 0
 			m.viewportTop = 0
 
-		case "G":
-			// Go to bottom
+		case key.Matches(msg, k.Bottom):
 			visibleNodes := m.getVisibleNodes()
 			m.cursor = len(visibleNodes) - 1
 			m = m.adjustViewport()
 
-		case "e":
-			// Expand all
-			for _, node := range m.nodes {
-				node.Expanded = true
+		case key.Matches(msg, k.Collapse):
+			// Collapse the selected group, or jump to the enclosing group
+			node := m.selectedNode()
+			if node == nil {
+				break
+			}
+			if node.IsGroup() && node.Expanded {
+				node.Expanded = false
+			} else if node.Parent != nil {
+				m.cursor = m.indexOfVisible(node.Parent)
+			}
+			m = m.adjustViewport()
+
+		case key.Matches(msg, k.Expand):
+			// Expand the selected group, or open the details of a resource
+			node := m.selectedNode()
+			if node == nil || m.viewMode != ViewChanges {
+				break
+			}
+			if !node.IsGroup() {
+				m.focusDetail = true
+				break
+			}
+			node.Expanded = true
+			m = m.adjustViewport()
+
+		case key.Matches(msg, k.PageUp, k.PageDown, k.HalfPageUp, k.HalfPageDown):
+			// Scroll the details without leaving the tree
+			if m.viewMode == ViewChanges {
+				m, _ = m.updateDetail(msg)
+			}
+
+		case key.Matches(msg, k.Search):
+			// Search the details of the selected node
+			if m.viewMode == ViewChanges && m.selectedNode() != nil {
+				m.focusDetail = true
+				m.searching = true
+				m.searchInput = ""
 			}
 
-		case "c":
-			// Collapse all
+		case key.Matches(msg, k.Yank):
+			// The next key selects what to copy
+			if m.viewMode == ViewChanges && m.selectedNode() != nil {
+				m.yanking = true
+			}
+
+		case key.Matches(msg, k.ExpandAll, k.CollapseAll):
 			for _, node := range m.nodes {
-				node.Expanded = false
+				node.SetExpandedRecursive(key.Matches(msg, k.ExpandAll))
 			}
+			m = m.adjustViewport()
 
-		case "a":
-			// Apply the plan
-			m.shouldApply = true
-			return m, tea.Quit
+		case key.Matches(msg, k.ExpandGroup, k.CollapseGroup):
+			// Expand/collapse everything within the selected group
+			visibleNodes := m.getVisibleNodes()
+			if m.cursor < len(visibleNodes) {
+				group := enclosingGroup(visibleNodes[m.cursor])
+				if group != nil {
+					group.SetExpandedRecursive(key.Matches(msg, k.ExpandGroup))
+					group.Expanded = true
+					m.cursor = m.indexOfVisible(group)
+				}
+				m = m.adjustViewport()
+			}
+
+		case key.Matches(msg, k.Noise):
+			// Toggle changes hidden by ignore rules
+			m.showNoise = !m.showNoise
+			m = m.adjustViewport()
+
+		case key.Matches(msg, k.Apply):
+			// Apply the plan (only possible when viewing a binary plan file)
+			if m.planFile == "" || m.result != nil {
+				break
+			}
+			m.confirming = true
+			m.confirmInput = ""
+			m.confirmTop = 0
+			m.confirmSeen = false
+
+		case key.Matches(msg, k.NextFailure):
+			// Jump to the next resource that failed to apply
+			if m.result != nil {
+				m = m.jumpToFailure()
+			}
+
+		case key.Matches(msg, k.ApplySummary):
+			// Show the apply summary again
+			if m.result != nil && m.result.Done() {
+				m.showApplySummary = true
+			}
 		}
 	}
 
-	return m, nil
+	return m.syncDetail(), nil
 }
 
 // View renders the UI
 func (m Model) View() string {
-	var b strings.Builder
+	if m.showHelp {
+		return m.renderKeyHelp()
+	}
 
-	// Render tabs
-	b.WriteString(m.renderTabs())
-	b.WriteString("\n\n")
+	var b strings.Builder
+	b.WriteString(m.renderHeader())
 
-	// Render summary
-	b.WriteString(m.renderSummary())
-	b.WriteString("\n")
+	// The apply confirmation replaces the content until it is answered
+	if m.confirming {
+		b.WriteString(m.renderConfirm())
+		return b.String()
+	}
+	if m.showApplySummary {
+		b.WriteString(m.renderApplySummary())
+		if m.notice != "" {
+			b.WriteString("\n" + helpStyle.Render(m.notice))
+		}
+		return b.String()
+	}
 
 	// Render content based on view mode
 	switch m.viewMode {
@@ -434,8 +404,39 @@ func (m Model) View() string {
 	return b.String()
 }
 
+// renderHeader renders everything above the content: the production
+// banner, the tabs, the summary and the apply progress
+func (m Model) renderHeader() string {
+	var b strings.Builder
+
+	// Warn loudly before anything else when the plan targets production
+	if len(m.production) > 0 {
+		b.WriteString(m.renderProductionBanner())
+		b.WriteString("\n\n")
+	}
+
+	// Render tabs
+	b.WriteString(m.renderTabs())
+	b.WriteString("\n\n")
+
+	// Render summary
+	b.WriteString(m.renderSummary())
+	b.WriteString("\n")
+
+	if m.result != nil {
+		b.WriteString(m.renderApplyProgress())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
 // renderTabs renders the tab bar
 func (m Model) renderTabs() string {
+	return lipgloss.JoinHorizontal(lipgloss.Top, m.tabs()...)
+}
+
+// tabs renders the Changes, Errors and Warnings tabs in view mode order
+func (m Model) tabs() []string {
 	tabs := []string{}
 
 	changeCount := len(m.plan.Resources)
@@ -464,7 +465,7 @@ func (m Model) renderTabs() string {
 		tabs = append(tabs, tabStyle.Render(warningsTab))
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	return tabs
 }
 
 // renderSummary renders the summary section
@@ -479,85 +480,123 @@ func (m Model) renderSummary() string {
 		m.plan.Summary.ToDelete,
 		replaceStyle.Render("⟳ Replace:"),
 		m.plan.Summary.ToReplace,
-		m.plan.TerraformVersion,
+		m.version(),
 	)
+	if len(m.plan.Roots) > 1 {
+		summary += fmt.Sprintf("  │  Roots: %d", len(m.plan.Roots))
+	}
+	if context := m.renderContext(); context != "" {
+		summary += "\n" + context
+	}
 
 	return summaryStyle.Render(summary)
 }
 
-// renderChangesView renders the changes tree view
-func (m Model) renderChangesView() string {
-	var b strings.Builder
-
-	visibleNodes := m.getVisibleNodes()
-	if len(visibleNodes) == 0 {
-		return helpStyle.Render("No changes to display")
+// version returns the terraform version, prefixed with the binary if known
+func (m Model) version() string {
+	if len(m.workspaces) > 0 && m.workspaces[0].Binary != "" {
+		return m.workspaces[0].Binary + " " + m.plan.TerraformVersion
 	}
+	return m.plan.TerraformVersion
+}
 
-	// Build all lines first, then apply viewport
-	var allLines []string
-	for i, node := range visibleNodes {
-		isSelected := (i == m.cursor)
-
-		// Render the node line
-		line := m.renderTreeNode(node, isSelected)
-		allLines = append(allLines, line)
-
-		// Render expanded details if applicable
-		if node.Expanded && (node.Level == 0 || node.Resource.Type != "module") {
-			detailsContent := m.renderResourceDetails(node)
-			if detailsContent != "" {
-				// Split details into individual lines
-				detailLines := strings.Split(strings.TrimSuffix(detailsContent, "\n"), "\n")
-				allLines = append(allLines, detailLines...)
-			}
+// renderContext renders the workspace, backend, var files and git branch the
+// plan was made with. With several roots, distinct values are listed once.
+func (m Model) renderContext() string {
+	if len(m.workspaces) == 0 {
+		return ""
+	}
+
+	workspaces := make([]string, 0)
+	backends := make([]string, 0)
+	varFiles := make([]string, 0)
+	branches := make([]string, 0)
+	for _, info := range m.workspaces {
+		workspaces = appendUnique(workspaces, info.Workspace)
+		backend := info.Backend
+		if info.BackendKey != "" {
+			backend += " (" + info.BackendKey + ")"
+		}
+		backends = appendUnique(backends, backend)
+		for _, file := range info.VarFiles {
+			varFiles = appendUnique(varFiles, file)
+		}
+		if info.Branch != "" {
+			branches = appendUnique(branches, info.Branch)
 		}
 	}
 
-	// Apply viewport - only show lines within the viewport range
-	totalLines := len(allLines)
-	viewportEnd := m.viewportTop + m.viewportSize
-	if viewportEnd > totalLines {
-		viewportEnd = totalLines
+	parts := []string{
+		"Workspace: " + strings.Join(workspaces, ", "),
+		"Backend: " + strings.Join(backends, ", "),
+	}
+	if len(varFiles) > 0 {
+		parts = append(parts, "Var files: "+strings.Join(varFiles, ", "))
+	}
+	if len(branches) > 0 {
+		parts = append(parts, "Branch: "+strings.Join(branches, ", "))
 	}
+	return contextStyle.Render(strings.Join(parts, "  │  "))
+}
 
-	displayedNodes := 0
-	for i := m.viewportTop; i < viewportEnd; i++ {
-		b.WriteString(allLines[i])
-		b.WriteString("\n")
-		displayedNodes++
+// renderProductionBanner renders the warning shown for production workspaces
+func (m Model) renderProductionBanner() string {
+	label := "workspace"
+	if len(m.production) > 1 {
+		label = "workspaces"
+	}
+	text := fmt.Sprintf("⚠  PRODUCTION %s %s — review carefully before applying  ⚠", label, strings.Join(m.production, ", "))
+	return bannerStyle.Width(m.width).Render(text)
+}
+
+// headerExtraLines returns the lines the workspace context and the
+// production banner add above the tree
+func (m Model) headerExtraLines() int {
+	lines := 0
+	if len(m.workspaces) > 0 {
+		lines++
+	}
+	if len(m.production) > 0 {
+		lines += 2
 	}
+	if m.result != nil {
+		lines++
+	}
+	return lines
+}
 
-	// Scroll indicator
-	if totalLines > m.viewportSize {
-		scrollInfo := fmt.Sprintf("\n%s [lines %d-%d of %d]",
-			helpStyle.Render("Scroll:"),
-			m.viewportTop+1,
-			viewportEnd,
-			totalLines,
-		)
-		b.WriteString(scrollInfo)
+// appendUnique appends value to list unless it is already present
+func appendUnique(list []string, value string) []string {
+	if contains(list, value) {
+		return list
 	}
+	return append(list, value)
+}
 
-	return b.String()
+// contains returns true if list includes value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
 }
 
-// getTotalRenderedLines calculates the total number of lines that would be rendered
-func (m Model) getTotalRenderedLines() int {
+// renderChangesView renders the tree and the detail pane of the selected node
+func (m Model) renderChangesView() string {
 	visibleNodes := m.getVisibleNodes()
-	totalLines := 0
-
-	for _, node := range visibleNodes {
-		totalLines++ // The node line itself
-		if node.Expanded && (node.Level == 0 || node.Resource.Type != "module") {
-			details := m.renderResourceDetails(node)
-			if details != "" {
-				totalLines += strings.Count(details, "\n")
-			}
-		}
+	if len(visibleNodes) == 0 {
+		return helpStyle.Render("No changes to display")
 	}
 
-	return totalLines
+	tree := m.renderTreePane(visibleNodes)
+	details := m.renderDetailPane()
+	if m.sideBySide {
+		divider := treeLineStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", m.contentHeight), "\n"))
+		return lipgloss.JoinHorizontal(lipgloss.Top, tree, " ", divider, " ", details) + "\n"
+	}
+	return tree + "\n" + details + "\n"
 }
 
 // min returns the minimum of two integers
@@ -568,66 +607,70 @@ func min(a, b int) int {
 	return b
 }
 
+// max returns the maximum of two integers
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// clamp limits v to the range from lo to hi; lo wins if the range is empty
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
+
 // renderTreeNode renders a single tree node
 func (m Model) renderTreeNode(node *TreeNode, selected bool) string {
 	// Tree structure
 	prefix := strings.Repeat("  ", node.Level)
 
-	// Expand icon - only show for nodes with children or expandable content
-	expandIcon := " "
-	hasExpandableContent := len(node.Children) > 0 || (node.Resource.Type != "module" && node.Resource.Type != "file" && node.Level == 0)
-	if hasExpandableContent {
-		if node.Expanded {
-			expandIcon = "▾"
-		} else {
-			expandIcon = "▸"
+	// Expand icon - groups expand to their children; resources show their
+	// details in the detail pane unless they are rendered inline
+	expandIcon := "▸"
+	if node.Expanded {
+		expandIcon = "▾"
+	}
+	if !node.IsGroup() && !m.inlineDetails {
+		expandIcon = " "
+	}
+
+	// Group nodes show an icon, their label and aggregated action counts
+	if node.IsGroup() {
+		groupStyle := textStyle
+		icon := "📄 "
+		switch node.Kind {
+		case NodeModule:
+			groupStyle = moduleStyle
+			icon = "📦 "
+		case NodeRoot:
+			groupStyle = rootStyle
+			icon = "🗂  "
 		}
-	}
-
-	// Special handling for module nodes
-	if node.Resource.Type == "module" {
-		moduleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true) // Cyan
-		childInfo := fmt.Sprintf(" [%d resources]", len(node.Children))
+		childInfo := fmt.Sprintf(" [%d resources]", node.ResourceCount())
 
 		if selected {
 			// Apply background only, preserve text colors
 			selector := selectedBgStyle.Render("❯ ")
 			prefixText := selectedBgStyle.Render(prefix)
 			expandText := selectedBgStyle.Render(expandIcon + " ")
-			iconAndName := selectedBgStyle.Copy().Inherit(moduleStyle).Render("📦 " + node.Resource.Address)
+			iconAndName := selectedBgStyle.Copy().Inherit(groupStyle).Render(icon + node.Label)
 			childInfoStyled := selectedBgStyle.Render(childInfo)
-			return selector + prefixText + expandText + iconAndName + childInfoStyled
-		} else {
-			selector := treeLineStyle.Render("  ")
-			prefixText := treeLineStyle.Render(prefix)
-			expandText := treeLineStyle.Render(expandIcon + " ")
-			iconAndName := moduleStyle.Render("📦 " + node.Resource.Address)
-			childInfoStyled := treeLineStyle.Render(childInfo)
-			return selector + prefixText + expandText + iconAndName + childInfoStyled
+			return selector + prefixText + expandText + iconAndName + childInfoStyled + m.renderGroupCounts(node.Counts(), selectedBgStyle)
 		}
-	}
-
-	// Special handling for file nodes
-	if node.Resource.Type == "file" {
-		fileStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("15")) // White
-		childInfo := fmt.Sprintf(" [%d resources]", len(node.Children))
 
-		if selected {
-			// Apply background only, preserve text colors
-			selector := selectedBgStyle.Render("❯ ")
-			prefixText := selectedBgStyle.Render(prefix)
-			expandText := selectedBgStyle.Render(expandIcon + " ")
-			iconAndName := selectedBgStyle.Copy().Inherit(fileStyle).Render("📄 " + node.Resource.Address)
-			childInfoStyled := selectedBgStyle.Render(childInfo)
-			return selector + prefixText + expandText + iconAndName + childInfoStyled
-		} else {
-			selector := treeLineStyle.Render("  ")
-			prefixText := treeLineStyle.Render(prefix)
-			expandText := treeLineStyle.Render(expandIcon + " ")
-			iconAndName := fileStyle.Render("📄 " + node.Resource.Address)
-			childInfoStyled := treeLineStyle.Render(childInfo)
-			return selector + prefixText + expandText + iconAndName + childInfoStyled
-		}
+		selector := treeLineStyle.Render("  ")
+		prefixText := treeLineStyle.Render(prefix)
+		expandText := treeLineStyle.Render(expandIcon + " ")
+		iconAndName := groupStyle.Render(icon + node.Label)
+		childInfoStyled := treeLineStyle.Render(childInfo)
+		return selector + prefixText + expandText + iconAndName + childInfoStyled + m.renderGroupCounts(node.Counts(), lipgloss.NewStyle())
 	}
 
 	// Action icon and style for regular resources
@@ -640,9 +683,13 @@ func (m Model) renderTreeNode(node *TreeNode, selected bool) string {
 
 	// Add child count for parent nodes (dependency-based grouping, if any)
 	childInfo := ""
-	if node.Level == 0 && len(node.Children) > 0 && node.Resource.Type != "module" && node.Resource.Type != "file" {
+	if len(node.Children) > 0 {
 		childInfo = fmt.Sprintf(" (%d related)", len(node.Children))
 	}
+	if node.noiseOnly {
+		childInfo += " [noise]"
+	}
+	status := m.renderApplyStatus(node.Resource)
 
 	if selected {
 		// Apply background only, preserve action text colors
@@ -651,7 +698,7 @@ func (m Model) renderTreeNode(node *TreeNode, selected bool) string {
 		expandText := selectedBgStyle.Render(expandIcon + " ")
 		iconAndName := selectedBgStyle.Copy().Inherit(actionStyle).Render(actionIcon + " " + address)
 		childInfoStyled := selectedBgStyle.Render(childInfo)
-		return selector + prefixText + expandText + iconAndName + childInfoStyled
+		return selector + prefixText + expandText + iconAndName + childInfoStyled + status
 	} else {
 		// Normal rendering with colored resource text based on action
 		selector := treeLineStyle.Render("  ")
@@ -662,36 +709,63 @@ func (m Model) renderTreeNode(node *TreeNode, selected bool) string {
 		iconAndName := actionStyle.Render(actionIcon + " " + address)
 		childInfoStyled := treeLineStyle.Render(childInfo)
 
-		return selector + prefixText + expandText + iconAndName + childInfoStyled
+		return selector + prefixText + expandText + iconAndName + childInfoStyled + status
 	}
 }
 
-// renderResourceDetails renders expanded resource details
-func (m Model) renderResourceDetails(node *TreeNode) string {
+// renderGroupCounts renders the "+3 ~2 -1 ±1" action counts shown on group headers
+func (m Model) renderGroupCounts(counts ActionCounts, base lipgloss.Style) string {
+	parts := []struct {
+		symbol string
+		count  int
+		style  lipgloss.Style
+	}{
+		{"+", counts.Create, createStyle},
+		{"~", counts.Update, updateStyle},
+		{"-", counts.Delete, deleteStyle},
+		{"±", counts.Replace, replaceStyle},
+	}
+
 	var b strings.Builder
-	// Indent details to align with resource name (2 spaces for selection indicator + 2 for content)
-	indent := "    "
+	for _, part := range parts {
+		if part.count == 0 {
+			continue
+		}
+		b.WriteString(base.Render(" "))
+		b.WriteString(base.Copy().Inherit(part.style).Render(fmt.Sprintf("%s%d", part.symbol, part.count)))
+	}
+	return b.String()
+}
 
-	res := node.Resource
+// renderResourceDetails renders the details of a resource expanded inside the tree
+func (m Model) renderResourceDetails(node *TreeNode) string {
+	// Indent details to align with resource name (2 spaces for selection indicator + 2 for content)
+	indent := "    " + strings.Repeat("  ", node.Level)
+	return m.renderDetails(node.Resource, indent) + "\n"
+}
 
-	// Get the action style for this resource
-	action := string(res.Action)
-	_, actionStyle := getActionIconAndStyle(action)
+// renderDetails renders the metadata, file and git information and
+// attribute diff of a resource
+func (m Model) renderDetails(res models.ResourceChange, indent string) string {
+	var b strings.Builder
 
-	// White style for resource metadata
-	whiteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("15")) // White
+	// Resource metadata
+	b.WriteString(fmt.Sprintf("%sType: %s\n", indent, textStyle.Render(res.Type)))
+	b.WriteString(fmt.Sprintf("%s  Provider: %s\n", indent, textStyle.Render(res.ProviderName)))
+	b.WriteString(fmt.Sprintf("%s  Mode: %s\n", indent, textStyle.Render(res.Mode)))
 
-	// Resource metadata - use white color
-	b.WriteString(fmt.Sprintf("%sType: %s\n", indent, whiteStyle.Render(res.Type)))
-	b.WriteString(fmt.Sprintf("%s  Provider: %s\n", indent, whiteStyle.Render(res.ProviderName)))
-	b.WriteString(fmt.Sprintf("%s  Mode: %s\n", indent, whiteStyle.Render(res.Mode)))
+	// Errors of a failed apply come first, next to the resource they belong to
+	if diagnostics := m.renderApplyDiagnostics(res, indent); diagnostics != "" {
+		b.WriteString("\n")
+		b.WriteString(diagnostics)
+	}
 
 	// Show file and git information if available
 	if res.DriftInfo != nil && res.DriftInfo.FilePath != "" {
 		b.WriteString("\n")
 
 		// Always show file path - use white for header
-		b.WriteString(fmt.Sprintf("%s%s\n", indent, whiteStyle.Render("File Information:")))
+		b.WriteString(fmt.Sprintf("%s%s\n", indent, textStyle.Render("File Information:")))
 
 		// Show git info if available (IsValid checks for full git info)
 		if res.DriftInfo.IsValid() {
@@ -700,354 +774,38 @@ func (m Model) renderResourceDetails(node *TreeNode) string {
 				res.DriftInfo.AuthorName,
 				res.DriftInfo.AuthorEmail,
 				res.DriftInfo.CommitDate.Format("2006-01-02 15:04:05"))
-			b.WriteString(fmt.Sprintf("%s  %s\n", indent, whiteStyle.Render(authorDateLine)))
+			b.WriteString(fmt.Sprintf("%s  %s\n", indent, textStyle.Render(authorDateLine)))
 
 			// File, Branch, and Commit all on the same line
 			fileInfoLine := fmt.Sprintf("File: %s  Branch: %s  Commit: %s",
 				res.DriftInfo.FilePath,
 				res.DriftInfo.BranchName,
 				res.DriftInfo.ShortCommitID())
-			b.WriteString(fmt.Sprintf("%s  %s\n", indent, whiteStyle.Render(fileInfoLine)))
+			b.WriteString(fmt.Sprintf("%s  %s\n", indent, textStyle.Render(fileInfoLine)))
 		} else {
 			// If no git info, just show the file path
-			b.WriteString(fmt.Sprintf("%s  %s\n", indent, whiteStyle.Render(fmt.Sprintf("File: %s", res.DriftInfo.FilePath))))
+			b.WriteString(fmt.Sprintf("%s  %s\n", indent, textStyle.Render(fmt.Sprintf("File: %s", res.DriftInfo.FilePath))))
 		}
 
 		if res.DriftInfo.HasUncommittedChanges {
-			warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")) // Yellow
-			b.WriteString(fmt.Sprintf("%s  %s\n", indent, warnStyle.Render("Status: Has uncommitted changes")))
+			b.WriteString(fmt.Sprintf("%s  %s\n", indent, warningStyle.Render("Status: Has uncommitted changes")))
 		}
 		b.WriteString("\n")
 	}
 
 	// Show attribute changes
-	if action == "create" {
-		b.WriteString(m.renderAttributes(indent, res.Change.After, "  ", actionStyle))
-	} else if action == "delete" {
-		b.WriteString(m.renderAttributes(indent, res.Change.Before, "  ", actionStyle))
-	} else if action == "update" || action == "replace" {
-		b.WriteString(m.renderAttributeDiff(indent, res.Change.Before, res.Change.After))
-	}
-
-	// Add a blank line after expanded details to separate from next resource
-	b.WriteString("\n")
-
-	return b.String()
-}
-
-// renderAttributes renders attribute map with indentation
-func (m Model) renderAttributes(baseIndent string, attrs map[string]interface{}, subIndent string, actionStyle lipgloss.Style) string {
-	var b strings.Builder
-
-	// Sort keys to ensure consistent ordering
-	keys := make([]string, 0, len(attrs))
-	for k := range attrs {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Show all attributes with proper nesting
-	for _, k := range keys {
-		v := attrs[k]
-		m.renderValue(&b, baseIndent, k, v, actionStyle, 0)
-	}
-
-	return b.String()
-}
-
-// renderValue renders a single value with proper handling of nested structures
-func (m Model) renderValue(b *strings.Builder, indent string, key string, value interface{}, style lipgloss.Style, depth int) {
-	// Limit nesting depth to prevent excessive output
-	if depth > 5 {
-		b.WriteString(style.Render(fmt.Sprintf("%s%s = <deeply nested>\n", indent, key)))
-		return
-	}
-
-	switch v := value.(type) {
-	case map[string]interface{}:
-		// Nested object
-		if len(v) == 0 {
-			b.WriteString(style.Render(fmt.Sprintf("%s%s = {}\n", indent, key)))
-		} else {
-			b.WriteString(style.Render(fmt.Sprintf("%s%s = {\n", indent, key)))
-			// Sort nested keys
-			nestedKeys := make([]string, 0, len(v))
-			for k := range v {
-				nestedKeys = append(nestedKeys, k)
-			}
-			sort.Strings(nestedKeys)
-			for _, nk := range nestedKeys {
-				m.renderValue(b, indent+"  ", nk, v[nk], style, depth+1)
-			}
-			b.WriteString(style.Render(fmt.Sprintf("%s}\n", indent)))
-		}
-	case []interface{}:
-		// Array
-		if len(v) == 0 {
-			b.WriteString(style.Render(fmt.Sprintf("%s%s = []\n", indent, key)))
-		} else {
-			b.WriteString(style.Render(fmt.Sprintf("%s%s = [\n", indent, key)))
-			for i, item := range v {
-				m.renderValue(b, indent+"  ", fmt.Sprintf("[%d]", i), item, style, depth+1)
-			}
-			b.WriteString(style.Render(fmt.Sprintf("%s]\n", indent)))
-		}
-	case string:
-		// String value - show with quotes
-		b.WriteString(style.Render(fmt.Sprintf("%s%s = %q\n", indent, key, v)))
-	case nil:
-		// Null value
-		b.WriteString(style.Render(fmt.Sprintf("%s%s = null\n", indent, key)))
-	case bool:
-		// Boolean value
-		b.WriteString(style.Render(fmt.Sprintf("%s%s = %t\n", indent, key, v)))
-	case float64:
-		// Number - check if it's an integer
-		if v == float64(int64(v)) {
-			b.WriteString(style.Render(fmt.Sprintf("%s%s = %d\n", indent, key, int64(v))))
-		} else {
-			b.WriteString(style.Render(fmt.Sprintf("%s%s = %g\n", indent, key, v)))
-		}
-	default:
-		// Fallback for other types
-		valueStr := fmt.Sprintf("%v", v)
-		if len(valueStr) > 100 {
-			valueStr = valueStr[:97] + "..."
-		}
-		b.WriteString(style.Render(fmt.Sprintf("%s%s = %s\n", indent, key, valueStr)))
-	}
-}
-
-// renderAttributeDiff renders before/after attribute differences
-func (m Model) renderAttributeDiff(baseIndent string, before, after map[string]interface{}) string {
-	var b strings.Builder
-
-	// Collect all keys from both maps and sort them
-	keySet := make(map[string]bool)
-	for k := range before {
-		keySet[k] = true
-	}
-	for k := range after {
-		keySet[k] = true
+	changeDiff := m.computeDiff(res)
+	if changeDiff.NoiseOnly() {
+		b.WriteString(fmt.Sprintf("%s%s\n", indent, helpStyle.Render("Noise only: every change matches an ignore rule")))
 	}
-
-	keys := make([]string, 0, len(keySet))
-	for k := range keySet {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Process all attributes with proper nesting
-	for _, k := range keys {
-		afterVal, existsAfter := after[k]
-		beforeVal, existsBefore := before[k]
-
-		if !existsBefore && existsAfter {
-			// New attribute - show with + prefix
-			m.renderDiffValue(&b, baseIndent, "+", k, afterVal, valueAddStyle, 0)
-		} else if existsBefore && !existsAfter {
-			// Removed attribute - show with - prefix
-			m.renderDiffValue(&b, baseIndent, "-", k, beforeVal, valueRemStyle, 0)
-		} else {
-			// Check if changed
-			m.renderDiffComparison(&b, baseIndent, k, beforeVal, afterVal, 0)
-		}
+	b.WriteString(m.renderAttributeDiff(indent, changeDiff))
+	if hidden := changeDiff.IgnoredChanges(); hidden > 0 && !m.showNoise {
+		b.WriteString(fmt.Sprintf("%s  %s\n", indent, helpStyle.Render(fmt.Sprintf("… %d ignored change(s) hidden (n: show noise)", hidden))))
 	}
 
 	return b.String()
 }
 
-// renderDiffValue renders a value in a diff context (added or removed)
-func (m Model) renderDiffValue(b *strings.Builder, indent string, prefix string, key string, value interface{}, style lipgloss.Style, depth int) {
-	if depth > 5 {
-		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = <deeply nested>\n", indent, prefix, key)))
-		return
-	}
-
-	switch v := value.(type) {
-	case map[string]interface{}:
-		if len(v) == 0 {
-			b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = ", indent, prefix, key)))
-			b.WriteString(style.Render("{}"))
-			b.WriteString("\n")
-		} else {
-			b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = {\n", indent, prefix, key)))
-			nestedKeys := make([]string, 0, len(v))
-			for k := range v {
-				nestedKeys = append(nestedKeys, k)
-			}
-			sort.Strings(nestedKeys)
-			for _, nk := range nestedKeys {
-				m.renderDiffValue(b, indent+"  ", prefix, nk, v[nk], style, depth+1)
-			}
-			b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s }\n", indent, prefix)))
-		}
-	case []interface{}:
-		if len(v) == 0 {
-			b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = ", indent, prefix, key)))
-			b.WriteString(style.Render("[]"))
-			b.WriteString("\n")
-		} else {
-			b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = [\n", indent, prefix, key)))
-			for i, item := range v {
-				m.renderDiffValue(b, indent+"  ", prefix, fmt.Sprintf("[%d]", i), item, style, depth+1)
-			}
-			b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s ]\n", indent, prefix)))
-		}
-	case string:
-		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = ", indent, prefix, key)))
-		b.WriteString(style.Render(fmt.Sprintf("%q", v)))
-		b.WriteString("\n")
-	case nil:
-		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = ", indent, prefix, key)))
-		b.WriteString(style.Render("null"))
-		b.WriteString("\n")
-	case bool:
-		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = ", indent, prefix, key)))
-		b.WriteString(style.Render(fmt.Sprintf("%t", v)))
-		b.WriteString("\n")
-	case float64:
-		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = ", indent, prefix, key)))
-		if v == float64(int64(v)) {
-			b.WriteString(style.Render(fmt.Sprintf("%d", int64(v))))
-		} else {
-			b.WriteString(style.Render(fmt.Sprintf("%g", v)))
-		}
-		b.WriteString("\n")
-	default:
-		valueStr := fmt.Sprintf("%v", v)
-		if len(valueStr) > 100 {
-			valueStr = valueStr[:97] + "..."
-		}
-		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s = ", indent, prefix, key)))
-		b.WriteString(style.Render(valueStr))
-		b.WriteString("\n")
-	}
-}
-
-// renderDiffComparison compares before and after values and renders the diff
-func (m Model) renderDiffComparison(b *strings.Builder, indent string, key string, before, after interface{}, depth int) {
-	if depth > 5 {
-		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  ~ %s = <deeply nested>\n", indent, key)))
-		return
-	}
-
-	// Deep comparison using JSON-like comparison
-	beforeStr := fmt.Sprintf("%v", before)
-	afterStr := fmt.Sprintf("%v", after)
-
-	if beforeStr == afterStr {
-		// No change
-		return
-	}
-
-	// Check if both values are strings - might be JSON or long text
-	beforeString, beforeIsString := before.(string)
-	afterString, afterIsString := after.(string)
-
-	if beforeIsString && afterIsString {
-		// For strings longer than 60 chars, show them on separate lines (like terraform plan)
-		if len(beforeString) > 60 || len(afterString) > 60 {
-			// Render the attribute label without styling the indent
-			b.WriteString(indent)
-			b.WriteString(attributeStyle.Render(fmt.Sprintf("  ~ %s:\n", key)))
-
-			// Try to pretty-print if it's JSON
-			beforeFormatted := m.tryPrettyJSON(beforeString)
-			afterFormatted := m.tryPrettyJSON(afterString)
-
-			// Split into lines
-			beforeLines := strings.Split(beforeFormatted, "\n")
-			afterLines := strings.Split(afterFormatted, "\n")
-
-			// Show side-by-side diff
-			m.renderSideBySideDiff(b, indent, beforeLines, afterLines)
-
-			return
-		}
-	}
-
-	// For short values or non-strings, show inline
-	b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  ~ %s: ", indent, key)))
-
-	// Format before value
-	switch v := before.(type) {
-	case string:
-		b.WriteString(valueRemStyle.Render(fmt.Sprintf("%q", v)))
-	case nil:
-		b.WriteString(valueRemStyle.Render("null"))
-	case bool:
-		b.WriteString(valueRemStyle.Render(fmt.Sprintf("%t", v)))
-	case float64:
-		if v == float64(int64(v)) {
-			b.WriteString(valueRemStyle.Render(fmt.Sprintf("%d", int64(v))))
-		} else {
-			b.WriteString(valueRemStyle.Render(fmt.Sprintf("%g", v)))
-		}
-	default:
-		if len(beforeStr) > 60 {
-			beforeStr = beforeStr[:57] + "..."
-		}
-		b.WriteString(valueRemStyle.Render(beforeStr))
-	}
-
-	b.WriteString(attributeStyle.Render(" → "))
-
-	// Format after value
-	switch v := after.(type) {
-	case string:
-		b.WriteString(valueAddStyle.Render(fmt.Sprintf("%q", v)))
-	case nil:
-		b.WriteString(valueAddStyle.Render("null"))
-	case bool:
-		b.WriteString(valueAddStyle.Render(fmt.Sprintf("%t", v)))
-	case float64:
-		if v == float64(int64(v)) {
-			b.WriteString(valueAddStyle.Render(fmt.Sprintf("%d", int64(v))))
-		} else {
-			b.WriteString(valueAddStyle.Render(fmt.Sprintf("%g", v)))
-		}
-	default:
-		if len(afterStr) > 60 {
-			afterStr = afterStr[:57] + "..."
-		}
-		b.WriteString(valueAddStyle.Render(afterStr))
-	}
-
-	b.WriteString("\n")
-}
-
-// wrapString wraps a long string into multiple lines at word boundaries
-func (m Model) wrapString(s string, maxLen int) []string {
-	if len(s) <= maxLen {
-		return []string{s}
-	}
-
-	var lines []string
-	remaining := s
-
-	for len(remaining) > 0 {
-		if len(remaining) <= maxLen {
-			lines = append(lines, remaining)
-			break
-		}
-
-		// Try to break at a reasonable point (space, comma, etc.)
-		breakPoint := maxLen
-		for i := maxLen; i > maxLen-20 && i > 0; i-- {
-			if remaining[i] == ' ' || remaining[i] == ',' || remaining[i] == ';' {
-				breakPoint = i + 1
-				break
-			}
-		}
-
-		lines = append(lines, remaining[:breakPoint])
-		remaining = remaining[breakPoint:]
-	}
-
-	return lines
-}
-
 // renderErrorsView renders the errors view
 func (m Model) renderErrorsView() string {
 	if len(m.plan.Errors) == 0 {
@@ -1108,24 +866,71 @@ func (m Model) renderWarningsView() string {
 
 // renderHelp renders the help text
 func (m Model) renderHelp() string {
-	help := "↑/↓: Navigate  Enter/Space: Expand/Collapse  Tab: Switch View  e: Expand All  c: Collapse All  g/G: Top/Bottom  a: Apply  q: Quit"
+	k := m.keys
+	var help string
+	switch {
+	case m.searching:
+		help = "Type to search the details  " + helpLine(entry("Find", k.SearchFind), entry("Cancel", k.SearchCancel))
+	case m.yanking:
+		help = "Copy  " + helpLine(entry("Address", k.YankAddress), entry("-target", k.YankTarget), entry("State Show", k.YankStateShow),
+			entry("Before", k.YankBefore), entry("After", k.YankAfter), entry("Changes", k.YankDiff)) + "  Any other key: Cancel"
+	case m.focusDetail && m.viewMode == ViewChanges:
+		help = helpLine(entry("Scroll", k.ScrollUp, k.ScrollDown), entry("Page", k.PageUp, k.PageDown), entry("Top/Bottom", k.DetailTop, k.DetailBottom),
+			entry("Search", k.Search), entry("Next/Previous Match", k.NextMatch, k.PrevMatch), entry("Back to Tree", k.Back), entry("Help", k.Help), entry("Quit", k.Quit))
+	case m.applying:
+		help = helpLine(entry("Navigate", k.Up, k.Down), entry("Expand/Collapse", k.Toggle), entry("Next Failure", k.NextFailure)) + "  (applying…)"
+	case m.result != nil:
+		help = helpLine(entry("Navigate", k.Up, k.Down), entry("Expand/Collapse", k.Toggle), entry("Next Failure", k.NextFailure),
+			entry("Apply Summary", k.ApplySummary), entry("Help", k.Help), entry("Quit", k.Quit))
+	default:
+		help = helpLine(entry("Navigate", k.Up, k.Down), entry("Expand/Details", k.Toggle, k.Expand), entry("Collapse", k.Collapse),
+			entry("Switch View", k.NextView), entry("Expand/Collapse All", k.ExpandAll, k.CollapseAll), entry("Expand/Collapse Group", k.ExpandGroup, k.CollapseGroup),
+			entry("Scroll Details", k.PageUp, k.PageDown), entry("Search Details", k.Search), entry("Top/Bottom", k.Top, k.Bottom),
+			entry("Show Noise", k.Noise), entry("Apply", k.Apply), entry("Help", k.Help), entry("Quit", k.Quit))
+	}
+	if m.notice != "" {
+		return helpStyle.Render(m.notice) + "\n" + helpStyle.Render(help)
+	}
 	return helpStyle.Render(help)
 }
 
+// switchView shows another tab, starting at its top
+func (m Model) switchView(mode ViewMode) Model {
+	m.viewMode = mode
+	m.cursor = 0
+	m.viewportTop = 0
+	return m
+}
+
 // getVisibleNodes returns all currently visible nodes (considering expand/collapse state)
 func (m Model) getVisibleNodes() []*TreeNode {
-	visible := make([]*TreeNode, 0)
-	for _, node := range m.nodes {
-		visible = append(visible, node)
-		// If node is expanded, add its children
-		if node.Expanded && len(node.Children) > 0 {
-			visible = append(visible, node.Children...)
+	return flattenVisible(m.nodes, make([]*TreeNode, 0))
+}
+
+// indexOfVisible returns the cursor position of a node, or the current cursor if it is hidden
+func (m Model) indexOfVisible(target *TreeNode) int {
+	for i, node := range m.getVisibleNodes() {
+		if node == target {
+			return i
 		}
 	}
-	return visible
+	return m.cursor
 }
 
-// adjustViewport adjusts the viewport to keep the cursor visible
+// enclosingGroup returns the node itself if it is a group, otherwise its parent group
+func enclosingGroup(node *TreeNode) *TreeNode {
+	if node.IsGroup() {
+		return node
+	}
+	return node.Parent
+}
+
+// showsDetails returns true if the node renders resource details below its line
+func showsDetails(node *TreeNode) bool {
+	return node.Expanded && !node.IsGroup()
+}
+
+// adjustViewport adjusts the tree viewport to keep the cursor visible
 func (m Model) adjustViewport() Model {
 	visibleNodes := m.getVisibleNodes()
 	if len(visibleNodes) == 0 {
@@ -1141,47 +946,17 @@ func (m Model) adjustViewport() Model {
 		m.cursor = 0
 	}
 
-	// Calculate the line position of the cursor (start of the cursor node)
-	cursorLineStart := 0
-	for i := 0; i < m.cursor && i < len(visibleNodes); i++ {
-		node := visibleNodes[i]
-		cursorLineStart++ // The node line itself
-		if node.Expanded && (node.Level == 0 || node.Resource.Type != "module") {
-			details := m.renderResourceDetails(node)
-			if details != "" {
-				cursorLineStart += strings.Count(details, "\n")
-			}
-		}
+	// Every node takes one line; the details have a pane of their own
+	if m.cursor < m.viewportTop {
+		m.viewportTop = m.cursor
+	} else if m.cursor >= m.viewportTop+m.viewportSize {
+		m.viewportTop = m.cursor - m.viewportSize + 1
 	}
 
-	// Calculate the total lines for the current cursor node (including expanded content)
-	currentNode := visibleNodes[m.cursor]
-	currentNodeLines := 1 // The node line itself
-	if currentNode.Expanded && (currentNode.Level == 0 || currentNode.Resource.Type != "module") {
-		details := m.renderResourceDetails(currentNode)
-		if details != "" {
-			currentNodeLines += strings.Count(details, "\n")
-		}
-	}
-	cursorLineEnd := cursorLineStart + currentNodeLines - 1
-
-	// Adjust viewport to keep cursor visible
-	if cursorLineStart < m.viewportTop {
-		// Cursor start is above viewport, scroll up to show the start
-		m.viewportTop = cursorLineStart
-	} else if cursorLineEnd >= m.viewportTop+m.viewportSize {
-		// Cursor end is below viewport, scroll down to show as much as possible
-		// Try to show the entire node if it fits, otherwise show from the start
-		if currentNodeLines <= m.viewportSize {
-			// Node fits in viewport, position it at the bottom
-			m.viewportTop = cursorLineEnd - m.viewportSize + 1
-		} else {
-			// Node is larger than viewport, show from the start
-			m.viewportTop = cursorLineStart
-		}
+	// Don't leave the pane half empty after collapsing near the bottom
+	if maxTop := len(visibleNodes) - m.viewportSize; m.viewportTop > maxTop {
+		m.viewportTop = maxTop
 	}
-
-	// Ensure viewport doesn't go negative
 	if m.viewportTop < 0 {
 		m.viewportTop = 0
 	}
@@ -1207,20 +982,25 @@ func (m Model) countActions() (creates, updates, deletes, replaces int) {
 	return
 }
 
-// getActionIconAndStyle returns the icon and style for an action
+// getActionIconAndStyle returns the icon and style for an action. With
+// action labels, the icon is followed by the action name.
 func getActionIconAndStyle(action string) (string, lipgloss.Style) {
+	icon, style := "•", noopStyle
 	switch action {
 	case "create":
-		return "✚", createStyle
+		icon, style = "✚", createStyle
 	case "update":
-		return "~", updateStyle
+		icon, style = "~", updateStyle
 	case "delete":
-		return "✖", deleteStyle
+		icon, style = "✖", deleteStyle
 	case "replace":
-		return "⟳", replaceStyle
-	default:
-		return "•", noopStyle
+		icon, style = "⟳", replaceStyle
+	}
+	if actionLabels {
+		// Pad to the longest action so addresses line up
+		icon = fmt.Sprintf("%s %-7s", icon, action)
 	}
+	return icon, style
 }
 
 // renderSideBySideDiff renders two sets of lines side-by-side
@@ -1266,23 +1046,117 @@ func (m Model) renderSideBySideDiff(b *strings.Builder, indent string, beforeLin
 	}
 }
 
-// tryPrettyJSON attempts to parse and pretty-print JSON, returns original string if not JSON
-func (m Model) tryPrettyJSON(s string) string {
-	// Try to parse as JSON
-	var jsonData interface{}
-	if err := json.Unmarshal([]byte(s), &jsonData); err != nil {
-		// Not valid JSON, return original with wrapping
-		return m.wrapStringSimple(s, 100)
+// renderAttributeDiff renders the attribute diff tree of a change
+func (m Model) renderAttributeDiff(baseIndent string, root *diff.Node) string {
+	var b strings.Builder
+	for _, child := range root.Children {
+		m.renderDiffNode(&b, baseIndent, child, 0)
 	}
+	return b.String()
+}
 
-	// Pretty-print the JSON with 2-space indentation
-	prettyJSON, err := json.MarshalIndent(jsonData, "", "  ")
-	if err != nil {
-		// Failed to marshal, return original
-		return m.wrapStringSimple(s, 100)
+// markNoise flags the resources whose changes all match ignore rules, so the
+// tree does not diff every visible resource on each frame. It has to run
+// again whenever the ignore rules change.
+func (m Model) markNoise() {
+	for _, root := range m.nodes {
+		root.walk(func(node *TreeNode) {
+			node.noiseOnly = node.Kind == NodeResource && len(m.ignoreRules) > 0 &&
+				m.computeDiff(node.Resource).NoiseOnly()
+		})
+	}
+}
+
+// computeDiff computes the attribute diff of a resource with ignore rules applied
+func (m Model) computeDiff(res models.ResourceChange) *diff.Node {
+	changeDiff := diff.Compute(res.Change)
+	m.ignoreRules.Apply(res.Type, changeDiff)
+	return changeDiff
+}
+
+// renderDiffNode renders a single node of the diff tree with its changed children
+func (m Model) renderDiffNode(b *strings.Builder, indent string, node *diff.Node, depth int) {
+	if !node.Changed() || (node.Ignored && !m.showNoise) {
+		return
+	}
+
+	label := fmt.Sprintf("%s  %s %s", indent, node.Kind, node.Key)
+
+	// Revealed noise is rendered dimmed so it stands apart from real changes
+	if node.Ignored && (!node.HasChildren() || node.Unknown || node.Sensitive) {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("%s: %s → %s (ignored)", label, truncateValue(node.BeforeString(), 60), truncateValue(node.AfterString(), 60))))
+		b.WriteString("\n")
+		return
+	}
+
+	// Limit nesting depth to prevent excessive output (documents such as
+	// Kubernetes manifests nest deeper than regular attributes)
+	if depth > 10 {
+		b.WriteString(attributeStyle.Render(label + " = <deeply nested>"))
+		b.WriteString("\n")
+		return
+	}
+
+	// Containers with changes are rendered as blocks with their changed children
+	if node.HasChildren() && !node.Unknown && !node.Sensitive {
+		open, close := "{", "}"
+		switch node.Type {
+		case diff.TypeList:
+			open, close = "[", "]"
+		case diff.TypeDocument:
+			open, close = node.Format+"encode(", ")"
+		}
+		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s = %s", label, open)))
+		b.WriteString("\n")
+		for _, child := range node.Children {
+			m.renderDiffNode(b, indent+"  ", child, depth+1)
+		}
+		b.WriteString(attributeStyle.Render(fmt.Sprintf("%s  %s %s", indent, node.Kind, close)))
+		b.WriteString("\n")
+		return
+	}
+
+	switch node.Kind {
+	case diff.Added:
+		b.WriteString(attributeStyle.Render(label + " = "))
+		b.WriteString(valueAddStyle.Render(node.AfterString()))
+		b.WriteString("\n")
+		return
+	case diff.Removed:
+		b.WriteString(attributeStyle.Render(label + " = "))
+		b.WriteString(valueRemStyle.Render(node.BeforeString()))
+		b.WriteString("\n")
+		return
+	}
+
+	// For strings longer than 60 chars, show them on separate lines (like terraform plan)
+	beforeString, beforeIsString := node.Before.(string)
+	afterString, afterIsString := node.After.(string)
+	if beforeIsString && afterIsString && !node.Sensitive && !node.Unknown && (len(beforeString) > 60 || len(afterString) > 60) {
+		b.WriteString(indent)
+		b.WriteString(attributeStyle.Render(fmt.Sprintf("  ~ %s:", node.Key)))
+		b.WriteString("\n")
+
+		beforeLines := strings.Split(m.wrapStringSimple(beforeString, 100), "\n")
+		afterLines := strings.Split(m.wrapStringSimple(afterString, 100), "\n")
+		m.renderSideBySideDiff(b, indent, beforeLines, afterLines)
+		return
 	}
 
-	return string(prettyJSON)
+	// For short values or non-strings, show inline
+	b.WriteString(attributeStyle.Render(label + ": "))
+	b.WriteString(valueRemStyle.Render(truncateValue(node.BeforeString(), 60)))
+	b.WriteString(attributeStyle.Render(" → "))
+	b.WriteString(valueAddStyle.Render(truncateValue(node.AfterString(), 60)))
+	b.WriteString("\n")
+}
+
+// truncateValue shortens a rendered value to a maximum length
+func truncateValue(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
 }
 
 // wrapStringSimple wraps a string at a maximum length without JSON parsing
@@ -1317,9 +1191,14 @@ func (m Model) wrapStringSimple(s string, maxLen int) string {
 	return result.String()
 }
 
-// Run starts the TUI application and returns whether to apply
-func Run(plan *models.PlanResult, tfCmd, planFile string) (bool, error) {
-	p := tea.NewProgram(NewModel(plan, tfCmd, planFile), tea.WithAltScreen())
+// Run starts the TUI application and returns whether the caller should apply
+// the plan, which is only the case when Options.Apply is nil
+func Run(plan *models.PlanResult, opts Options) (bool, error) {
+	programOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	if !opts.DisableMouse {
+		programOpts = append(programOpts, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(NewModel(plan, opts), programOpts...)
 	finalModel, err := p.Run()
 	if err != nil {
 		return false, err
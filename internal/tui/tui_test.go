@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/config"
+	"github.com/yourusername/tplan/internal/ignore"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// update returns a resource update changing the given attributes from "a" to "b"
+func update(address string, attributes ...string) models.ResourceChange {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	for _, attr := range attributes {
+		before[attr] = "a"
+		after[attr] = "b"
+	}
+	return models.ResourceChange{
+		Address: address,
+		Type:    strings.Split(address, ".")[0],
+		Action:  models.ActionUpdate,
+		Change:  models.Change{Before: before, After: after},
+	}
+}
+
+func TestMarkNoise(t *testing.T) {
+	plan := &models.PlanResult{Resources: []models.ResourceChange{
+		update("aws_s3_bucket.logs", "tags_all"),
+		update("aws_s3_bucket.data", "tags_all", "acl"),
+		update("aws_instance.web", "tags_all"),
+	}}
+
+	tests := []struct {
+		name  string
+		rules ignore.Rules
+		want  map[string]bool
+	}{
+		{
+			name: "no rules",
+			want: map[string]bool{},
+		},
+		{
+			name:  "rule for every type",
+			rules: ignore.Rules{{ResourceType: "*", Attribute: "tags_all"}},
+			want:  map[string]bool{"aws_s3_bucket.logs": true, "aws_instance.web": true},
+		},
+		{
+			name:  "rule for one type",
+			rules: ignore.Rules{{ResourceType: "aws_s3_*", Attribute: "tags_all"}},
+			want:  map[string]bool{"aws_s3_bucket.logs": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(plan, Options{IgnoreRules: tt.rules, Grouping: config.GroupNone})
+			for _, root := range m.nodes {
+				root.walk(func(node *TreeNode) {
+					if node.Kind != NodeResource {
+						return
+					}
+					if got := node.noiseOnly; got != tt.want[node.Resource.Address] {
+						t.Errorf("%s: noiseOnly = %t, want %t", node.Resource.Address, got, !got)
+					}
+					line := m.renderTreeNode(node, false)
+					if strings.Contains(line, "[noise]") != tt.want[node.Resource.Address] {
+						t.Errorf("%s: rendered as %q", node.Resource.Address, line)
+					}
+				})
+			}
+		})
+	}
+}
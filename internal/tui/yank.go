@@ -0,0 +1,261 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/tplan/internal/clipboard"
+	"github.com/yourusername/tplan/internal/diff"
+)
+
+// copiedMsg reports the result of copying to the clipboard
+type copiedMsg struct {
+	what   string
+	method string
+	err    error
+}
+
+// terminalCopyMsg asks to copy text through the terminal, as no clipboard
+// command did
+type terminalCopyMsg struct {
+	text string
+	what string
+}
+
+// clipboardTimeout is how long the clipboard commands may take altogether
+const clipboardTimeout = 2 * time.Second
+
+// plainArgument matches addresses that need no quoting in a shell
+var plainArgument = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// updateYank handles the key after Yank, which selects what to copy of the
+// selected node. Any other key cancels.
+func (m Model) updateYank(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.yanking = false
+	node := m.selectedNode()
+	if node == nil {
+		return m, nil
+	}
+
+	k := m.keys
+	var what, text string
+	var err error
+	switch {
+	case key.Matches(msg, k.YankAddress, k.YankTarget):
+		address, ok := nodeAddress(node)
+		if !ok {
+			err = fmt.Errorf("select a resource or module to copy its address")
+			break
+		}
+		what, text = "address", address
+		if key.Matches(msg, k.YankTarget) {
+			what, text = "-target argument", "-target="+shellQuote(address)
+			// The argument only works in the root module the resource was
+			// planned in, so a multi-root plan names it
+			if root := nodeRoot(node); root != "" {
+				what += " for " + root
+			}
+		}
+	case key.Matches(msg, k.YankStateShow):
+		what = "state show command"
+		if node.IsGroup() {
+			err = fmt.Errorf("select a resource to copy its state show command")
+			break
+		}
+		text = m.stateShowCommand(node.Resource.Root, node.Resource.Address)
+	case key.Matches(msg, k.YankBefore, k.YankAfter, k.YankDiff):
+		if node.IsGroup() {
+			err = fmt.Errorf("select a resource to copy its values")
+			break
+		}
+		changeDiff := m.computeDiff(node.Resource)
+		switch {
+		case key.Matches(msg, k.YankBefore):
+			what = "values before the change"
+			text, err = marshalJSON(changeDiff.BeforeValue())
+		case key.Matches(msg, k.YankAfter):
+			what = "values after the change"
+			text, err = marshalJSON(changeDiff.AfterValue())
+		default:
+			what = "changes"
+			text, err = marshalJSON(m.diffJSON(node, changeDiff))
+		}
+	default:
+		return m, nil
+	}
+
+	if err != nil {
+		m.notice = "Nothing copied: " + err.Error()
+		return m, nil
+	}
+	return m, copyToClipboard(text, what)
+}
+
+// nodeAddress returns the address of a resource or module node; file and
+// root groups have none
+func nodeAddress(node *TreeNode) (string, bool) {
+	switch node.Kind {
+	case NodeResource:
+		return node.Resource.Address, true
+	case NodeModule:
+		return node.Label, true
+	}
+	return "", false
+}
+
+// nodeRoot returns the root module directory a resource or module node was
+// planned in; it is empty unless the plan has multiple roots
+func nodeRoot(node *TreeNode) string {
+	if node.Kind == NodeResource {
+		return node.Resource.Root
+	}
+	for n := node; n != nil; n = n.Parent {
+		if n.Kind == NodeRoot {
+			return n.Label
+		}
+	}
+	return ""
+}
+
+// stateShowCommand returns the command showing a resource in the state of
+// the root module it was planned in
+func (m Model) stateShowCommand(root, address string) string {
+	tfCmd := m.tfCmd
+	if tfCmd == "" {
+		tfCmd = "terraform"
+	}
+	if root != "" {
+		tfCmd += " -chdir=" + shellQuote(root)
+	}
+	return tfCmd + " state show " + shellQuote(address)
+}
+
+// shellQuote quotes an argument for POSIX shells unless it is plain, e.g.
+// addresses with for_each keys like aws_instance.web["a"]
+func shellQuote(s string) string {
+	if plainArgument.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// jsonChange is a changed attribute in the copied JSON
+type jsonChange struct {
+	Path   string      `json:"path"`
+	Change string      `json:"change"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// diffJSON returns the changed attributes of a resource as shown in the
+// detail pane, with sensitive values masked
+func (m Model) diffJSON(node *TreeNode, changeDiff *diff.Node) interface{} {
+	changes := make([]jsonChange, 0)
+	for _, change := range changeDiff.Changes() {
+		if change.Ignored && !m.showNoise {
+			continue
+		}
+		changes = append(changes, jsonChange{
+			Path:   change.Path,
+			Change: changeName(change.Kind),
+			Before: change.BeforeValue(),
+			After:  change.AfterValue(),
+		})
+	}
+	return struct {
+		Address string       `json:"address"`
+		Action  string       `json:"action"`
+		Changes []jsonChange `json:"changes"`
+	}{node.Resource.Address, string(node.Resource.Action), changes}
+}
+
+// changeName returns the name of a diff kind in the copied JSON
+func changeName(kind diff.Kind) string {
+	switch kind {
+	case diff.Added:
+		return "added"
+	case diff.Removed:
+		return "removed"
+	case diff.Modified:
+		return "modified"
+	default:
+		return "unchanged"
+	}
+}
+
+// marshalJSON formats a value as indented JSON
+func marshalJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// copyToClipboard copies text in the background; if no clipboard command
+// works, the text goes through the terminal (see copyThroughTerminal)
+func copyToClipboard(text, what string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+		defer cancel()
+		method, err := clipboard.Copy(ctx, text)
+		if errors.Is(err, clipboard.ErrNoCommand) {
+			return terminalCopyMsg{text: text, what: what}
+		}
+		return copiedMsg{what: what, method: method, err: err}
+	}
+}
+
+// copyThroughTerminal writes the OSC 52 sequence for msg. Bubble Tea owns
+// the output, and its renderer would cut the sequence short inside View, so
+// the program is paused for the write like for an external command.
+func copyThroughTerminal(msg terminalCopyMsg) tea.Cmd {
+	write := &terminalWrite{sequence: clipboard.Sequence(msg.text)}
+	return tea.Exec(write, func(err error) tea.Msg {
+		return copiedMsg{what: msg.what, method: clipboard.MethodOSC52, err: err}
+	})
+}
+
+// terminalWrite is a tea.ExecCommand writing an escape sequence to the
+// program's output
+type terminalWrite struct {
+	sequence string
+	output   io.Writer
+}
+
+func (w *terminalWrite) Run() error {
+	if _, err := io.WriteString(w.output, w.sequence); err != nil {
+		return fmt.Errorf("failed to write to the terminal: %w", err)
+	}
+	return nil
+}
+
+func (w *terminalWrite) SetStdin(io.Reader)      {}
+func (w *terminalWrite) SetStdout(out io.Writer) { w.output = out }
+func (w *terminalWrite) SetStderr(io.Writer)     {}
+
+// copied reports the result of copying in the help line. Pausing the program
+// for OSC 52 turns mouse reporting off, so it is turned back on.
+func (m Model) copied(msg copiedMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg.method == clipboard.MethodOSC52 && !m.disableMouse {
+		cmd = tea.EnableMouseCellMotion
+	}
+	switch {
+	case msg.err != nil:
+		m.notice = fmt.Sprintf("Failed to copy the %s: %v", msg.what, msg.err)
+	case msg.method == clipboard.MethodOSC52:
+		m.notice = fmt.Sprintf("Copied the %s through the terminal (OSC 52)", msg.what)
+	default:
+		m.notice = fmt.Sprintf("Copied the %s to the clipboard", msg.what)
+	}
+	return m, cmd
+}
@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/tplan/internal/clipboard"
+	"github.com/yourusername/tplan/internal/ignore"
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"aws_instance.web", "aws_instance.web"},
+		{"module.app-1.aws_instance.web", "module.app-1.aws_instance.web"},
+		{`aws_instance.web["a"]`, `'aws_instance.web["a"]'`},
+		{"aws_instance.web[\"it's\"]", `'aws_instance.web["it'\''s"]'`},
+		{"envs/prod eu", "'envs/prod eu'"},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.arg); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestStateShowCommand(t *testing.T) {
+	tests := []struct {
+		tfCmd   string
+		root    string
+		address string
+		want    string
+	}{
+		{"", "", "aws_instance.web", "terraform state show aws_instance.web"},
+		{"tofu", "", `aws_instance.web["a"]`, `tofu state show 'aws_instance.web["a"]'`},
+		{"terraform", "envs/prod", "aws_instance.web", "terraform -chdir='envs/prod' state show aws_instance.web"},
+	}
+	for _, tt := range tests {
+		m := Model{tfCmd: tt.tfCmd}
+		if got := m.stateShowCommand(tt.root, tt.address); got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestNodeRoot(t *testing.T) {
+	root := newGroupNode(NodeRoot, "envs/prod")
+	module := newGroupNode(NodeModule, "module.app")
+	root.addChild(module)
+	resource := newResourceNode(models.ResourceChange{Root: "envs/prod", Address: "module.app.aws_instance.web"})
+	module.addChild(resource)
+
+	tests := []struct {
+		name string
+		node *TreeNode
+		want string
+	}{
+		{"resource", resource, "envs/prod"},
+		{"module", module, "envs/prod"},
+		{"single root resource", newResourceNode(models.ResourceChange{Address: "aws_instance.web"}), ""},
+		{"single root module", newGroupNode(NodeModule, "module.app"), ""},
+	}
+	for _, tt := range tests {
+		if got := nodeRoot(tt.node); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	res := update("aws_db_instance.main", "password", "tags_all", "engine_version")
+	res.Change.AfterSensitive = map[string]interface{}{"password": true}
+	rules := ignore.Rules{{ResourceType: "*", Attribute: "tags_all"}}
+
+	tests := []struct {
+		name      string
+		showNoise bool
+		want      []string
+	}{
+		{"noise hidden", false, []string{"engine_version", "password"}},
+		{"noise shown", true, []string{"engine_version", "password", "tags_all"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(&models.PlanResult{Resources: []models.ResourceChange{res}}, Options{IgnoreRules: rules, ShowNoise: tt.showNoise})
+			node := &TreeNode{Kind: NodeResource, Resource: res}
+			text, err := marshalJSON(m.diffJSON(node, m.computeDiff(res)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got struct {
+				Address string
+				Action  string
+				Changes []jsonChange
+			}
+			if err := json.Unmarshal([]byte(text), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Address != res.Address || got.Action != "update" {
+				t.Errorf("address %q, action %q", got.Address, got.Action)
+			}
+			paths := make([]string, 0)
+			for _, change := range got.Changes {
+				paths = append(paths, change.Path)
+				if change.Path == "password" && change.After == "b" {
+					t.Error("the sensitive value was copied")
+				}
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("changed paths %v, want %v", paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyThroughTerminal(t *testing.T) {
+	var out bytes.Buffer
+	write := &terminalWrite{sequence: clipboard.Sequence("aws_instance.web")}
+	write.SetStdout(&out)
+	if err := write.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != write.sequence {
+		t.Errorf("wrote %q, want the sequence", out.String())
+	}
+
+	tests := []struct {
+		name         string
+		msg          copiedMsg
+		disableMouse bool
+		wantNotice   string
+		wantCmd      bool
+	}{
+		{"command", copiedMsg{what: "address", method: "xclip"}, false, "Copied the address to the clipboard", false},
+		{"terminal", copiedMsg{what: "address", method: clipboard.MethodOSC52}, false, "through the terminal", true},
+		{"terminal without mouse", copiedMsg{what: "address", method: clipboard.MethodOSC52}, true, "through the terminal", false},
+		{"failure", copiedMsg{what: "address", method: clipboard.MethodOSC52, err: errors.New("broken pipe")}, false, "Failed to copy the address: broken pipe", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, cmd := Model{disableMouse: tt.disableMouse}.copied(tt.msg)
+			if notice := model.(Model).notice; !strings.Contains(notice, tt.wantNotice) {
+				t.Errorf("notice %q, want %q", notice, tt.wantNotice)
+			}
+			// The mouse is turned back on after pausing the program
+			if (cmd != nil) != tt.wantCmd {
+				t.Errorf("got command %t, want %t", cmd != nil, tt.wantCmd)
+			}
+		})
+	}
+}
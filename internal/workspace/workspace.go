@@ -0,0 +1,211 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+// DefaultWorkspace is the workspace terraform uses when none is selected
+const DefaultWorkspace = "default"
+
+// Info describes the terraform context a plan was made in
+type Info struct {
+	Dir       string
+	Workspace string
+
+	// Backend is the backend type (e.g., "s3"), or "local" if none is configured
+	Backend string
+
+	// BackendKey identifies the state within the backend (e.g., "bucket/prod/network.tfstate")
+	BackendKey string
+
+	// Binary and Version identify the terraform-compatible tool that made the plan
+	Binary  string
+	Version string
+
+	// VarFiles lists the variable files in use, including auto-loaded ones
+	VarFiles []string
+
+	// Branch is the current git branch, if dir is in a git repository
+	Branch string
+}
+
+// backendState is the part of .terraform/terraform.tfstate that describes the backend
+type backendState struct {
+	Backend *struct {
+		Type   string                 `json:"type"`
+		Config map[string]interface{} `json:"config"`
+	} `json:"backend"`
+}
+
+// Detect inspects a root module directory and the plan arguments used for it
+func Detect(dir string, planArgs []string) Info {
+	info := Info{
+		Dir:       dir,
+		Workspace: DefaultWorkspace,
+		Backend:   "local",
+	}
+
+	dataDir := terraformDataDir(dir)
+
+	// TF_WORKSPACE overrides the workspace selected with "terraform workspace select"
+	if ws := os.Getenv("TF_WORKSPACE"); ws != "" {
+		info.Workspace = ws
+	} else if content, err := os.ReadFile(filepath.Join(dataDir, "environment")); err == nil {
+		if ws := strings.TrimSpace(string(content)); ws != "" {
+			info.Workspace = ws
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dataDir, "terraform.tfstate")); err == nil {
+		var state backendState
+		if json.Unmarshal(content, &state) == nil && state.Backend != nil && state.Backend.Type != "" {
+			info.Backend = state.Backend.Type
+			info.BackendKey = backendKey(state.Backend.Config)
+		}
+	}
+
+	info.VarFiles = varFiles(dir, planArgs)
+	info.Branch = gitBranch(dir)
+
+	return info
+}
+
+// terraformDataDir returns the .terraform directory of a root module. Terragrunt
+// initializes terraform in a copy of the source inside .terragrunt-cache.
+func terraformDataDir(dir string) string {
+	if env := os.Getenv("TF_DATA_DIR"); env != "" {
+		if filepath.IsAbs(env) {
+			return env
+		}
+		return filepath.Join(dir, env)
+	}
+
+	dataDir := filepath.Join(dir, ".terraform")
+	if _, err := os.Stat(dataDir); err == nil {
+		return dataDir
+	}
+
+	for _, pattern := range []string{"*/*/.terraform", "*/*/*/.terraform", "*/*/*/*/.terraform"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, ".terragrunt-cache", pattern))
+		if len(matches) > 0 {
+			return matches[0]
+		}
+	}
+	return dataDir
+}
+
+// backendKey summarizes where a backend keeps the state
+func backendKey(config map[string]interface{}) string {
+	str := func(key string) string {
+		if v, ok := config[key].(string); ok {
+			return v
+		}
+		return ""
+	}
+
+	switch {
+	case str("bucket") != "" && str("key") != "": // s3, oss
+		return str("bucket") + "/" + str("key")
+	case str("bucket") != "" && str("prefix") != "": // gcs
+		return str("bucket") + "/" + str("prefix")
+	case str("container_name") != "" && str("key") != "": // azurerm
+		return str("container_name") + "/" + str("key")
+	case str("organization") != "": // remote, cloud
+		if workspaces, ok := config["workspaces"].(map[string]interface{}); ok {
+			for _, key := range []string{"name", "prefix"} {
+				if name, ok := workspaces[key].(string); ok && name != "" {
+					return str("organization") + "/" + name
+				}
+			}
+		}
+		return str("organization")
+	case str("path") != "": // local
+		return str("path")
+	case str("key") != "":
+		return str("key")
+	}
+	return ""
+}
+
+// varFiles returns the -var-file arguments followed by the variable files
+// terraform loads automatically
+func varFiles(dir string, planArgs []string) []string {
+	files := make([]string, 0)
+	for i, arg := range planArgs {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		switch {
+		case strings.HasPrefix(name, "var-file="):
+			files = append(files, strings.TrimPrefix(name, "var-file="))
+		case name == "var-file" && i+1 < len(planArgs):
+			files = append(files, planArgs[i+1])
+		}
+	}
+
+	// terraform.tfvars first, then *.auto.tfvars in lexical order (as returned by Glob)
+	auto := make([]string, 0)
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			auto = append(auto, name)
+		}
+	}
+	for _, pattern := range []string{"*.auto.tfvars", "*.auto.tfvars.json"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, match := range matches {
+			auto = append(auto, filepath.Base(match))
+		}
+	}
+	return append(files, auto...)
+}
+
+// gitBranch returns the current git branch of dir, or "" outside a repository
+func gitBranch(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// IsProduction returns true if the workspace name matches the production pattern
+func (i Info) IsProduction(pattern *regexp.Regexp) bool {
+	return pattern != nil && pattern.MatchString(i.Workspace)
+}
+
+// ConfirmationWord returns what the user has to type to apply a destructive
+// plan: the workspaces of the roots with destructive changes, separated by
+// commas. Plans without workspace information fall back to "yes".
+func ConfirmationWord(plan *models.PlanResult, infos []Info) string {
+	roots := make(map[string]bool)
+	for _, res := range plan.DestructiveResources() {
+		roots[res.Root] = true
+	}
+
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, info := range infos {
+		// Single-root plans leave Root empty
+		if len(plan.Roots) > 0 && !roots[info.Dir] {
+			continue
+		}
+		if !seen[info.Workspace] {
+			seen[info.Workspace] = true
+			names = append(names, info.Workspace)
+		}
+	}
+	if len(names) == 0 {
+		return "yes"
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,76 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/yourusername/tplan/internal/models"
+)
+
+func TestConfirmationWord(t *testing.T) {
+	resource := func(root string, action models.ChangeAction) models.ResourceChange {
+		return models.ResourceChange{Address: "aws_instance.web", Root: root, Action: action}
+	}
+
+	tests := []struct {
+		name  string
+		roots []string
+		res   []models.ResourceChange
+		infos []Info
+		want  string
+	}{
+		{
+			name: "no workspace information",
+			res:  []models.ResourceChange{resource("", models.ActionDelete)},
+			want: "yes",
+		},
+		{
+			name:  "single root",
+			res:   []models.ResourceChange{resource("", models.ActionReplace)},
+			infos: []Info{{Dir: ".", Workspace: "prod"}},
+			want:  "prod",
+		},
+		{
+			name:  "roots with destructive changes only",
+			roots: []string{"network", "app", "dns"},
+			res: []models.ResourceChange{
+				resource("network", models.ActionDelete),
+				resource("app", models.ActionUpdate),
+				resource("dns", models.ActionReplace),
+			},
+			infos: []Info{
+				{Dir: "network", Workspace: "prod-eu"},
+				{Dir: "app", Workspace: "staging"},
+				{Dir: "dns", Workspace: "prod-us"},
+			},
+			want: "prod-eu,prod-us",
+		},
+		{
+			name:  "shared workspace named once",
+			roots: []string{"network", "app"},
+			res: []models.ResourceChange{
+				resource("network", models.ActionDelete),
+				resource("app", models.ActionDelete),
+			},
+			infos: []Info{
+				{Dir: "network", Workspace: "prod"},
+				{Dir: "app", Workspace: "prod"},
+			},
+			want: "prod",
+		},
+		{
+			name:  "no destructive changes",
+			roots: []string{"app"},
+			res:   []models.ResourceChange{resource("app", models.ActionCreate)},
+			infos: []Info{{Dir: "app", Workspace: "prod"}},
+			want:  "yes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &models.PlanResult{Roots: tt.roots, Resources: tt.res}
+			if got := ConfirmationWord(plan, tt.infos); got != tt.want {
+				t.Errorf("ConfirmationWord() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
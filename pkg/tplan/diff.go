@@ -0,0 +1,39 @@
+package tplan
+
+import (
+	"github.com/yourusername/tplan/internal/diff"
+)
+
+// Diff types
+type (
+	// DiffNode is an attribute in the diff tree of a change. Nested values
+	// are its Children.
+	DiffNode = diff.Node
+
+	// DiffKind describes how a value changed
+	DiffKind = diff.Kind
+
+	// ResourceDiff describes how the planned change of a resource differs
+	// between two plans
+	ResourceDiff = diff.ResourceDiff
+)
+
+// Kinds of a DiffNode or ResourceDiff
+const (
+	Unchanged = diff.Unchanged
+	Added     = diff.Added
+	Removed   = diff.Removed
+	Modified  = diff.Modified
+)
+
+// DiffChange computes the attribute diff tree of a change, including values
+// nested in JSON and YAML documents, unknown and sensitive values
+func DiffChange(change Change) *DiffNode {
+	return diff.Compute(change)
+}
+
+// ComparePlans compares the resource changes of two plans, e.g. before and
+// after a code change. Results are sorted by root and address.
+func ComparePlans(oldPlan, newPlan *PlanResult) []ResourceDiff {
+	return diff.ComparePlans(oldPlan, newPlan)
+}
@@ -0,0 +1,26 @@
+// Package tplan is the public Go API of tplan. It loads Terraform and
+// OpenTofu plans, diffs resource changes, renders reports and evaluates
+// policies, the same way the tplan command does.
+//
+//	plan, err := tplan.LoadPlanFile(ctx, "plan.tfplan", tplan.Options{Dir: "envs/prod"})
+//	if err != nil {
+//		return err
+//	}
+//	violations, err := tplan.EvaluatePolicies([]string{"policy.yaml"}, plan)
+//	report := tplan.MarkdownReport(plan, tplan.ReportOptions{})
+//
+// # Compatibility
+//
+// This package follows semantic versioning of the module. Within a major
+// version, exported identifiers of this package are not removed or changed
+// incompatibly: fields may be added to structs, constants and functions may
+// be added, and report output may change. The packages below internal/ are
+// not covered and may change at any time; use this package instead.
+//
+// The model types are aliases of tplan's own types, so values can be passed
+// between this package and tplan without conversion.
+package tplan
+
+// APIVersion is the major version of this API. It changes only with
+// incompatible changes, together with the module's major version.
+const APIVersion = 1
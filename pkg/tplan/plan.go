@@ -0,0 +1,159 @@
+package tplan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/tplan/internal/models"
+	"github.com/yourusername/tplan/internal/parser"
+)
+
+// Plan model types
+type (
+	// PlanResult is a parsed plan with its resource changes and summary
+	PlanResult = models.PlanResult
+
+	// PlanSummary counts the planned changes by action
+	PlanSummary = models.PlanSummary
+
+	// ResourceChange is the planned change of one resource
+	ResourceChange = models.ResourceChange
+
+	// Change holds the before and after values of a resource or output
+	Change = models.Change
+
+	// ChangeAction is the action planned for a resource
+	ChangeAction = models.ChangeAction
+
+	// OutputChange is the planned change of a root module output
+	OutputChange = models.OutputChange
+
+	// PlanError and PlanWarning are diagnostics reported with the plan
+	PlanError   = models.PlanError
+	PlanWarning = models.PlanWarning
+
+	// DriftInfo is the git information of a resource's declaration
+	DriftInfo = models.DriftInfo
+)
+
+// Actions of a ResourceChange
+const (
+	ActionCreate  = models.ActionCreate
+	ActionUpdate  = models.ActionUpdate
+	ActionDelete  = models.ActionDelete
+	ActionReplace = models.ActionReplace
+	ActionRead    = models.ActionRead
+	ActionNoOp    = models.ActionNoOp
+)
+
+// Options configure how terraform is run for binary plans and directories
+type Options struct {
+	// Binary is the terraform-compatible command to run; empty looks for
+	// terraform, then tofu
+	Binary string
+
+	// Dir is the initialized root module directory terraform runs in;
+	// empty means the working directory
+	Dir string
+
+	// PlanArgs are passed to terraform plan, e.g. -var-file=prod.tfvars
+	PlanArgs []string
+
+	// Env is added to the environment of terraform
+	Env []string
+}
+
+// ParsePlan parses the output of 'terraform show -json'
+func ParsePlan(data []byte) (*PlanResult, error) {
+	return parser.NewParser().ParseBytes(data)
+}
+
+// MergePlans combines the plans of several root modules into one plan whose
+// resources record their root directory
+func MergePlans(roots []string, plans []*PlanResult) *PlanResult {
+	return models.MergePlans(roots, plans)
+}
+
+// LoadPlanFile loads a plan file, either the output of 'terraform show -json'
+// or a binary plan. Binary plans are converted with 'terraform show -json' in
+// opts.Dir, which must be the initialized directory the plan was made in.
+func LoadPlanFile(ctx context.Context, path string, opts Options) (*PlanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return ParsePlan(data)
+	}
+
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	output, err := opts.run(ctx, "show", "-json", absolute)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlan(output)
+}
+
+// PlanDir runs terraform plan in an initialized root module directory and
+// returns the parsed plan. The binary plan is written to a private temporary
+// file and removed afterwards. Terraform runs without prompting for input.
+func PlanDir(ctx context.Context, dir string, opts Options) (*PlanResult, error) {
+	tempDir, err := os.MkdirTemp("", "tplan-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	planFile := filepath.Join(tempDir, "plan.tfplan")
+	opts.Dir = dir
+	args := append([]string{"plan", "-input=false", "-out=" + planFile}, opts.PlanArgs...)
+	if _, err := opts.run(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	output, err := opts.run(ctx, "show", "-json", planFile)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlan(output)
+}
+
+// run runs terraform with args in opts.Dir and returns its stdout. Stderr is
+// included in the returned error.
+func (opts Options) run(ctx context.Context, args ...string) ([]byte, error) {
+	binary := opts.Binary
+	if binary == "" {
+		for _, candidate := range []string{"terraform", "tofu"} {
+			if _, err := exec.LookPath(candidate); err == nil {
+				binary = candidate
+				break
+			}
+		}
+		if binary == "" {
+			return nil, fmt.Errorf("neither terraform nor tofu was found in PATH")
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Dir = opts.Dir
+	cmd.Env = append(os.Environ(), opts.Env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s %s failed: %w: %s", binary, args[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s %s failed: %w", binary, args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
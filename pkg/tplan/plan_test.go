@@ -0,0 +1,143 @@
+package tplan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const planJSON = `{
+  "format_version": "1.1",
+  "resource_changes": [{
+    "address": "aws_s3_bucket.logs",
+    "mode": "managed",
+    "type": "aws_s3_bucket",
+    "name": "logs",
+    "change": {"actions": ["create"], "before": null, "after": {"bucket": "logs"}}
+  }]
+}`
+
+// stubTerraform writes a shell script standing in for terraform that logs
+// its arguments, one per line, before running script
+func stubTerraform(t *testing.T, script string) (binary, log string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	binary = filepath.Join(dir, "terraform")
+	log = filepath.Join(dir, "args.log")
+	text := "#!/bin/sh\nfor arg in \"$@\"; do echo \"$arg\" >> '" + log + "'; done\n" + script
+	if err := os.WriteFile(binary, []byte(text), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return binary, log
+}
+
+func TestLoadPlanFile(t *testing.T) {
+	showJSON := "cat <<'EOF'\n" + planJSON + "\nEOF\n"
+
+	tests := []struct {
+		name     string
+		contents string
+		script   string
+		wantArgs bool
+		wantErr  string
+	}{
+		{
+			name:     "json plan is parsed without terraform",
+			contents: "\n  " + planJSON,
+			script:   "exit 1\n",
+		},
+		{
+			name:     "binary plan is converted",
+			contents: "PK\x03\x04",
+			script:   showJSON,
+			wantArgs: true,
+		},
+		{
+			name:     "failed conversion includes stderr",
+			contents: "PK\x03\x04",
+			script:   "echo 'Error: plan file was created by a newer version' >&2\nexit 1\n",
+			wantErr:  "newer version",
+		},
+		{
+			name:     "invalid json",
+			contents: "{",
+			wantErr:  "invalid input format",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binary, log := stubTerraform(t, tt.script)
+			path := filepath.Join(t.TempDir(), "plan.tfplan")
+			if err := os.WriteFile(path, []byte(tt.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			plan, err := LoadPlanFile(context.Background(), path, Options{Binary: binary, Dir: t.TempDir()})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Resources) != 1 || plan.Resources[0].Action != ActionCreate {
+				t.Errorf("resources %+v, want one create", plan.Resources)
+			}
+
+			args, _ := os.ReadFile(log)
+			if want := "show\n-json\n" + path + "\n"; tt.wantArgs && string(args) != want {
+				t.Errorf("terraform ran with %q, want %q", args, want)
+			} else if !tt.wantArgs && len(args) != 0 {
+				t.Errorf("terraform ran with %q for a JSON plan", args)
+			}
+		})
+	}
+}
+
+func TestPlanDir(t *testing.T) {
+	binary, log := stubTerraform(t, `
+if [ "$1" = show ]; then
+  [ "$TF_WORKSPACE" = prod ] || exit 1
+  cat <<'EOF'
+`+planJSON+`
+EOF
+fi
+`)
+	dir := t.TempDir()
+
+	plan, err := PlanDir(context.Background(), dir, Options{
+		Binary:   binary,
+		PlanArgs: []string{"-var-file=prod.tfvars"},
+		Env:      []string{"TF_WORKSPACE=prod"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Summary.ToCreate != 1 {
+		t.Errorf("summary %+v, want one create", plan.Summary)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(args) != 7 || args[0] != "plan" || args[1] != "-input=false" || args[3] != "-var-file=prod.tfvars" || args[4] != "show" {
+		t.Fatalf("terraform ran with %q", args)
+	}
+	planFile := strings.TrimPrefix(args[2], "-out=")
+	if args[6] != planFile {
+		t.Errorf("showed %q, want the plan file %q", args[6], planFile)
+	}
+	if _, err := os.Stat(filepath.Dir(planFile)); !os.IsNotExist(err) {
+		t.Errorf("temporary plan directory was not removed: %v", err)
+	}
+}
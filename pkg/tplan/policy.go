@@ -0,0 +1,39 @@
+package tplan
+
+import (
+	"github.com/yourusername/tplan/internal/policy"
+)
+
+// Policy types
+type (
+	// Policy is a named set of rules loaded from a policy file
+	Policy = policy.Policy
+
+	// PolicyRule flags planned actions on matching resources
+	PolicyRule = policy.Rule
+
+	// Violation is a rule broken by a plan. Violations with warning
+	// severity don't fail a check; see Violation.IsError.
+	Violation = policy.Violation
+)
+
+// Rule severities
+const (
+	SeverityError   = policy.SeverityError
+	SeverityWarning = policy.SeverityWarning
+)
+
+// LoadPolicy reads a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	return policy.LoadFile(path)
+}
+
+// ParsePolicy parses a policy in the YAML policy file format
+func ParsePolicy(data []byte) (*Policy, error) {
+	return policy.Parse(data)
+}
+
+// EvaluatePolicies loads the policy files and evaluates them against the plan
+func EvaluatePolicies(paths []string, plan *PlanResult) ([]Violation, error) {
+	return policy.EvaluateAll(paths, plan)
+}
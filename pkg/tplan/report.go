@@ -0,0 +1,102 @@
+package tplan
+
+import (
+	"io"
+	"text/template"
+
+	"github.com/yourusername/tplan/internal/ignore"
+	"github.com/yourusername/tplan/internal/report"
+)
+
+// IgnoreRules hide attribute changes that churn on every plan
+type IgnoreRules = ignore.Rules
+
+// IgnoreRule matches attributes of resource types by glob
+type IgnoreRule = ignore.Rule
+
+// ParseIgnoreRules reads rules in the .tplanignore format
+func ParseIgnoreRules(r io.Reader) (IgnoreRules, error) {
+	return ignore.Parse(r)
+}
+
+// LoadIgnoreFile reads rules from a .tplanignore file. A missing file has
+// no rules.
+func LoadIgnoreFile(path string) (IgnoreRules, error) {
+	return ignore.LoadFile(path)
+}
+
+// ReportOptions configure report rendering
+type ReportOptions struct {
+	// IncludeDrift adds the git information of resources, if the plan has it
+	IncludeDrift bool
+
+	// IgnoreRules hide matching attribute changes, or mark them with ShowNoise
+	IgnoreRules IgnoreRules
+	ShowNoise   bool
+}
+
+// MarkdownReport renders the plan as a Markdown report
+func MarkdownReport(plan *PlanResult, opts ReportOptions) string {
+	return newGenerator(plan, opts).GenerateMarkdown()
+}
+
+// WriteMarkdownReport renders the plan as a Markdown report into a file
+func WriteMarkdownReport(path string, plan *PlanResult, opts ReportOptions) error {
+	return newGenerator(plan, opts).WriteToFile(path)
+}
+
+// HTMLReport renders the plan as a self-contained HTML page with navigation,
+// search, action filters and side-by-side attribute diffs
+func HTMLReport(plan *PlanResult, opts ReportOptions) string {
+	return newGenerator(plan, opts).GenerateHTML()
+}
+
+// newGenerator returns the report generator for the options
+func newGenerator(plan *PlanResult, opts ReportOptions) *report.Generator {
+	return report.NewGenerator(plan, opts.IncludeDrift).WithIgnoreRules(opts.IgnoreRules, opts.ShowNoise)
+}
+
+// PRCommentOptions configure a pull request comment
+type PRCommentOptions = report.CommentOptions
+
+// PRComment renders the plan as a compact Markdown pull request comment,
+// truncated to opts.MaxLength. The comment starts with PRCommentMarker(opts.ID).
+func PRComment(plan *PlanResult, opts ReportOptions, comment PRCommentOptions) string {
+	return newGenerator(plan, opts).GeneratePRComment(comment)
+}
+
+// PRCommentMarker returns the hidden marker that identifies a comment, so
+// an existing comment can be found and updated instead of adding another
+func PRCommentMarker(id string) string {
+	return report.CommentMarker(id)
+}
+
+// TemplateData is the data report templates are executed with
+type TemplateData = report.TemplateData
+
+// TemplateResource is a changing resource with its attribute diff, as seen by templates
+type TemplateResource = report.TemplateResource
+
+// RootSummary is the action counts of one root module
+type RootSummary = report.RootSummary
+
+// DefaultReportTemplate returns the source of the built-in Markdown report template
+func DefaultReportTemplate() string {
+	return report.DefaultTemplate()
+}
+
+// ParseReportTemplate parses a report template with tplan's helper functions
+func ParseReportTemplate(name, text string) (*template.Template, error) {
+	return report.ParseTemplate(name, text)
+}
+
+// LoadReportTemplate reads and parses a report template file
+func LoadReportTemplate(path string) (*template.Template, error) {
+	return report.LoadTemplate(path)
+}
+
+// TemplateReport renders the plan with a template from ParseReportTemplate
+// or LoadReportTemplate
+func TemplateReport(plan *PlanResult, opts ReportOptions, tmpl *template.Template) (string, error) {
+	return newGenerator(plan, opts).GenerateTemplate(tmpl)
+}